@@ -3,22 +3,55 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"dbcat/database"
+	"dbcat/store"
 )
 
 // App struct
 type App struct {
-	ctx context.Context
+	ctx      context.Context
+	history  *store.HistoryStore
+	snippets *store.SnippetStore
+	metadata *database.MetadataCache
+	jobs     *jobManager
+	watches  *watchManager
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	app := &App{jobs: newJobManager(), watches: newWatchManager()}
+	app.metadata = app.newMetadataCache()
+	return app
 }
 
 // startup is called when the app starts. The context is saved
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	dir, err := store.DefaultDir()
+	if err != nil {
+		log.Printf("获取数据目录失败: %v", err)
+		return
+	}
+	if a.history, err = store.OpenHistoryStore(filepath.Join(dir, "history.db")); err != nil {
+		log.Printf("打开查询历史失败: %v", err)
+	}
+	if a.snippets, err = store.OpenSnippetStore(filepath.Join(dir, "snippets.json")); err != nil {
+		log.Printf("打开片段库失败: %v", err)
+	}
+}
+
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	a.stopAllWatches()
+	if a.history != nil {
+		a.history.Close()
+	}
 }
 
 // CreateDatabase 创建新数据库
@@ -145,6 +178,22 @@ func (a *App) GetTables(config database.DatabaseConfig, dbName, schema string) (
 	return list,nil
 }
 
+// GetTableStats 获取表的大小、行数估算等存储统计
+func (a *App) GetTableStats(config database.DatabaseConfig, dbName, schema string) ([]database.TableStats, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.GetTableStats(dbName, schema)
+}
+
 // GetTableStructure 获取表结构
 func (a *App) GetTableStructure(config database.DatabaseConfig, dbName, tableName string) ([]database.ColumnInfo, error) {
 	factory := database.NewDBFactory()
@@ -170,6 +219,11 @@ func (a *App) GetTableStructure(config database.DatabaseConfig, dbName, tableNam
 
 // GetTableData 获取表数据
 func (a *App) GetTableData(config database.DatabaseConfig, dbName, tableName string, offset, limit int) ([]map[string]string, error) {
+	return a.GetTableDataFiltered(config, dbName, tableName, "", offset, limit)
+}
+
+// GetTableDataFiltered 按过滤条件获取表数据，where 为过滤条件（如 BuildJSONFilter 生成的条件），为空时不过滤
+func (a *App) GetTableDataFiltered(config database.DatabaseConfig, dbName, tableName, where string, offset, limit int) ([]map[string]string, error) {
 	factory := database.NewDBFactory()
 	adapter, err := factory.CreateAdapter(config)
 	if err != nil {
@@ -181,7 +235,7 @@ func (a *App) GetTableData(config database.DatabaseConfig, dbName, tableName str
 		return nil, fmt.Errorf("连接数据库失败: %v", err)
 	}
 
-	list,err:= adapter.QueryTableData(dbName, tableName, offset, limit)
+	list,err:= adapter.QueryTableData(dbName, tableName, where, offset, limit)
 	if err!=nil{
 		return nil,err
 	}
@@ -193,6 +247,11 @@ func (a *App) GetTableData(config database.DatabaseConfig, dbName, tableName str
 
 // GetTableRowCount 获取表行数
 func (a *App) GetTableRowCount(config database.DatabaseConfig, dbName, tableName string) (int64, error) {
+	return a.GetTableRowCountFiltered(config, dbName, tableName, "")
+}
+
+// GetTableRowCountFiltered 按过滤条件获取表行数，where 与 GetTableDataFiltered 相同
+func (a *App) GetTableRowCountFiltered(config database.DatabaseConfig, dbName, tableName, where string) (int64, error) {
 	factory := database.NewDBFactory()
 	adapter, err := factory.CreateAdapter(config)
 	if err != nil {
@@ -204,11 +263,76 @@ func (a *App) GetTableRowCount(config database.DatabaseConfig, dbName, tableName
 		return 0, fmt.Errorf("连接数据库失败: %v", err)
 	}
 
-	return adapter.GetTableRowCount(dbName, tableName)
+	return adapter.GetTableRowCount(dbName, tableName, where)
 }
 
 // ExecuteQuery 执行SQL查询
 func (a *App) ExecuteQuery(config database.DatabaseConfig, dbName, sql string) ([]map[string]string, error) {
+	started := time.Now()
+	result, rows, err := a.executeQuery(config, dbName, sql)
+	a.recordHistory(config, dbName, sql, started, rows, err)
+	if database.IsDDL(database.DialectOf(config.Type), sql) {
+		a.metadata.Invalidate(config)
+	}
+	return result, err
+}
+
+// executeQuery 执行SQL查询，不记录历史，返回结果集及返回或影响的行数
+func (a *App) executeQuery(config database.DatabaseConfig, dbName, sql string) ([]map[string]string, int64, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, 0, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	if database.IsDML(database.DialectOf(config.Type), sql) {
+		affected, err := execAffected(adapter, dbName, sql, nil)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []map[string]string{}, affected, nil
+	}
+	result, err := adapter.ExecuteQuery(dbName, sql)
+	return result, int64(len(result)), err
+}
+
+// execAffected 执行单条修改语句，返回影响的行数
+func execAffected(adapter database.DBAdapter, dbName, query string, args []interface{}) (int64, error) {
+	ctx := context.Background()
+	conn, err := adapter.Conn(ctx, dbName)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(ctx, strings.TrimRight(strings.TrimSpace(query), ";"), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ExecuteQueryResult 执行单条SQL查询，返回带列类型的结果，JSON 列的值为 JSON 本身而不是文本
+func (a *App) ExecuteQueryResult(config database.DatabaseConfig, dbName, sql string) (*database.ResultSet, error) {
+	started := time.Now()
+	result, err := a.executeQueryResult(config, dbName, sql)
+	var rows int64
+	if result != nil {
+		rows = int64(len(result.Rows)) + result.Affected
+	}
+	a.recordHistory(config, dbName, sql, started, rows, err)
+	if database.IsDDL(database.DialectOf(config.Type), sql) {
+		a.metadata.Invalidate(config)
+	}
+	return result, err
+}
+
+func (a *App) executeQueryResult(config database.DatabaseConfig, dbName, sql string) (*database.ResultSet, error) {
 	factory := database.NewDBFactory()
 	adapter, err := factory.CreateAdapter(config)
 	if err != nil {
@@ -220,5 +344,22 @@ func (a *App) ExecuteQuery(config database.DatabaseConfig, dbName, sql string) (
 		return nil, fmt.Errorf("连接数据库失败: %v", err)
 	}
 
-	return adapter.ExecuteQuery(dbName, sql)
+	result := &database.ResultSet{}
+	if database.IsDML(database.DialectOf(config.Type), sql) {
+		if result.Affected, err = execAffected(adapter, dbName, sql, nil); err != nil {
+			return nil, err
+		}
+		result.Columns, result.Rows = []database.ResultColumn{}, [][]interface{}{}
+		return result, nil
+	}
+	if err := adapter.StreamQuery(context.Background(), dbName, sql, nil, database.NewResultSink(result)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BuildJSONFilter 按 JSON 路径生成浏览表数据时使用的过滤条件，如 MySQL 的 JSON_EXTRACT、PostgreSQL 的 ->>、
+// SQLite 的 json_extract，路径形如 $.a.b[0]
+func (a *App) BuildJSONFilter(config database.DatabaseConfig, column, path, operator, value string) (string, error) {
+	return database.DialectOf(config.Type).JSONFilter(column, path, operator, value)
 }
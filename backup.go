@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dbcat/database"
+)
+
+// BackupDatabase 在后台将整个数据库备份为 SQL 脚本，返回任务ID
+func (a *App) BackupDatabase(config database.DatabaseConfig, dbName, path string, options database.BackupOptions) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("未指定备份文件")
+	}
+	title := fmt.Sprintf("备份 %s → %s", dbName, filepath.Base(path))
+
+	return a.startJob("backup", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		adapter, err := factory.CreateAdapter(config)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer adapter.Close()
+
+		if err := adapter.Connect(); err != nil {
+			return "", fmt.Errorf("连接数据库失败: %v", err)
+		}
+
+		// 进度按已完成的表数计算，当前表和累计行数写在说明文字中
+		err = database.BackupDatabase(ctx, adapter, config.Type, dbName, path, options, func(p database.BackupProgress) {
+			message := fmt.Sprintf("已备份 %d 行", p.Rows)
+			if p.Table != "" {
+				message = fmt.Sprintf("正在备份 %s，%s", p.Table, message)
+			}
+			r.Update(int64(p.TablesDone), int64(p.TablesTotal), message)
+		})
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}), nil
+}
+
+// RestoreDatabase 在后台执行备份文件恢复数据库，压缩的备份会自动解压，返回任务ID
+func (a *App) RestoreDatabase(config database.DatabaseConfig, dbName, path string, options database.ScriptOptions) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("未指定备份文件")
+	}
+	options.Database = dbName
+	title := fmt.Sprintf("恢复 %s → %s", filepath.Base(path), dbName)
+	return a.startScriptJob("restore", title, config, path, options), nil
+}
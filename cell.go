@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"dbcat/database"
+)
+
+// readCell 按主键读取单元格的原始值
+func (a *App) readCell(config database.DatabaseConfig, ref database.CellRef) (interface{}, string, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, "", fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, "", fmt.Errorf("连接数据库失败: %v", err)
+	}
+	return database.ReadCell(context.Background(), adapter, config.Type, ref)
+}
+
+// GetCellValue 按主键读取单元格的完整值，用于查看结果集中只显示摘要的大字段和二进制值
+func (a *App) GetCellValue(config database.DatabaseConfig, dbName, schema, table string, pk map[string]string, column string) (database.CellValue, error) {
+	ref := database.CellRef{Database: dbName, Schema: schema, Table: table, PrimaryKey: pk, Column: column}
+	v, dbType, err := a.readCell(config, ref)
+	if err != nil {
+		return database.CellValue{}, err
+	}
+	return database.NewCellValue(column, dbType, v), nil
+}
+
+// SaveCellToFile 将单元格的内容保存到文件，文本按 UTF-8 写入，返回写入的字节数
+func (a *App) SaveCellToFile(config database.DatabaseConfig, dbName, schema, table string, pk map[string]string, column, path string) (int64, error) {
+	ref := database.CellRef{Database: dbName, Schema: schema, Table: table, PrimaryKey: pk, Column: column}
+	v, dbType, err := a.readCell(config, ref)
+	if err != nil {
+		return 0, err
+	}
+	if v == nil {
+		return 0, fmt.Errorf("该单元格为 NULL")
+	}
+	data, ok := v.([]byte)
+	if !ok {
+		data = []byte(database.FormatValue(dbType, v))
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return 0, fmt.Errorf("写入文件失败: %v", err)
+	}
+	return int64(len(data)), nil
+}
+
+// LoadCellFromFile 使用文件内容更新单元格，文本列要求文件为 UTF-8 编码，返回读取的字节数
+func (a *App) LoadCellFromFile(config database.DatabaseConfig, dbName, schema, table string, pk map[string]string, column, path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("读取文件失败: %v", err)
+	}
+	ref := database.CellRef{Database: dbName, Schema: schema, Table: table, PrimaryKey: pk, Column: column}
+	if err := a.writeCell(config, ref, data); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// UpdateCellValue 按主键更新单元格，null 为 true 时写入 NULL。空间列接受 WKT 或带 SRID 前缀的 EWKT
+func (a *App) UpdateCellValue(config database.DatabaseConfig, dbName, schema, table string, pk map[string]string, column, value string, null bool) error {
+	ref := database.CellRef{Database: dbName, Schema: schema, Table: table, PrimaryKey: pk, Column: column}
+	data := []byte(value)
+	if null {
+		data = nil
+	}
+	return a.writeCell(config, ref, data)
+}
+
+// writeCell 按主键写入单元格
+func (a *App) writeCell(config database.DatabaseConfig, ref database.CellRef, data []byte) error {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	if err := database.WriteCell(context.Background(), adapter, config.Type, ref, data); err != nil {
+		return fmt.Errorf("更新单元格失败: %v", err)
+	}
+	return nil
+}
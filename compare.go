@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"dbcat/database"
+)
+
+// CompareSchemas 比较两个连接中数据库（PostgreSQL 为 schema）的结构，返回差异和使目标库与源库一致的迁移脚本
+func (a *App) CompareSchemas(sourceConfig, targetConfig database.DatabaseConfig, opts database.SchemaCompareOptions) (*database.SchemaDiff, error) {
+	factory := database.NewDBFactory()
+	src, err := factory.CreateAdapter(sourceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer src.Close()
+	if err := src.Connect(); err != nil {
+		return nil, fmt.Errorf("连接源数据库失败: %v", err)
+	}
+
+	dst, err := factory.CreateAdapter(targetConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.Connect(); err != nil {
+		return nil, fmt.Errorf("连接目标数据库失败: %v", err)
+	}
+
+	return database.CompareSchemas(src, sourceConfig.Type, dst, targetConfig.Type, opts)
+}
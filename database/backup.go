@@ -0,0 +1,404 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 备份文件的压缩方式
+const (
+	CompressNone = "none"
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// DumpTable 备份中的一张表
+type DumpTable struct {
+	Schema string
+	Name   string
+	// DDL 不含二级索引和外键的建表语句
+	DDL string
+	// Columns 需要导出数据的列，不包含生成列
+	Columns []string
+}
+
+// DumpStatement 依附于某张表的语句，如索引、外键和触发器
+type DumpStatement struct {
+	Schema string
+	Table  string
+	SQL    string
+}
+
+// DumpView 备份中的视图
+type DumpView struct {
+	Schema string
+	Name   string
+	SQL    string
+}
+
+// DumpRoutine 备份中的函数或存储过程，Name 在有重载时包含参数列表
+type DumpRoutine struct {
+	Schema string
+	Name   string
+	Type   string
+	SQL    string
+}
+
+// DumpSchema 备份使用的数据库结构，由各适配器通过元数据查询生成。
+// 恢复时按 Prologue、Schemas、Tables、数据、Indexes、ForeignKeys、Routines、Views、Triggers、Epilogue 的顺序执行，
+// 函数先于视图创建，以便视图调用函数
+type DumpSchema struct {
+	Prologue    []string
+	Schemas     []string
+	Tables      []DumpTable
+	Indexes     []DumpStatement
+	ForeignKeys []DumpStatement
+	Views       []DumpView
+	Routines    []DumpRoutine
+	Triggers    []DumpStatement
+	Epilogue    []string
+}
+
+// BackupOptions 备份选项
+type BackupOptions struct {
+	// Compression 为 none、gzip 或 zstd
+	Compression string `json:"Compression"`
+	// Include 需要备份的表和视图，支持 * 和 ? 通配符，为空时备份全部表和视图
+	Include []string `json:"Include"`
+	// Exclude 排除的表和视图，优先于 Include
+	Exclude []string `json:"Exclude"`
+	// NoData 为 true 时只备份结构
+	NoData    bool `json:"NoData"`
+	BatchSize int  `json:"BatchSize"`
+}
+
+// BackupProgress 备份进度
+type BackupProgress struct {
+	Table       string `json:"Table"`
+	TablesDone  int    `json:"TablesDone"`
+	TablesTotal int    `json:"TablesTotal"`
+	Rows        int64  `json:"Rows"`
+}
+
+// BackupProgressFunc 备份进度回调
+type BackupProgressFunc func(p BackupProgress)
+
+// matchTable 判断表或视图是否在备份范围内，模式可以是表名或 schema.表名
+func (o BackupOptions) matchTable(schema, table string) bool {
+	match := func(patterns []string) bool {
+		for _, p := range patterns {
+			p = strings.TrimSpace(p)
+			if ok, _ := path.Match(p, table); ok {
+				return true
+			}
+			if schema != "" {
+				if ok, _ := path.Match(p, schema+"."+table); ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if match(o.Exclude) {
+		return false
+	}
+	return len(o.Include) == 0 || match(o.Include)
+}
+
+// filter 按表过滤结构中的表、视图以及依附于表的语句
+func (s *DumpSchema) filter(opts BackupOptions) {
+	var tables []DumpTable
+	for _, t := range s.Tables {
+		if opts.matchTable(t.Schema, t.Name) {
+			tables = append(tables, t)
+		}
+	}
+	s.Tables = tables
+
+	var views []DumpView
+	for _, v := range s.Views {
+		if opts.matchTable(v.Schema, v.Name) {
+			views = append(views, v)
+		}
+	}
+	s.Views = views
+
+	keep := func(list []DumpStatement) []DumpStatement {
+		var out []DumpStatement
+		for _, st := range list {
+			if opts.matchTable(st.Schema, st.Table) {
+				out = append(out, st)
+			}
+		}
+		return out
+	}
+	s.Indexes = keep(s.Indexes)
+	s.ForeignKeys = keep(s.ForeignKeys)
+	s.Triggers = keep(s.Triggers)
+}
+
+// sortViews 按引用关系排列视图，被引用的视图排在前面
+func sortViews(views []DumpView) []DumpView {
+	key := func(v DumpView) string { return v.Schema + "." + v.Name }
+	sort.Slice(views, func(i, k int) bool { return key(views[i]) < key(views[k]) })
+
+	done := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var out []DumpView
+	var visit func(v DumpView)
+	visit = func(v DumpView) {
+		if done[key(v)] || visiting[key(v)] {
+			return
+		}
+		visiting[key(v)] = true
+		body := v.SQL
+		if i := strings.Index(strings.ToUpper(body), " AS "); i >= 0 {
+			body = body[i:]
+		}
+		for _, dep := range views {
+			if key(dep) != key(v) && strings.Contains(body, dep.Name) {
+				visit(dep)
+			}
+		}
+		visiting[key(v)] = false
+		done[key(v)] = true
+		out = append(out, v)
+	}
+	for _, v := range views {
+		visit(v)
+	}
+	return out
+}
+
+// dumpWriter 写入备份文件，记录第一个写入错误
+type dumpWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (d *dumpWriter) section(title string) {
+	d.printf("\n--\n-- %s\n--\n\n", title)
+}
+
+func (d *dumpWriter) statement(sql string) {
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	d.printf("%s;\n", sql)
+}
+
+// block 写入内部包含分号的语句
+func (d *dumpWriter) block(dialect Dialect, sql string) {
+	d.printf("%s\n", scriptBlock(dialect, sql))
+}
+
+// scriptBlock 返回可在脚本中执行的复合语句（函数、触发器等），MySQL 需要临时切换客户端分隔符
+func scriptBlock(dialect Dialect, sql string) string {
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	if dialect.Name == "mysql" {
+		return "DELIMITER ;;\n" + sql + ";;\nDELIMITER ;\n"
+	}
+	return sql + ";\n"
+}
+
+func (d *dumpWriter) printf(format string, args ...interface{}) {
+	if d.err == nil {
+		_, d.err = fmt.Fprintf(d.w, format, args...)
+	}
+}
+
+// compressWriter 按压缩方式包装输出
+func compressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch strings.ToLower(compression) {
+	case "", CompressNone:
+		return nopWriteCloser{w}, nil
+	case CompressGzip, "gz":
+		return gzip.NewWriter(w), nil
+	case CompressZstd, "zst":
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unsupported compression: %s", compression)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// decompressReader 根据文件头自动识别 gzip 和 zstd 压缩，compressed 表示输入是否经过压缩
+func decompressReader(r io.Reader) (reader io.Reader, compressed bool, closeFn func(), err error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		return zr, true, func() { zr.Close() }, nil
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		return zr, true, zr.Close, nil
+	}
+	return br, false, func() {}, nil
+}
+
+// BackupDatabase 将数据库的结构和数据写为可独立执行的 SQL 脚本
+func BackupDatabase(ctx context.Context, adapter DBAdapter, dbType, dbName, filePath string, opts BackupOptions, progress BackupProgressFunc) (err error) {
+	schema, err := adapter.GetDumpSchema(dbName)
+	if err != nil {
+		return err
+	}
+	schema.filter(opts)
+	schema.Views = sortViews(schema.Views)
+	dialect := DialectOf(dbType)
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	zw, err := compressWriter(f, opts.Compression)
+	if err != nil {
+		return err
+	}
+	out := &dumpWriter{w: bufio.NewWriterSize(zw, 256*1024)}
+
+	out.printf("-- dbcat backup\n-- Type: %s\n-- Database: %s\n-- Created: %s\n\n", dbType, dbName, time.Now().Format(time.RFC3339))
+	for _, s := range schema.Prologue {
+		out.statement(s)
+	}
+	if len(schema.Schemas) > 0 {
+		out.section("Schemas and sequences")
+		for _, s := range schema.Schemas {
+			out.statement(s)
+		}
+	}
+
+	report := BackupProgress{TablesTotal: len(schema.Tables)}
+	for _, t := range schema.Tables {
+		out.section("Table " + t.Name)
+		out.statement(t.DDL)
+	}
+
+	if !opts.NoData {
+		for _, t := range schema.Tables {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			report.Table = t.Name
+			if progress != nil {
+				progress(report)
+			}
+			out.section("Data for table " + t.Name)
+			if out.err != nil {
+				return out.err
+			}
+			rows, err := dumpTableData(ctx, adapter, dialect, dbName, t, out.w, opts.BatchSize, func(n int64) {
+				if progress != nil {
+					r := report
+					r.Rows += n
+					progress(r)
+				}
+			})
+			if err != nil {
+				return fmt.Errorf("dump table %s: %v", t.Name, err)
+			}
+			report.Rows += rows
+			report.TablesDone++
+		}
+	}
+
+	if len(schema.Indexes) > 0 {
+		out.section("Indexes")
+		for _, s := range schema.Indexes {
+			out.statement(s.SQL)
+		}
+	}
+	if len(schema.ForeignKeys) > 0 {
+		out.section("Foreign keys")
+		for _, s := range schema.ForeignKeys {
+			out.statement(s.SQL)
+		}
+	}
+	if len(schema.Routines) > 0 {
+		out.section("Routines")
+		for _, r := range schema.Routines {
+			out.block(dialect, r.SQL)
+		}
+	}
+	if len(schema.Views) > 0 {
+		out.section("Views")
+		for _, v := range schema.Views {
+			out.statement(v.SQL)
+		}
+	}
+	if len(schema.Triggers) > 0 {
+		out.section("Triggers")
+		for _, s := range schema.Triggers {
+			out.block(dialect, s.SQL)
+		}
+	}
+	if len(schema.Epilogue) > 0 {
+		out.printf("\n")
+		for _, s := range schema.Epilogue {
+			out.statement(s)
+		}
+	}
+
+	if out.err != nil {
+		return out.err
+	}
+	if err := out.w.Flush(); err != nil {
+		return err
+	}
+	if progress != nil {
+		report.Table = ""
+		progress(report)
+	}
+	return zw.Close()
+}
+
+// dumpTableData 将一张表的数据写为 INSERT 语句
+func dumpTableData(ctx context.Context, adapter DBAdapter, dialect Dialect, dbName string, t DumpTable, w io.Writer, batchSize int, progress ProgressFunc) (int64, error) {
+	if len(t.Columns) == 0 {
+		return 0, nil
+	}
+	cols := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = dialect.QuoteIdent(c)
+	}
+	table := dialect.QuoteIdent(t.Name)
+	if t.Schema != "" && dialect.Name == "postgres" {
+		table = dialect.QuoteIdent(t.Schema) + "." + table
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(cols, ", "), table)
+
+	writer := NewSQLInsertWriter(w, dialect, table, SQLOptions{BatchSize: batchSize})
+	var rows int64
+	sink := WithProgress(ctx, writer, func(n int64) {
+		rows = n
+		progress(n)
+	})
+	if err := adapter.StreamQuery(ctx, dbName, query, nil, sink); err != nil {
+		return rows, err
+	}
+	return rows, writer.Close()
+}
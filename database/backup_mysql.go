@@ -0,0 +1,218 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// mysqlDefinerRe 匹配 SHOW CREATE 输出中的 DEFINER 子句，恢复到其他服务器时通常不存在该用户
+var mysqlDefinerRe = regexp.MustCompile("DEFINER=(`[^`]*`|[^@\\s]+)@(`[^`]*`|\\S+)\\s+")
+
+// GetDumpSchema 获取备份所需的表、索引、外键、视图、存储过程和触发器
+func (a *MySQLAdapter) GetDumpSchema(dbName string) (*DumpSchema, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	d := DialectOf("mysql")
+	schema := &DumpSchema{
+		Prologue: []string{
+			"SET NAMES utf8mb4",
+			"SET FOREIGN_KEY_CHECKS = 0",
+			"SET UNIQUE_CHECKS = 0",
+			"SET SQL_MODE = 'NO_AUTO_VALUE_ON_ZERO'",
+		},
+		Epilogue: []string{
+			"SET FOREIGN_KEY_CHECKS = 1",
+			"SET UNIQUE_CHECKS = 1",
+		},
+	}
+
+	// 可写入的列，排除生成列
+	columns := make(map[string][]string)
+	rows, err := db.Queryx(`
+		SELECT TABLE_NAME, COLUMN_NAME
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND EXTRA NOT LIKE '%GENERATED%'
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
+	`, dbName)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		columns[table] = append(columns[table], column)
+	}
+	rows.Close()
+
+	var tables, views []string
+	rows, err = db.Queryx(`
+		SELECT TABLE_NAME, TABLE_TYPE
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME
+	`, dbName)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if typ == "VIEW" {
+			views = append(views, name)
+		} else if typ == "BASE TABLE" {
+			tables = append(tables, name)
+		}
+	}
+	rows.Close()
+
+	for _, name := range tables {
+		ddl, err := a.GetTableDDL(dbName, name)
+		if err != nil {
+			return nil, err
+		}
+		create, indexes, fks := splitMySQLCreateTable(ddl)
+		schema.Tables = append(schema.Tables, DumpTable{Name: name, DDL: create, Columns: columns[name]})
+		for _, idx := range indexes {
+			schema.Indexes = append(schema.Indexes, DumpStatement{Table: name, SQL: "ALTER TABLE " + d.QuoteIdent(name) + " ADD " + idx})
+		}
+		for _, fk := range fks {
+			schema.ForeignKeys = append(schema.ForeignKeys, DumpStatement{Table: name, SQL: "ALTER TABLE " + d.QuoteIdent(name) + " ADD " + fk})
+		}
+	}
+
+	// 视图中的库名限定会去掉，以便恢复到其他库
+	qualifier := d.QuoteIdent(dbName) + "."
+	for _, name := range views {
+		sql, err := mysqlShowCreate(db, "SHOW CREATE VIEW "+d.TableName(dbName, "", name), "Create View")
+		if err != nil {
+			return nil, err
+		}
+		sql = strings.ReplaceAll(mysqlDefinerRe.ReplaceAllString(sql, ""), qualifier, "")
+		schema.Views = append(schema.Views, DumpView{Name: name, SQL: sql})
+	}
+
+	routines, err := a.GetRoutines(dbName, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range routines {
+		kind := strings.ToUpper(r.Type)
+		column := "Create Procedure"
+		if kind == "FUNCTION" {
+			column = "Create Function"
+		}
+		sql, err := mysqlShowCreate(db, "SHOW CREATE "+kind+" "+d.TableName(dbName, "", r.Name), column)
+		if err != nil {
+			return nil, err
+		}
+		// 没有权限时定义为空
+		if sql != "" {
+			schema.Routines = append(schema.Routines, DumpRoutine{Name: r.Name, Type: kind, SQL: mysqlDefinerRe.ReplaceAllString(sql, "")})
+		}
+	}
+
+	rows, err = db.Queryx(`
+		SELECT TRIGGER_NAME, EVENT_OBJECT_TABLE
+		FROM INFORMATION_SCHEMA.TRIGGERS
+		WHERE TRIGGER_SCHEMA = ?
+		ORDER BY EVENT_OBJECT_TABLE, ACTION_ORDER
+	`, dbName)
+	if err != nil {
+		return nil, err
+	}
+	var triggers [][2]string
+	for rows.Next() {
+		var name, table string
+		if err := rows.Scan(&name, &table); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		triggers = append(triggers, [2]string{name, table})
+	}
+	rows.Close()
+	for _, t := range triggers {
+		sql, err := mysqlShowCreate(db, "SHOW CREATE TRIGGER "+d.TableName(dbName, "", t[0]), "SQL Original Statement")
+		if err != nil {
+			return nil, err
+		}
+		schema.Triggers = append(schema.Triggers, DumpStatement{Table: t[1], SQL: mysqlDefinerRe.ReplaceAllString(sql, "")})
+	}
+	return schema, nil
+}
+
+// mysqlShowCreate 执行 SHOW CREATE 语句并返回指定列
+func mysqlShowCreate(db *sqlx.DB, query, column string) (string, error) {
+	rows, err := db.Queryx(query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return "", fmt.Errorf("no result for %s", query)
+	}
+	row := make(map[string]interface{})
+	if err := rows.MapScan(row); err != nil {
+		return "", err
+	}
+	switch v := row[column].(type) {
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	}
+	return "", nil
+}
+
+// splitMySQLCreateTable 从 SHOW CREATE TABLE 的结果中拆出二级索引和外键，
+// 返回精简后的建表语句以及可用于 ALTER TABLE ... ADD 的索引和外键定义
+func splitMySQLCreateTable(ddl string) (string, []string, []string) {
+	lines := strings.Split(strings.TrimRight(strings.TrimSpace(ddl), ";"), "\n")
+	if len(lines) < 3 {
+		return ddl, nil, nil
+	}
+	// 自增列必须有索引，以自增列开头的索引保留在建表语句中
+	var autoColumns []string
+	for _, line := range lines[1 : len(lines)-1] {
+		def := strings.TrimSpace(line)
+		if strings.HasPrefix(def, "`") && strings.Contains(def, " AUTO_INCREMENT") {
+			autoColumns = append(autoColumns, def[:strings.Index(def[1:], "`")+2])
+		}
+	}
+	keepIndex := func(def string) bool {
+		for _, col := range autoColumns {
+			if strings.Contains(def, "("+col) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var body, indexes, fks []string
+	for _, line := range lines[1 : len(lines)-1] {
+		def := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		upper := strings.ToUpper(def)
+		switch {
+		case (strings.HasPrefix(upper, "KEY ") || strings.HasPrefix(upper, "UNIQUE KEY ") ||
+			strings.HasPrefix(upper, "FULLTEXT KEY ") || strings.HasPrefix(upper, "SPATIAL KEY ")) && !keepIndex(def):
+			indexes = append(indexes, def)
+		case strings.HasPrefix(upper, "CONSTRAINT ") && strings.Contains(upper, " FOREIGN KEY "):
+			fks = append(fks, def)
+		default:
+			body = append(body, "  "+def)
+		}
+	}
+	create := lines[0] + "\n" + strings.Join(body, ",\n") + "\n" + lines[len(lines)-1]
+	return create, indexes, fks
+}
@@ -0,0 +1,358 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetDumpSchema 获取备份所需的 schema、序列、表、约束、索引、视图、函数和触发器
+func (a *PostgresAdapter) GetDumpSchema(dbName string) (*DumpSchema, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	d := DialectOf("postgres")
+	schema := &DumpSchema{
+		Prologue: []string{
+			"SET client_encoding = 'UTF8'",
+			"SET standard_conforming_strings = on",
+			"SET check_function_bodies = false",
+		},
+	}
+
+	var schemas []string
+	err = db.Select(&schemas, `
+		SELECT nspname FROM pg_namespace
+		WHERE nspname NOT IN ('pg_catalog', 'information_schema', 'pg_toast')
+			AND nspname NOT LIKE 'pg_temp_%' AND nspname NOT LIKE 'pg_toast_temp_%'
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = pg_namespace.oid AND d.deptype = 'e')
+		ORDER BY nspname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schemas {
+		if s != "public" {
+			schema.Schemas = append(schema.Schemas, "CREATE SCHEMA IF NOT EXISTS "+d.QuoteIdent(s))
+		}
+	}
+	nsp := pq.Array(schemas)
+
+	// 扩展和自定义类型需要在建表之前创建
+	var extensions []string
+	err = db.Select(&extensions, `
+		SELECT 'CREATE EXTENSION IF NOT EXISTS ' || quote_ident(e.extname) || ' WITH SCHEMA ' || quote_ident(n.nspname)
+		FROM pg_extension e JOIN pg_namespace n ON n.oid = e.extnamespace
+		WHERE e.extname <> 'plpgsql'
+		ORDER BY e.extname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	schema.Schemas = append(schema.Schemas, extensions...)
+	types, err := pgUserTypes(db, schemas)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		schema.Schemas = append(schema.Schemas, t.DDL)
+	}
+
+	if err := a.dumpSequences(db, nsp, schema); err != nil {
+		return nil, err
+	}
+
+	// 表和列
+	tableRows, err := db.Queryx(`
+		SELECT c.oid, n.nspname, c.relname
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'r' AND n.nspname = ANY($1)
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+		ORDER BY n.nspname, c.relname
+	`, nsp)
+	if err != nil {
+		return nil, err
+	}
+	type tableRef struct {
+		oid          int64
+		schema, name string
+	}
+	var tables []tableRef
+	for tableRows.Next() {
+		var t tableRef
+		if err := tableRows.Scan(&t.oid, &t.schema, &t.name); err != nil {
+			tableRows.Close()
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	tableRows.Close()
+
+	for _, t := range tables {
+		qualified := d.QuoteIdent(t.schema) + "." + d.QuoteIdent(t.name)
+		table, identity, err := pgDumpTable(db, t.oid, qualified)
+		if err != nil {
+			return nil, err
+		}
+		table.Schema, table.Name = t.schema, t.name
+		schema.Tables = append(schema.Tables, table)
+		// 标识列先以 BY DEFAULT 创建以便写入原值，数据写完后再恢复为 ALWAYS
+		for _, col := range identity {
+			schema.Indexes = append(schema.Indexes, DumpStatement{Schema: t.schema, Table: t.name,
+				SQL: fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET GENERATED ALWAYS", qualified, d.QuoteIdent(col))})
+		}
+	}
+
+	// 主键和检查约束已写在建表语句中，其余约束放在数据之后
+	rows, err := db.Queryx(`
+		SELECT n.nspname, c.relname, con.conname, con.contype::text, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'r' AND n.nspname = ANY($1) AND con.contype IN ('u', 'x', 'f')
+		ORDER BY n.nspname, c.relname, con.conname
+	`, nsp)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var s, table, name, typ, def string
+		if err := rows.Scan(&s, &table, &name, &typ, &def); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		st := DumpStatement{Schema: s, Table: table, SQL: fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s %s",
+			d.QuoteIdent(s), d.QuoteIdent(table), d.QuoteIdent(name), def)}
+		if typ == "f" {
+			schema.ForeignKeys = append(schema.ForeignKeys, st)
+		} else {
+			schema.Indexes = append(schema.Indexes, st)
+		}
+	}
+	rows.Close()
+
+	// 不属于约束的索引
+	rows, err = db.Queryx(`
+		SELECT n.nspname, t.relname, pg_get_indexdef(i.indexrelid)
+		FROM pg_index i
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE t.relkind = 'r' AND n.nspname = ANY($1)
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid)
+		ORDER BY n.nspname, t.relname, i.indexrelid
+	`, nsp)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var st DumpStatement
+		if err := rows.Scan(&st.Schema, &st.Table, &st.SQL); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		schema.Indexes = append(schema.Indexes, st)
+	}
+	rows.Close()
+
+	rows, err = db.Queryx(`
+		SELECT n.nspname, c.relname, pg_get_viewdef(c.oid)
+		FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind = 'v' AND n.nspname = ANY($1)
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+		ORDER BY n.nspname, c.relname
+	`, nsp)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var s, name, def string
+		if err := rows.Scan(&s, &name, &def); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		qualified := d.QuoteIdent(s) + "." + d.QuoteIdent(name)
+		schema.Views = append(schema.Views, DumpView{Schema: s, Name: name, SQL: "CREATE OR REPLACE VIEW " + qualified + " AS\n" + def})
+	}
+	rows.Close()
+
+	rows, err = db.Queryx(`
+		SELECT n.nspname, p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')',
+			CASE p.prokind WHEN 'p' THEN 'PROCEDURE' ELSE 'FUNCTION' END, pg_get_functiondef(p.oid)
+		FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = ANY($1) AND p.prokind IN ('f', 'p')
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = p.oid AND d.deptype = 'e')
+		ORDER BY n.nspname, p.proname
+	`, nsp)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var r DumpRoutine
+		if err := rows.Scan(&r.Schema, &r.Name, &r.Type, &r.SQL); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		schema.Routines = append(schema.Routines, r)
+	}
+	rows.Close()
+
+	rows, err = db.Queryx(`
+		SELECT n.nspname, c.relname, pg_get_triggerdef(t.oid)
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE NOT t.tgisinternal AND n.nspname = ANY($1)
+			AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid IN (t.oid, c.oid) AND d.deptype = 'e')
+		ORDER BY n.nspname, c.relname, t.tgname
+	`, nsp)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var st DumpStatement
+		if err := rows.Scan(&st.Schema, &st.Table, &st.SQL); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		schema.Triggers = append(schema.Triggers, st)
+	}
+	rows.Close()
+
+	return schema, nil
+}
+
+// dumpSequences 生成独立序列的建立语句，并在数据之后恢复所有序列（包括标识列）的当前值
+func (a *PostgresAdapter) dumpSequences(db *sqlx.DB, nsp interface{}, schema *DumpSchema) error {
+	d := DialectOf("postgres")
+	rows, err := db.Queryx(`
+		SELECT s.schemaname, s.sequencename, s.start_value, s.min_value, s.max_value,
+			s.increment_by, s.cycle, s.cache_size, s.last_value,
+			tbl.relname, att.attname
+		FROM pg_sequences s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class c ON c.relname = s.sequencename AND c.relnamespace = n.oid
+		LEFT JOIN pg_depend dep ON dep.objid = c.oid AND dep.deptype = 'i'
+		LEFT JOIN pg_class tbl ON tbl.oid = dep.refobjid
+		LEFT JOIN pg_attribute att ON att.attrelid = dep.refobjid AND att.attnum = dep.refobjsubid
+		WHERE s.schemaname = ANY($1)
+		ORDER BY s.schemaname, s.sequencename
+	`, nsp)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s, name string
+		var start, min, max, inc, cache int64
+		var cycle bool
+		var last sql.NullInt64
+		var table, column sql.NullString
+		if err := rows.Scan(&s, &name, &start, &min, &max, &inc, &cycle, &cache, &last, &table, &column); err != nil {
+			return err
+		}
+		qualified := d.QuoteIdent(s) + "." + d.QuoteIdent(name)
+		if !table.Valid {
+			stmt := fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d",
+				qualified, inc, min, max, start, cache)
+			if cycle {
+				stmt += " CYCLE"
+			}
+			schema.Schemas = append(schema.Schemas, stmt)
+			if last.Valid {
+				schema.Epilogue = append(schema.Epilogue, fmt.Sprintf("SELECT setval(%s, %d, true)", d.QuoteString(qualified), last.Int64))
+			}
+			continue
+		}
+		// 标识列的序列随表创建，名称可能不同，通过列查找
+		if last.Valid {
+			tableName := d.QuoteIdent(s) + "." + d.QuoteIdent(table.String)
+			schema.Epilogue = append(schema.Epilogue, fmt.Sprintf("SELECT setval(pg_get_serial_sequence(%s, %s), %d, true)",
+				d.QuoteString(tableName), d.QuoteString(column.String), last.Int64))
+		}
+	}
+	return rows.Err()
+}
+
+// pgDumpTable 根据系统表生成建表语句，返回表和标识列列表
+func pgDumpTable(db *sqlx.DB, oid int64, qualified string) (DumpTable, []string, error) {
+	d := DialectOf("postgres")
+	table := DumpTable{}
+	rows, err := db.Queryx(`
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			pg_get_expr(ad.adbin, ad.adrelid), a.attidentity::text, a.attgenerated::text,
+			col_description(a.attrelid, a.attnum)
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum
+	`, oid)
+	if err != nil {
+		return table, nil, err
+	}
+	var defs, identity, comments []string
+	for rows.Next() {
+		var name, typ, ident, generated string
+		var notNull bool
+		var def, comment sql.NullString
+		if err := rows.Scan(&name, &typ, &notNull, &def, &ident, &generated, &comment); err != nil {
+			rows.Close()
+			return table, nil, err
+		}
+		col := "  " + d.QuoteIdent(name) + " " + typ
+		switch {
+		case generated == "s":
+			col += " GENERATED ALWAYS AS (" + def.String + ") STORED"
+		case ident == "a" || ident == "d":
+			col += " GENERATED BY DEFAULT AS IDENTITY"
+			if ident == "a" {
+				identity = append(identity, name)
+			}
+		case def.Valid:
+			col += " DEFAULT " + def.String
+		}
+		if notNull {
+			col += " NOT NULL"
+		}
+		if generated != "s" {
+			table.Columns = append(table.Columns, name)
+		}
+		if comment.Valid {
+			comments = append(comments, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", qualified, d.QuoteIdent(name), d.QuoteString(comment.String)))
+		}
+		defs = append(defs, col)
+	}
+	rows.Close()
+
+	rows, err = db.Queryx(`
+		SELECT conname, pg_get_constraintdef(oid)
+		FROM pg_constraint WHERE conrelid = $1 AND contype IN ('p', 'c')
+		ORDER BY contype DESC, conname
+	`, oid)
+	if err != nil {
+		return table, nil, err
+	}
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			rows.Close()
+			return table, nil, err
+		}
+		defs = append(defs, "  CONSTRAINT "+d.QuoteIdent(name)+" "+def)
+	}
+	rows.Close()
+
+	table.DDL = "CREATE TABLE " + qualified + " (\n" + strings.Join(defs, ",\n") + "\n);"
+	var comment sql.NullString
+	if err := db.Get(&comment, "SELECT obj_description($1, 'pg_class')", oid); err == nil && comment.Valid {
+		table.DDL += fmt.Sprintf("\nCOMMENT ON TABLE %s IS %s;", qualified, d.QuoteString(comment.String))
+	}
+	for _, c := range comments {
+		table.DDL += "\n" + c + ";"
+	}
+	return table, identity, nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// GetDumpSchema 从 sqlite_master 获取备份所需的表、索引、视图和触发器，外键包含在建表语句中
+func (a *SQLiteAdapter) GetDumpSchema(dbName string) (*DumpSchema, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	schema := &DumpSchema{
+		Prologue: []string{"PRAGMA foreign_keys = OFF", "BEGIN"},
+		Epilogue: []string{"COMMIT"},
+	}
+
+	// 虚拟表（如 FTS）的影子表随虚拟表自动创建，不单独备份
+	shadow := make(map[string]bool)
+	if rows, err := db.Queryx("SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'shadow'"); err == nil {
+		for rows.Next() {
+			var name string
+			if rows.Scan(&name) == nil {
+				shadow[name] = true
+			}
+		}
+		rows.Close()
+	}
+
+	rows, err := db.Queryx(`
+		SELECT type, name, tbl_name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, rowid
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []DumpTable
+	for rows.Next() {
+		var typ, name, table string
+		var ddl sql.NullString
+		if err := rows.Scan(&typ, &name, &table, &ddl); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		switch typ {
+		case "table":
+			if !shadow[name] {
+				tables = append(tables, DumpTable{Name: name, DDL: ddl.String})
+			}
+		case "index":
+			schema.Indexes = append(schema.Indexes, DumpStatement{Table: table, SQL: ddl.String})
+		case "view":
+			schema.Views = append(schema.Views, DumpView{Name: name, SQL: ddl.String})
+		case "trigger":
+			schema.Triggers = append(schema.Triggers, DumpStatement{Table: table, SQL: ddl.String})
+		}
+	}
+	rows.Close()
+
+	// 生成列和虚拟表的隐藏列不导出数据
+	for i := range tables {
+		rows, err := db.Queryx("SELECT name, hidden FROM pragma_table_xinfo(?)", tables[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name string
+			var hidden int
+			if err := rows.Scan(&name, &hidden); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if hidden == 0 {
+				tables[i].Columns = append(tables[i].Columns, name)
+			}
+		}
+		rows.Close()
+		if !strings.HasSuffix(tables[i].DDL, ";") {
+			tables[i].DDL += ";"
+		}
+	}
+	schema.Tables = tables
+	return schema, nil
+}
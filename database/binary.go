@@ -0,0 +1,314 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CellValue 的编码方式
+const (
+	EncodingText   = "text"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+// binaryInlineLimit 结果集中以十六进制完整显示的二进制值的最大字节数，
+// 更大的值只显示大小和类型，完整内容通过 ReadCell 按需读取
+const binaryInlineLimit = 64
+
+// cellDataLimit CellValue 中返回内容的最大字节数，更大的值需要保存到文件查看
+const cellDataLimit = 16 << 20
+
+// CellValue 单元格的完整值。二进制值不超过 64 字节时使用 hex 编码，否则使用 base64（可直接用于 data URL）；
+// 文本的 Encoding 为 text。MIME 为根据内容推测的类型，Size 为原始字节数，超过 16MB 时 Truncated 为 true 且 Data 为空
+type CellValue struct {
+	Column    string `json:"Column"`
+	Type      string `json:"Type"`
+	Null      bool   `json:"Null"`
+	Binary    bool   `json:"Binary"`
+	Size      int64  `json:"Size"`
+	MIME      string `json:"MIME"`
+	Encoding  string `json:"Encoding"`
+	Data      string `json:"Data"`
+	Truncated bool   `json:"Truncated"`
+	// Geometry 空间值解码后的 WKT、GeoJSON 和 SRID，此时 Data 为 EWKT
+	Geometry *Geometry `json:"Geometry"`
+	// JSON JSON 列的值，此时 Data 为原始文本
+	JSON json.RawMessage `json:"JSON"`
+}
+
+// IsBinary 判断驱动返回的字节是否按二进制处理：列类型为二进制类型，或内容不是有效的 UTF-8
+func IsBinary(dbType string, b []byte) bool {
+	return ColumnKind(dbType) == KindBinary || !utf8.Valid(b)
+}
+
+// BytesText 将驱动返回的 []byte 转换为结果集中显示的文本。空间值显示为 EWKT，短的二进制值显示为
+// 0x 开头的十六进制，长的显示为 [BLOB 大小 类型] 形式的摘要
+func BytesText(dbType string, b []byte) string {
+	if g, ok := DecodeGeometry(dbType, b); ok {
+		return g.EWKT()
+	}
+	if !IsBinary(dbType, b) {
+		return string(b)
+	}
+	if len(b) <= binaryInlineLimit {
+		return "0x" + hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("[BLOB %s %s]", SizeText(int64(len(b))), DetectMIME(b))
+}
+
+// SizeText 返回便于阅读的字节数，如 12.3 KB
+func SizeText(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	size, unit := float64(n)/1024, "KB"
+	for _, u := range []string{"MB", "GB", "TB"} {
+		if size < 1024 {
+			break
+		}
+		size, unit = size/1024, u
+	}
+	return fmt.Sprintf("%.1f %s", size, unit)
+}
+
+// DetectMIME 根据内容推测 MIME 类型，识别常见的图片、音视频、PDF 和压缩包，不能识别时为 application/octet-stream
+func DetectMIME(b []byte) string {
+	mime := http.DetectContentType(b)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	// Office 文档是 zip 格式，按其中的目录区分
+	if mime == "application/zip" {
+		head := b
+		if len(head) > 4096 {
+			head = head[:4096]
+		}
+		switch {
+		case strings.Contains(string(head), "word/"):
+			return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case strings.Contains(string(head), "xl/"):
+			return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		case strings.Contains(string(head), "ppt/"):
+			return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+		}
+	}
+	return mime
+}
+
+// NewCellValue 根据驱动返回的值生成 CellValue
+func NewCellValue(column, dbType string, v interface{}) CellValue {
+	cell := CellValue{Column: column, Type: dbType, Encoding: EncodingText}
+	if v == nil {
+		cell.Null = true
+		return cell
+	}
+	if ColumnKind(dbType) == KindJSON {
+		if raw, ok := JSONRaw(v); ok {
+			cell.JSON, cell.Data = raw, string(raw)
+			cell.Size, cell.MIME = int64(len(raw)), "application/json"
+			return cell
+		}
+	}
+	if g, ok := DecodeGeometry(dbType, v); ok {
+		cell.Geometry, cell.Data = g, g.EWKT()
+		cell.Size, cell.MIME = int64(len(cell.Data)), "text/plain"
+		return cell
+	}
+	b, ok := v.([]byte)
+	if !ok || !IsBinary(dbType, b) {
+		text := FormatValue(dbType, v)
+		cell.Size = int64(len(text))
+		cell.MIME = "text/plain"
+		if cell.Size > cellDataLimit {
+			cell.Truncated = true
+		} else {
+			cell.Data = text
+		}
+		return cell
+	}
+
+	cell.Binary = true
+	cell.Size = int64(len(b))
+	cell.MIME = DetectMIME(b)
+	switch {
+	case cell.Size > cellDataLimit:
+		cell.Truncated = true
+	case cell.Size <= binaryInlineLimit:
+		cell.Encoding, cell.Data = EncodingHex, hex.EncodeToString(b)
+	default:
+		cell.Encoding, cell.Data = EncodingBase64, base64.StdEncoding.EncodeToString(b)
+	}
+	return cell
+}
+
+// columnTypeNames 返回结果集各列的类型名，按列名索引，用于 MapScan 的结果
+func columnTypeNames(rows *sqlx.Rows) (map[string]string, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(types))
+	for _, t := range types {
+		names[t.Name()] = t.DatabaseTypeName()
+	}
+	return names, nil
+}
+
+// CellRef 按主键定位的单元格。PrimaryKey 的值为结果集中显示的文本，二进制主键使用 0x 开头的十六进制
+type CellRef struct {
+	Database   string            `json:"Database"`
+	Schema     string            `json:"Schema"`
+	Table      string            `json:"Table"`
+	PrimaryKey map[string]string `json:"PrimaryKey"`
+	Column     string            `json:"Column"`
+}
+
+// cellSink 读取单个单元格
+type cellSink struct {
+	column ResultColumn
+	value  interface{}
+	rows   int
+}
+
+func (s *cellSink) Begin(columns []ResultColumn) error {
+	s.column = columns[0]
+	return nil
+}
+
+func (s *cellSink) Row(values []interface{}) error {
+	s.rows++
+	if v, ok := values[0].([]byte); ok {
+		// 驱动会复用缓冲区
+		values[0] = append([]byte(nil), v...)
+	}
+	s.value = values[0]
+	return nil
+}
+
+// cellKeys 返回主键列名及对应的参数，并检查列名是否属于该表
+func cellKeys(adapter DBAdapter, ref CellRef) ([]string, []interface{}, ColumnInfo, error) {
+	var target ColumnInfo
+	if len(ref.PrimaryKey) == 0 {
+		return nil, nil, target, fmt.Errorf("primary key is required")
+	}
+	columns, err := SchemaTableColumns(adapter, ref.Database, ref.Schema, ref.Table)
+	if err != nil {
+		return nil, nil, target, err
+	}
+	found := false
+	var keys []string
+	var args []interface{}
+	for _, col := range columns {
+		if col.Name == ref.Column {
+			target, found = col, true
+		}
+		value, ok := ref.PrimaryKey[col.Name]
+		if !ok {
+			continue
+		}
+		var arg interface{} = value
+		if ColumnKind(col.Type) == KindBinary && strings.HasPrefix(value, "0x") {
+			b, err := hex.DecodeString(value[2:])
+			if err != nil {
+				return nil, nil, target, fmt.Errorf("invalid binary key %s: %v", col.Name, err)
+			}
+			arg = b
+		}
+		keys = append(keys, col.Name)
+		args = append(args, arg)
+	}
+	if !found {
+		return nil, nil, target, fmt.Errorf("column %s not found in table %s", ref.Column, ref.Table)
+	}
+	if len(keys) != len(ref.PrimaryKey) {
+		return nil, nil, target, fmt.Errorf("primary key contains unknown columns")
+	}
+	return keys, args, target, nil
+}
+
+// keyWhere 生成主键条件，first 为第一个占位符的序号
+func keyWhere(d Dialect, keys []string, first int) string {
+	conds := make([]string, len(keys))
+	for i, key := range keys {
+		conds[i] = d.QuoteIdent(key) + " = " + d.Placeholder(first+i)
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// selectCell 查询主键对应的行中的 expr，主键必须恰好匹配一行
+func selectCell(ctx context.Context, adapter DBAdapter, d Dialect, ref CellRef, expr string, keys []string, args []interface{}) (*cellSink, error) {
+	query := "SELECT " + expr + " FROM " + d.TableName(ref.Database, ref.Schema, ref.Table) + keyWhere(d, keys, 1) + " LIMIT 2"
+	sink := &cellSink{}
+	if err := adapter.StreamQuery(ctx, ref.Database, query, args, sink); err != nil {
+		return nil, err
+	}
+	switch sink.rows {
+	case 0:
+		return nil, fmt.Errorf("row not found")
+	case 1:
+		return sink, nil
+	}
+	return nil, fmt.Errorf("primary key matches more than one row")
+}
+
+// ReadCell 按主键读取一个单元格的原始值及结果集中的列类型，主键必须恰好匹配一行
+func ReadCell(ctx context.Context, adapter DBAdapter, dbType string, ref CellRef) (interface{}, string, error) {
+	keys, args, _, err := cellKeys(adapter, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	d := DialectOf(dbType)
+	sink, err := selectCell(ctx, adapter, d, ref, d.QuoteIdent(ref.Column), keys, args)
+	if err != nil {
+		return nil, "", err
+	}
+	return sink.value, sink.column.DatabaseType, nil
+}
+
+// WriteCell 按主键更新一个单元格，主键必须恰好匹配一行。文本列的内容必须是有效的 UTF-8，data 为 nil 时写入 NULL；
+// 空间列接受 WKT 或 EWKT，按方言转换为可写入的值
+func WriteCell(ctx context.Context, adapter DBAdapter, dbType string, ref CellRef, data []byte) error {
+	keys, args, col, err := cellKeys(adapter, ref)
+	if err != nil {
+		return err
+	}
+	d := DialectOf(dbType)
+	if _, err := selectCell(ctx, adapter, d, ref, "1", keys, args); err != nil {
+		return err
+	}
+
+	var value interface{}
+	switch {
+	case data == nil:
+	case ColumnKind(col.Type) == KindGeometry && LooksLikeWKT(string(data)):
+		g, err := ParseWKT(string(data))
+		if err != nil {
+			return err
+		}
+		value = d.GeometryValue(g)
+	case ColumnKind(col.Type) == KindBinary:
+		value = data
+	case utf8.Valid(data):
+		value = string(data)
+	default:
+		return fmt.Errorf("column %s is not a binary column and the content is not valid UTF-8", col.Name)
+	}
+
+	conn, err := adapter.Conn(ctx, ref.Database)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stmt := "UPDATE " + d.TableName(ref.Database, ref.Schema, ref.Table) + " SET " + d.QuoteIdent(col.Name) + " = " + d.Placeholder(1) + keyWhere(d, keys, 2)
+	_, err = conn.ExecContext(ctx, stmt, append([]interface{}{value}, args...)...)
+	return err
+}
@@ -0,0 +1,808 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 差异状态
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// primaryKeyName 主键在索引差异中的名称
+const primaryKeyName = "PRIMARY KEY"
+
+// ColumnDiff 列差异，Source 为源库中的定义，Target 为目标库中的定义
+type ColumnDiff struct {
+	Name   string      `json:"Name"`
+	Status string      `json:"Status"`
+	Source *ColumnInfo `json:"Source"`
+	Target *ColumnInfo `json:"Target"`
+}
+
+// IndexDiff 索引差异，主键的名称为 PRIMARY KEY
+type IndexDiff struct {
+	Name   string     `json:"Name"`
+	Status string     `json:"Status"`
+	Source *IndexInfo `json:"Source"`
+	Target *IndexInfo `json:"Target"`
+}
+
+// ForeignKeyDiff 外键差异，外键按列和引用关系匹配
+type ForeignKeyDiff struct {
+	Name   string          `json:"Name"`
+	Status string          `json:"Status"`
+	Source *ForeignKeyInfo `json:"Source"`
+	Target *ForeignKeyInfo `json:"Target"`
+}
+
+// TableDiff 表差异
+type TableDiff struct {
+	Name        string           `json:"Name"`
+	Status      string           `json:"Status"`
+	Columns     []ColumnDiff     `json:"Columns"`
+	Indexes     []IndexDiff      `json:"Indexes"`
+	ForeignKeys []ForeignKeyDiff `json:"ForeignKeys"`
+}
+
+// ObjectDiff 视图或函数、存储过程的差异
+type ObjectDiff struct {
+	Name      string `json:"Name"`
+	Type      string `json:"Type"`
+	Status    string `json:"Status"`
+	SourceSQL string `json:"SourceSQL"`
+	TargetSQL string `json:"TargetSQL"`
+}
+
+// SchemaChange 迁移脚本中的一条语句，Destructive 表示可能丢失数据或删除对象
+type SchemaChange struct {
+	Object      string `json:"Object"`
+	SQL         string `json:"SQL"`
+	Destructive bool   `json:"Destructive"`
+
+	block bool
+}
+
+// SchemaDiff 两个库的结构差异，以及使目标库与源库一致的迁移脚本。
+// Script 包含全部语句，SafeScript 只包含非破坏性的语句
+type SchemaDiff struct {
+	SourceType  string         `json:"SourceType"`
+	TargetType  string         `json:"TargetType"`
+	Tables      []TableDiff    `json:"Tables"`
+	Views       []ObjectDiff   `json:"Views"`
+	Routines    []ObjectDiff   `json:"Routines"`
+	Changes     []SchemaChange `json:"Changes"`
+	Destructive int            `json:"Destructive"`
+	Script      string         `json:"Script"`
+	SafeScript  string         `json:"SafeScript"`
+}
+
+// schemaSnapshot 一个库的结构快照
+type schemaSnapshot struct {
+	dialect  Dialect
+	schema   string
+	prologue []string
+	tables   []DumpTable
+	columns  map[string][]ColumnInfo
+	indexes  map[string][]IndexInfo
+	fks      map[string][]ForeignKeyInfo
+	dumpIdx  map[string][]string
+	dumpFKs  map[string][]string
+	views    []DumpView
+	routines []DumpRoutine
+}
+
+// loadSnapshot 读取库的结构，PostgreSQL 只比较 schema 中的对象，schema 为空时使用 public
+func loadSnapshot(adapter DBAdapter, dbType, dbName, schema string) (*schemaSnapshot, error) {
+	s := &schemaSnapshot{
+		dialect: DialectOf(dbType),
+		columns: make(map[string][]ColumnInfo),
+		indexes: make(map[string][]IndexInfo),
+		fks:     make(map[string][]ForeignKeyInfo),
+		dumpIdx: make(map[string][]string),
+		dumpFKs: make(map[string][]string),
+	}
+	if dbType == "postgres" {
+		s.schema = schema
+		if s.schema == "" {
+			s.schema = "public"
+		}
+	}
+
+	dump, err := adapter.GetDumpSchema(dbName)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if s.schema != "" {
+		prefix = s.dialect.QuoteIdent(s.schema) + "."
+	}
+	for _, stmt := range dump.Schemas {
+		if strings.HasPrefix(stmt, "CREATE SEQUENCE") && strings.Contains(stmt, prefix) {
+			s.prologue = append(s.prologue, stmt)
+		}
+	}
+	for _, t := range dump.Tables {
+		if t.Schema != s.schema {
+			continue
+		}
+		s.tables = append(s.tables, t)
+		columns, err := SchemaTableColumns(adapter, dbName, s.schema, t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("read columns of %s: %v", t.Name, err)
+		}
+		s.columns[t.Name] = columns
+	}
+	for _, st := range dump.Indexes {
+		if st.Schema == s.schema {
+			s.dumpIdx[st.Table] = append(s.dumpIdx[st.Table], st.SQL)
+		}
+	}
+	for _, st := range dump.ForeignKeys {
+		if st.Schema == s.schema {
+			s.dumpFKs[st.Table] = append(s.dumpFKs[st.Table], st.SQL)
+		}
+	}
+	for _, v := range dump.Views {
+		if v.Schema == s.schema {
+			s.views = append(s.views, v)
+		}
+	}
+	for _, r := range dump.Routines {
+		if r.Schema == s.schema {
+			s.routines = append(s.routines, r)
+		}
+	}
+
+	indexes, err := adapter.GetIndexes(dbName, s.schema)
+	if err != nil {
+		return nil, fmt.Errorf("read indexes: %v", err)
+	}
+	for _, idx := range indexes {
+		s.indexes[idx.Table] = append(s.indexes[idx.Table], idx)
+	}
+	fks, err := adapter.GetForeignKeys(dbName, s.schema)
+	if err != nil {
+		return nil, fmt.Errorf("read foreign keys: %v", err)
+	}
+	for _, fk := range fks {
+		s.fks[fk.Table] = append(s.fks[fk.Table], fk)
+	}
+	return s, nil
+}
+
+// table 按名称查找表
+func (s *schemaSnapshot) table(name string) (DumpTable, bool) {
+	for _, t := range s.tables {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return DumpTable{}, false
+}
+
+// tableIndexes 返回表的索引，主键按列信息合成，避免 SQLite 的 rowid 主键没有索引
+func (s *schemaSnapshot) tableIndexes(table string) map[string]IndexInfo {
+	result := make(map[string]IndexInfo)
+	pk := IndexInfo{Table: table, Name: primaryKeyName, Unique: true, Primary: true, Constraint: true}
+	for _, idx := range s.indexes[table] {
+		if idx.Primary {
+			pk.Name = idx.Name
+			pk.Columns = idx.Columns
+			continue
+		}
+		result[idx.Name] = idx
+	}
+	if len(pk.Columns) == 0 {
+		for _, col := range s.columns[table] {
+			if col.IsPrimary {
+				pk.Columns = append(pk.Columns, col.Name)
+			}
+		}
+	}
+	if len(pk.Columns) > 0 {
+		result[primaryKeyName] = pk
+	}
+	return result
+}
+
+// tableForeignKeys 返回表的外键，以列和引用关系作为键
+func (s *schemaSnapshot) tableForeignKeys(table string) map[string]ForeignKeyInfo {
+	result := make(map[string]ForeignKeyInfo)
+	for _, fk := range s.fks[table] {
+		result[foreignKeySignature(fk)] = fk
+	}
+	return result
+}
+
+// foreignKeySignature 外键的比较键，不含外键名称
+func foreignKeySignature(fk ForeignKeyInfo) string {
+	return fmt.Sprintf("(%s) -> %s(%s) ON DELETE %s ON UPDATE %s", strings.Join(fk.Columns, ", "), fk.RefTable,
+		strings.Join(fk.RefColumns, ", "), foreignKeyAction(fk.OnDelete), foreignKeyAction(fk.OnUpdate))
+}
+
+// foreignKeyAction 统一外键动作，未设置时为 NO ACTION
+func foreignKeyAction(action string) string {
+	action = strings.ToUpper(strings.TrimSpace(action))
+	if action == "" {
+		return "NO ACTION"
+	}
+	return action
+}
+
+// normalizeType 统一类型名和参数，用于比较
+func normalizeType(t string) string {
+	name, params := baseType(t)
+	if isUnsigned(t) {
+		name += " unsigned"
+	}
+	// 整数的显示宽度不影响取值范围，MySQL 8.0 起不再显示（tinyint(1) 除外）
+	if len(params) == 0 || ColumnKind(name) == KindInteger && !strings.HasPrefix(name, "tinyint") {
+		return name
+	}
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = fmt.Sprint(p)
+	}
+	return name + "(" + strings.Join(parts, ",") + ")"
+}
+
+// normalizeSQL 合并空白并去掉结尾分号，用于比较视图和函数定义
+func normalizeSQL(sql string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(sql), " "), ";")
+}
+
+// SchemaCompareOptions 结构比较选项，Schema 只对 PostgreSQL 有效，为空时使用 public
+type SchemaCompareOptions struct {
+	SourceDatabase string `json:"SourceDatabase"`
+	SourceSchema   string `json:"SourceSchema"`
+	TargetDatabase string `json:"TargetDatabase"`
+	TargetSchema   string `json:"TargetSchema"`
+}
+
+// CompareSchemas 比较源库和目标库的表、列、索引、外键、视图和函数，生成使目标库与源库一致的迁移脚本。
+// 不同类型的数据库之间，列类型会先按目标方言映射再比较，视图和函数的定义原样输出
+func CompareSchemas(source DBAdapter, sourceType string, target DBAdapter, targetType string, opts SchemaCompareOptions) (*SchemaDiff, error) {
+	src, err := loadSnapshot(source, sourceType, opts.SourceDatabase, opts.SourceSchema)
+	if err != nil {
+		return nil, fmt.Errorf("read source schema: %v", err)
+	}
+	dst, err := loadSnapshot(target, targetType, opts.TargetDatabase, opts.TargetSchema)
+	if err != nil {
+		return nil, fmt.Errorf("read target schema: %v", err)
+	}
+
+	diff := &SchemaDiff{SourceType: sourceType, TargetType: targetType}
+	names := make(map[string]bool)
+	for _, t := range src.tables {
+		names[t.Name] = true
+	}
+	for _, t := range dst.tables {
+		names[t.Name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		_, inSource := src.table(name)
+		_, inTarget := dst.table(name)
+		switch {
+		case inSource && !inTarget:
+			diff.Tables = append(diff.Tables, TableDiff{Name: name, Status: DiffAdded})
+		case !inSource && inTarget:
+			diff.Tables = append(diff.Tables, TableDiff{Name: name, Status: DiffRemoved})
+		default:
+			if t := compareTable(src, dst, name); t != nil {
+				diff.Tables = append(diff.Tables, *t)
+			}
+		}
+	}
+
+	views := make(map[string][2]string)
+	for _, v := range src.views {
+		views[v.Name] = [2]string{v.SQL, views[v.Name][1]}
+	}
+	for _, v := range dst.views {
+		views[v.Name] = [2]string{views[v.Name][0], v.SQL}
+	}
+	for _, name := range sortedKeys(views) {
+		if o := compareObject(name, "VIEW", views[name]); o != nil {
+			diff.Views = append(diff.Views, *o)
+		}
+	}
+
+	routines := make(map[string][2]string)
+	types := make(map[string]string)
+	for _, r := range src.routines {
+		key := r.Type + " " + r.Name
+		routines[key] = [2]string{r.SQL, routines[key][1]}
+		types[key] = r.Type
+	}
+	for _, r := range dst.routines {
+		key := r.Type + " " + r.Name
+		routines[key] = [2]string{routines[key][0], r.SQL}
+		types[key] = r.Type
+	}
+	for _, key := range sortedKeys(routines) {
+		if o := compareObject(strings.TrimPrefix(key, types[key]+" "), types[key], routines[key]); o != nil {
+			diff.Routines = append(diff.Routines, *o)
+		}
+	}
+
+	m := &migration{src: src, dst: dst, d: dst.dialect, same: sourceType == targetType}
+	diff.Changes = m.build(diff)
+	var script, safe strings.Builder
+	for _, c := range diff.Changes {
+		stmt := strings.TrimRight(strings.TrimSpace(c.SQL), ";") + ";\n"
+		if c.block {
+			stmt = scriptBlock(m.d, c.SQL)
+		}
+		if c.Destructive {
+			diff.Destructive++
+			script.WriteString("-- destructive: " + c.Object + "\n")
+		} else {
+			safe.WriteString(stmt)
+		}
+		script.WriteString(stmt)
+	}
+	diff.Script = script.String()
+	diff.SafeScript = safe.String()
+	return diff, nil
+}
+
+// compareTable 比较两边都存在的表，没有差异时返回 nil
+func compareTable(src, dst *schemaSnapshot, name string) *TableDiff {
+	t := &TableDiff{Name: name, Status: DiffChanged}
+
+	srcCols := src.columns[name]
+	dstCols := make(map[string]ColumnInfo)
+	for _, col := range dst.columns[name] {
+		dstCols[col.Name] = col
+	}
+	seen := make(map[string]bool)
+	for i := range srcCols {
+		col := srcCols[i]
+		seen[col.Name] = true
+		other, ok := dstCols[col.Name]
+		if !ok {
+			t.Columns = append(t.Columns, ColumnDiff{Name: col.Name, Status: DiffAdded, Source: &col})
+			continue
+		}
+		srcType := normalizeType(MapColumnType(dst.dialect, src.dialect.Name, col))
+		if srcType != normalizeType(columnTypeSQL(other)) || col.Nullable != other.Nullable {
+			t.Columns = append(t.Columns, ColumnDiff{Name: col.Name, Status: DiffChanged, Source: &col, Target: &other})
+		}
+	}
+	for i := range dst.columns[name] {
+		col := dst.columns[name][i]
+		if !seen[col.Name] {
+			t.Columns = append(t.Columns, ColumnDiff{Name: col.Name, Status: DiffRemoved, Target: &col})
+		}
+	}
+
+	srcIdx, dstIdx := src.tableIndexes(name), dst.tableIndexes(name)
+	for _, key := range sortedKeys(srcIdx) {
+		idx := srcIdx[key]
+		other, ok := dstIdx[key]
+		switch {
+		case !ok:
+			t.Indexes = append(t.Indexes, IndexDiff{Name: key, Status: DiffAdded, Source: &idx})
+		case idx.Unique != other.Unique || strings.Join(idx.Columns, ",") != strings.Join(other.Columns, ","):
+			t.Indexes = append(t.Indexes, IndexDiff{Name: key, Status: DiffChanged, Source: &idx, Target: &other})
+		}
+	}
+	for _, key := range sortedKeys(dstIdx) {
+		if _, ok := srcIdx[key]; !ok {
+			idx := dstIdx[key]
+			t.Indexes = append(t.Indexes, IndexDiff{Name: key, Status: DiffRemoved, Target: &idx})
+		}
+	}
+
+	srcFKs, dstFKs := src.tableForeignKeys(name), dst.tableForeignKeys(name)
+	for _, key := range sortedKeys(srcFKs) {
+		if _, ok := dstFKs[key]; !ok {
+			fk := srcFKs[key]
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKeyDiff{Name: fk.Name, Status: DiffAdded, Source: &fk})
+		}
+	}
+	for _, key := range sortedKeys(dstFKs) {
+		if _, ok := srcFKs[key]; !ok {
+			fk := dstFKs[key]
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKeyDiff{Name: fk.Name, Status: DiffRemoved, Target: &fk})
+		}
+	}
+
+	if len(t.Columns) == 0 && len(t.Indexes) == 0 && len(t.ForeignKeys) == 0 {
+		return nil
+	}
+	return t
+}
+
+// compareObject 比较视图或函数的定义，没有差异时返回 nil
+func compareObject(name, typ string, sql [2]string) *ObjectDiff {
+	o := &ObjectDiff{Name: name, Type: typ, SourceSQL: sql[0], TargetSQL: sql[1]}
+	switch {
+	case sql[1] == "":
+		o.Status = DiffAdded
+	case sql[0] == "":
+		o.Status = DiffRemoved
+	case normalizeSQL(sql[0]) != normalizeSQL(sql[1]):
+		o.Status = DiffChanged
+	default:
+		return nil
+	}
+	return o
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mysqlAutoIncrementRe 匹配建表语句中的自增起始值
+var mysqlAutoIncrementRe = regexp.MustCompile(`\s+AUTO_INCREMENT=\d+`)
+
+// createTableNameRe 匹配建表语句中的表名
+var createTableNameRe = regexp.MustCompile("(?is)^\\s*CREATE\\s+TABLE\\s+(?:IF\\s+NOT\\s+EXISTS\\s+)?(?:\"(?:[^\"]|\"\")*\"|`[^`]*`|\\[[^\\]]*\\]|[^\\s(]+)")
+
+// migration 按依赖顺序生成迁移语句
+type migration struct {
+	src, dst *schemaSnapshot
+	d        Dialect
+	// same 为 true 时源和目标是同一种数据库，可以直接使用源库的定义
+	same bool
+
+	dropFKs, dropObjects, dropIndexes, createTables []SchemaChange
+	alterColumns, dropTables, createIndexes, addFKs []SchemaChange
+	createObjects                                   []SchemaChange
+	rebuilt                                         bool
+}
+
+// build 生成全部迁移语句
+func (m *migration) build(diff *SchemaDiff) []SchemaChange {
+	added := false
+	for _, t := range diff.Tables {
+		switch t.Status {
+		case DiffAdded:
+			added = true
+			m.createTable(t.Name)
+		case DiffRemoved:
+			m.dropTables = append(m.dropTables, SchemaChange{Object: "table " + t.Name, SQL: "DROP TABLE " + m.table(t.Name), Destructive: true})
+		default:
+			m.alterTable(t)
+		}
+	}
+	m.objects(diff)
+
+	var changes []SchemaChange
+	if m.rebuilt {
+		changes = append(changes, SchemaChange{Object: "pragma", SQL: "PRAGMA foreign_keys = OFF"})
+	}
+	if added && m.same {
+		for _, stmt := range m.src.prologue {
+			changes = append(changes, SchemaChange{Object: "sequence", SQL: stmt})
+		}
+	}
+	for _, group := range [][]SchemaChange{m.dropFKs, m.dropObjects, m.dropIndexes, m.createTables, m.alterColumns,
+		m.dropTables, m.createIndexes, m.addFKs, m.createObjects} {
+		changes = append(changes, group...)
+	}
+	if m.rebuilt {
+		changes = append(changes, SchemaChange{Object: "pragma", SQL: "PRAGMA foreign_keys = ON"})
+	}
+	return changes
+}
+
+// table 返回目标库中的表名
+func (m *migration) table(name string) string {
+	return m.d.TableName("", m.dst.schema, name)
+}
+
+// targetColumns 将源表的列转换为目标方言
+func (m *migration) targetColumns(name string) []ColumnInfo {
+	columns := make([]ColumnInfo, len(m.src.columns[name]))
+	for i, col := range m.src.columns[name] {
+		typ := MapColumnType(m.d, m.src.dialect.Name, col)
+		if col.IsPrimary {
+			typ = keyColumnType(m.d, typ)
+		}
+		columns[i] = ColumnInfo{Name: col.Name, Type: typ, Nullable: col.Nullable, IsPrimary: col.IsPrimary}
+	}
+	return columns
+}
+
+// createTable 创建目标库中缺少的表，同类数据库使用源库的建表语句
+func (m *migration) createTable(name string) {
+	object := "table " + name
+	if m.same {
+		t, _ := m.src.table(name)
+		m.createTables = append(m.createTables, SchemaChange{Object: object, SQL: mysqlAutoIncrementRe.ReplaceAllString(t.DDL, "")})
+		for _, stmt := range m.src.dumpIdx[name] {
+			m.createIndexes = append(m.createIndexes, SchemaChange{Object: object, SQL: stmt})
+		}
+		for _, stmt := range m.src.dumpFKs[name] {
+			m.addFKs = append(m.addFKs, SchemaChange{Object: object, SQL: stmt})
+		}
+		return
+	}
+	m.createTables = append(m.createTables, SchemaChange{Object: object, SQL: m.d.BuildCreateTable(m.table(name), m.targetColumns(name), false)})
+	for _, idx := range m.src.tableIndexes(name) {
+		if !idx.Primary {
+			m.createIndexes = append(m.createIndexes, m.addIndex(idx))
+		}
+	}
+	for _, fk := range m.src.fks[name] {
+		if m.d.Name != "sqlite" {
+			m.addFKs = append(m.addFKs, m.addForeignKey(fk))
+		}
+	}
+}
+
+// alterTable 修改两边都存在但结构不同的表
+func (m *migration) alterTable(t TableDiff) {
+	if m.d.Name == "sqlite" && m.needsRebuild(t) {
+		m.rebuildTable(t)
+		return
+	}
+	object := "table " + t.Name
+	table := m.table(t.Name)
+
+	for _, fk := range t.ForeignKeys {
+		switch fk.Status {
+		case DiffRemoved:
+			m.dropFKs = append(m.dropFKs, m.dropForeignKey(*fk.Target))
+		case DiffAdded:
+			m.addFKs = append(m.addFKs, m.addForeignKey(*fk.Source))
+		}
+	}
+	for _, idx := range t.Indexes {
+		if idx.Target != nil {
+			m.dropIndexes = append(m.dropIndexes, m.dropIndex(*idx.Target))
+		}
+		if idx.Source != nil {
+			m.createIndexes = append(m.createIndexes, m.addIndex(*idx.Source))
+		}
+	}
+
+	for _, col := range t.Columns {
+		column := object + "." + col.Name
+		switch col.Status {
+		case DiffAdded:
+			m.alterColumns = append(m.alterColumns, SchemaChange{Object: column,
+				SQL: "ALTER TABLE " + table + " ADD COLUMN " + m.columnDefinition(t.Name, *col.Source)})
+		case DiffRemoved:
+			m.alterColumns = append(m.alterColumns, SchemaChange{Object: column,
+				SQL: "ALTER TABLE " + table + " DROP COLUMN " + m.d.QuoteIdent(col.Name), Destructive: true})
+		default:
+			m.alterColumns = append(m.alterColumns, m.modifyColumn(t.Name, col)...)
+		}
+	}
+}
+
+// columnDefinition 返回列定义，同类数据库从源表的建表语句中取完整定义（含默认值等）
+func (m *migration) columnDefinition(table string, col ColumnInfo) string {
+	quoted := m.d.QuoteIdent(col.Name)
+	if m.same && m.d.Name != "sqlite" {
+		t, _ := m.src.table(table)
+		for _, line := range strings.Split(t.DDL, "\n") {
+			if def := strings.TrimSuffix(strings.TrimSpace(line), ","); strings.HasPrefix(def, quoted+" ") {
+				return def
+			}
+		}
+	}
+	def := quoted + " " + MapColumnType(m.d, m.src.dialect.Name, col)
+	if !col.Nullable {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+// modifyColumn 修改列的类型或可空性，类型变化可能截断数据
+func (m *migration) modifyColumn(table string, col ColumnDiff) []SchemaChange {
+	object := "table " + table + "." + col.Name
+	typ := MapColumnType(m.d, m.src.dialect.Name, *col.Source)
+	typeChanged := normalizeType(typ) != normalizeType(columnTypeSQL(*col.Target))
+	if m.d.Name == "mysql" {
+		return []SchemaChange{{Object: object, SQL: "ALTER TABLE " + m.table(table) + " MODIFY COLUMN " + m.columnDefinition(table, *col.Source),
+			Destructive: typeChanged}}
+	}
+
+	quoted := m.d.QuoteIdent(col.Name)
+	prefix := "ALTER TABLE " + m.table(table) + " ALTER COLUMN " + quoted
+	var changes []SchemaChange
+	if typeChanged {
+		changes = append(changes, SchemaChange{Object: object, SQL: prefix + " TYPE " + typ + " USING " + quoted + "::" + typ, Destructive: true})
+	}
+	if col.Source.Nullable != col.Target.Nullable {
+		action := " SET NOT NULL"
+		if col.Source.Nullable {
+			action = " DROP NOT NULL"
+		}
+		changes = append(changes, SchemaChange{Object: object, SQL: prefix + action})
+	}
+	return changes
+}
+
+// needsRebuild 判断 SQLite 表是否只能通过重建来修改
+func (m *migration) needsRebuild(t TableDiff) bool {
+	if len(t.ForeignKeys) > 0 {
+		return true
+	}
+	for _, col := range t.Columns {
+		if col.Status != DiffAdded || !col.Source.Nullable || col.Source.IsPrimary {
+			return true
+		}
+	}
+	for _, idx := range t.Indexes {
+		for _, i := range []*IndexInfo{idx.Source, idx.Target} {
+			if i != nil && i.Constraint {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rebuildTable 按 SQLite 推荐的步骤重建表：建新表、复制数据、删除旧表、重命名，再重建索引。
+// 重建的几条语句必须一起执行，会丢失数据时全部标记为破坏性
+func (m *migration) rebuildTable(t TableDiff) {
+	object := "table " + t.Name
+	temp := t.Name + "__new"
+	destructive := false
+	for _, col := range t.Columns {
+		if col.Status == DiffRemoved || (col.Status == DiffChanged &&
+			normalizeType(MapColumnType(m.d, m.src.dialect.Name, *col.Source)) != normalizeType(columnTypeSQL(*col.Target))) {
+			destructive = true
+		}
+	}
+
+	create := m.d.BuildCreateTable(m.table(temp), m.targetColumns(t.Name), false)
+	if m.same {
+		src, _ := m.src.table(t.Name)
+		create = createTableNameRe.ReplaceAllLiteralString(src.DDL, "CREATE TABLE "+m.table(temp))
+	}
+	targetCols := make(map[string]bool)
+	for _, col := range m.dst.columns[t.Name] {
+		targetCols[col.Name] = true
+	}
+	var common []string
+	for _, col := range m.src.columns[t.Name] {
+		if targetCols[col.Name] {
+			common = append(common, m.d.QuoteIdent(col.Name))
+		}
+	}
+	columns := strings.Join(common, ", ")
+
+	m.rebuilt = true
+	m.alterColumns = append(m.alterColumns,
+		SchemaChange{Object: object, SQL: create, Destructive: destructive},
+		SchemaChange{Object: object, SQL: fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", m.table(temp), columns, columns, m.table(t.Name)), Destructive: destructive},
+		SchemaChange{Object: object, SQL: "DROP TABLE " + m.table(t.Name), Destructive: destructive},
+		SchemaChange{Object: object, SQL: "ALTER TABLE " + m.table(temp) + " RENAME TO " + m.d.QuoteIdent(t.Name), Destructive: destructive},
+	)
+	for _, idx := range m.src.tableIndexes(t.Name) {
+		if !idx.Constraint {
+			m.createIndexes = append(m.createIndexes, m.addIndex(idx))
+		}
+	}
+}
+
+// indexColumns 返回索引列，PostgreSQL 的索引列已由 pg_get_indexdef 格式化，可能是表达式
+func (m *migration) indexColumns(idx IndexInfo) string {
+	if m.same && m.d.Name == "postgres" {
+		return strings.Join(idx.Columns, ", ")
+	}
+	quoted := make([]string, len(idx.Columns))
+	for i, c := range idx.Columns {
+		quoted[i] = m.d.QuoteIdent(c)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// addIndex 创建索引、唯一约束或主键
+func (m *migration) addIndex(idx IndexInfo) SchemaChange {
+	object := "index " + idx.Table + "." + idx.Name
+	table := m.table(idx.Table)
+	columns := m.indexColumns(idx)
+	switch {
+	case idx.Primary:
+		return SchemaChange{Object: "table " + idx.Table + " primary key", SQL: "ALTER TABLE " + table + " ADD PRIMARY KEY (" + columns + ")"}
+	case idx.Constraint && m.d.Name == "postgres":
+		return SchemaChange{Object: object, SQL: "ALTER TABLE " + table + " ADD CONSTRAINT " + m.d.QuoteIdent(idx.Name) + " UNIQUE (" + columns + ")"}
+	}
+	verb := "CREATE INDEX "
+	if idx.Unique {
+		verb = "CREATE UNIQUE INDEX "
+	}
+	return SchemaChange{Object: object, SQL: verb + m.d.QuoteIdent(idx.Name) + " ON " + table + " (" + columns + ")"}
+}
+
+// dropIndex 删除目标库中的索引、唯一约束或主键
+func (m *migration) dropIndex(idx IndexInfo) SchemaChange {
+	object := "index " + idx.Table + "." + idx.Name
+	table := m.table(idx.Table)
+	switch {
+	case idx.Primary && m.d.Name == "mysql":
+		return SchemaChange{Object: "table " + idx.Table + " primary key", SQL: "ALTER TABLE " + table + " DROP PRIMARY KEY"}
+	case m.d.Name == "mysql":
+		return SchemaChange{Object: object, SQL: "DROP INDEX " + m.d.QuoteIdent(idx.Name) + " ON " + table}
+	case idx.Constraint:
+		return SchemaChange{Object: object, SQL: "ALTER TABLE " + table + " DROP CONSTRAINT " + m.d.QuoteIdent(idx.Name)}
+	}
+	return SchemaChange{Object: object, SQL: "DROP INDEX " + m.d.TableName("", m.dst.schema, idx.Name)}
+}
+
+// addForeignKey 添加外键
+func (m *migration) addForeignKey(fk ForeignKeyInfo) SchemaChange {
+	cols := make([]string, len(fk.Columns))
+	for i, c := range fk.Columns {
+		cols[i] = m.d.QuoteIdent(c)
+	}
+	refs := make([]string, len(fk.RefColumns))
+	for i, c := range fk.RefColumns {
+		refs[i] = m.d.QuoteIdent(c)
+	}
+	sql := "ALTER TABLE " + m.table(fk.Table) + " ADD "
+	if fk.Name != "" {
+		sql += "CONSTRAINT " + m.d.QuoteIdent(fk.Name) + " "
+	}
+	sql += fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE %s ON UPDATE %s", strings.Join(cols, ", "),
+		m.table(fk.RefTable), strings.Join(refs, ", "), foreignKeyAction(fk.OnDelete), foreignKeyAction(fk.OnUpdate))
+	return SchemaChange{Object: "foreign key " + fk.Table + "." + fk.Name, SQL: sql}
+}
+
+// dropForeignKey 删除外键
+func (m *migration) dropForeignKey(fk ForeignKeyInfo) SchemaChange {
+	verb := " DROP CONSTRAINT "
+	if m.d.Name == "mysql" {
+		verb = " DROP FOREIGN KEY "
+	}
+	return SchemaChange{Object: "foreign key " + fk.Table + "." + fk.Name, SQL: "ALTER TABLE " + m.table(fk.Table) + verb + m.d.QuoteIdent(fk.Name)}
+}
+
+// objects 生成视图和函数的删除与创建语句，有变化的对象先删除再按源库定义重建
+func (m *migration) objects(diff *SchemaDiff) {
+	views := make(map[string]bool)
+	for _, v := range diff.Views {
+		if v.Status != DiffAdded {
+			m.dropObjects = append(m.dropObjects, SchemaChange{Object: "view " + v.Name,
+				SQL: "DROP VIEW IF EXISTS " + m.table(v.Name), Destructive: v.Status == DiffRemoved})
+		}
+		if v.Status != DiffRemoved {
+			views[v.Name] = true
+		}
+	}
+	// 按源库中的依赖顺序创建视图
+	for _, v := range sortViews(append([]DumpView(nil), m.src.views...)) {
+		if views[v.Name] {
+			m.createObjects = append(m.createObjects, SchemaChange{Object: "view " + v.Name, SQL: v.SQL})
+		}
+	}
+
+	for _, r := range diff.Routines {
+		object := strings.ToLower(r.Type) + " " + r.Name
+		if r.Status != DiffAdded {
+			m.dropObjects = append(m.dropObjects, SchemaChange{Object: object,
+				SQL: "DROP " + r.Type + " IF EXISTS " + m.routineName(r.Name), Destructive: r.Status == DiffRemoved})
+		}
+		if r.Status != DiffRemoved {
+			m.createObjects = append(m.createObjects, SchemaChange{Object: object, SQL: r.SourceSQL, block: true})
+		}
+	}
+}
+
+// routineName 返回函数名，PostgreSQL 的函数名带有参数列表以区分重载
+func (m *migration) routineName(name string) string {
+	args := ""
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		name, args = name[:i], name[i:]
+	}
+	return m.table(name) + args
+}
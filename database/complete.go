@@ -0,0 +1,298 @@
+package database
+
+import (
+	"strings"
+)
+
+// 补全项类型
+const (
+	CompletionKeyword = "keyword"
+	CompletionSchema  = "schema"
+	CompletionTable   = "table"
+	CompletionAlias   = "alias"
+	CompletionColumn  = "column"
+	CompletionRoutine = "routine"
+)
+
+// maxCompletionItems 单次补全返回的最大条数
+const maxCompletionItems = 200
+
+// CompletionItem 补全建议
+type CompletionItem struct {
+	Label  string `json:"Label"`
+	Kind   string `json:"Kind"`
+	Detail string `json:"Detail"`
+}
+
+// tableRef 语句中引用的表
+type tableRef struct {
+	schema string
+	name   string
+	alias  string
+}
+
+// 光标前最近的关键字决定补全上下文
+var (
+	tableContextKeywords  = []string{"FROM", "JOIN", "UPDATE", "INTO", "TABLE", "TRUNCATE", "DESCRIBE"}
+	columnContextKeywords = []string{
+		"SELECT", "WHERE", "ON", "AND", "OR", "BY", "SET", "HAVING", "WHEN", "THEN",
+		"ELSE", "CASE", "NOT", "IN", "LIKE", "BETWEEN", "RETURNING", "USING", "DISTINCT",
+	}
+)
+
+// Complete 根据光标位置（字节偏移）返回补全建议
+func (c *MetadataCache) Complete(config DatabaseConfig, dbName, sql string, cursor int) []CompletionItem {
+	if cursor < 0 {
+		cursor = 0
+	}
+	if cursor > len(sql) {
+		cursor = len(sql)
+	}
+
+	tokens := statementAt(Tokenize(DialectOf(config.Type), sql), cursor)
+
+	// 光标所在的正在输入的单词
+	prefix := ""
+	prefixStart := cursor
+	for _, tok := range tokens {
+		if tok.Start < cursor && cursor <= tok.End &&
+			(tok.Type == TokenIdent || tok.Type == TokenKeyword || tok.Type == TokenQuotedIdent) {
+			prefix = strings.TrimLeft(sql[tok.Start:cursor], "\"`")
+			prefixStart = tok.Start
+		}
+	}
+
+	var before, all []Token
+	for _, tok := range tokens {
+		if tok.Type == TokenWhitespace || tok.Type == TokenComment {
+			continue
+		}
+		// 正在输入的单词不参与表引用解析，避免被当成别名
+		if prefix != "" && tok.Start == prefixStart {
+			continue
+		}
+		all = append(all, tok)
+		if tok.End <= prefixStart {
+			before = append(before, tok)
+		}
+	}
+
+	qualifier := ""
+	if n := len(before); n >= 2 && before[n-1].Text == "." && isNameToken(before[n-2]) {
+		qualifier = before[n-2].Value()
+	}
+
+	refs := parseTableRefs(all)
+
+	e := c.entry(config, dbName)
+	e.mu.RLock()
+	tables := append([]*TableMeta{}, e.tables...)
+	schemas := append([]string{}, e.schemas...)
+	routines := append([]RoutineInfo{}, e.routines...)
+	e.mu.RUnlock()
+
+	// 需要列信息的表：限定名指向的表或 FROM 子句中的表
+	var targets []*TableMeta
+	if qualifier != "" {
+		for _, ref := range refs {
+			if strings.EqualFold(ref.alias, qualifier) || strings.EqualFold(ref.name, qualifier) {
+				if t := findTable(tables, ref.schema, ref.name); t != nil {
+					targets = append(targets, t)
+				}
+			}
+		}
+		if len(targets) == 0 {
+			if t := findTable(tables, "", qualifier); t != nil {
+				targets = append(targets, t)
+			}
+		}
+	} else {
+		for _, ref := range refs {
+			if t := findTable(tables, ref.schema, ref.name); t != nil {
+				targets = append(targets, t)
+			}
+		}
+	}
+	c.ensureColumns(e, targets)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var items []CompletionItem
+	add := func(label, kind, detail string) {
+		if prefix == "" || strings.HasPrefix(strings.ToLower(label), strings.ToLower(prefix)) {
+			items = append(items, CompletionItem{Label: label, Kind: kind, Detail: detail})
+		}
+	}
+	addColumns := func(list []*TableMeta) {
+		for _, t := range list {
+			for _, col := range t.Columns {
+				add(col.Name, CompletionColumn, t.Name+" · "+col.Type)
+			}
+		}
+	}
+	addTables := func(schema string) {
+		for _, t := range tables {
+			if schema == "" || strings.EqualFold(t.Schema, schema) {
+				add(t.Name, CompletionTable, t.Comment)
+			}
+		}
+	}
+
+	if qualifier != "" {
+		// alias. / table. 补全列，schema. 补全表
+		addColumns(targets)
+		for _, s := range schemas {
+			if strings.EqualFold(s, qualifier) {
+				addTables(s)
+			}
+		}
+		return finishCompletion(items)
+	}
+
+	switch contextKeyword(before) {
+	case "table":
+		addTables("")
+		if config.Type == "postgres" {
+			for _, s := range schemas {
+				add(s, CompletionSchema, "")
+			}
+		}
+	case "column":
+		addColumns(targets)
+		for _, ref := range refs {
+			if ref.alias != "" {
+				add(ref.alias, CompletionAlias, ref.name)
+			}
+		}
+		for _, r := range routines {
+			add(r.Name, CompletionRoutine, r.Type)
+		}
+		addTables("")
+		for _, k := range Keywords(config.Type) {
+			add(k, CompletionKeyword, "")
+		}
+	default:
+		for _, k := range Keywords(config.Type) {
+			add(k, CompletionKeyword, "")
+		}
+		addTables("")
+	}
+	return finishCompletion(items)
+}
+
+// finishCompletion 去重并截断补全结果，保持插入顺序
+func finishCompletion(items []CompletionItem) []CompletionItem {
+	seen := make(map[string]bool)
+	result := []CompletionItem{}
+	for _, item := range items {
+		key := item.Kind + "\x00" + item.Label
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, item)
+		if len(result) >= maxCompletionItems {
+			break
+		}
+	}
+	return result
+}
+
+// statementAt 返回光标所在语句的词法单元
+func statementAt(tokens []Token, cursor int) []Token {
+	start := 0
+	for i, tok := range tokens {
+		if tok.Type == TokenPunct && tok.Text == ";" {
+			if tok.End <= cursor {
+				start = i + 1
+			} else {
+				return tokens[start:i]
+			}
+		}
+	}
+	return tokens[start:]
+}
+
+// contextKeyword 根据光标前最近的关键字判断补全上下文
+func contextKeyword(before []Token) string {
+	for i := len(before) - 1; i >= 0; i-- {
+		tok := before[i]
+		if tok.Type != TokenKeyword {
+			continue
+		}
+		switch {
+		case tok.IsKeyword(tableContextKeywords...):
+			return "table"
+		case tok.IsKeyword(columnContextKeywords...):
+			return "column"
+		case tok.IsKeyword("AS", "ASC", "DESC", "NULL", "IS", "TRUE", "FALSE"):
+			continue
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// parseTableRefs 解析 FROM/JOIN/UPDATE/INTO 后引用的表及其别名
+func parseTableRefs(tokens []Token) []tableRef {
+	var refs []tableRef
+	for i := 0; i < len(tokens); i++ {
+		if !tokens[i].IsKeyword("FROM", "JOIN", "UPDATE", "INTO", "TABLE", "TRUNCATE", "DESCRIBE") {
+			continue
+		}
+		multi := tokens[i].IsKeyword("FROM", "UPDATE")
+		j := i + 1
+		for j < len(tokens) {
+			ref, next, ok := parseTableRef(tokens, j)
+			if !ok {
+				break
+			}
+			refs = append(refs, ref)
+			j = next
+			if !multi || j >= len(tokens) || tokens[j].Text != "," {
+				break
+			}
+			j++
+		}
+		i = j - 1
+	}
+	return refs
+}
+
+// parseTableRef 解析 [schema.]table [[AS] alias]
+func parseTableRef(tokens []Token, i int) (tableRef, int, bool) {
+	if i >= len(tokens) || !isNameToken(tokens[i]) {
+		return tableRef{}, i, false
+	}
+	ref := tableRef{name: tokens[i].Value()}
+	i++
+	if i+1 < len(tokens) && tokens[i].Text == "." && isNameToken(tokens[i+1]) {
+		ref.schema = ref.name
+		ref.name = tokens[i+1].Value()
+		i += 2
+	}
+	if i < len(tokens) && tokens[i].IsKeyword("AS") {
+		i++
+	}
+	if i < len(tokens) && (tokens[i].Type == TokenIdent || tokens[i].Type == TokenQuotedIdent) {
+		ref.alias = tokens[i].Value()
+		i++
+	}
+	return ref, i, true
+}
+
+func isNameToken(tok Token) bool {
+	return tok.Type == TokenIdent || tok.Type == TokenQuotedIdent
+}
+
+// findTable 按名称查找表，schema 为空时匹配任意 schema
+func findTable(tables []*TableMeta, schema, name string) *TableMeta {
+	for _, t := range tables {
+		if strings.EqualFold(t.Name, name) && (schema == "" || strings.EqualFold(t.Schema, schema)) {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,601 @@
+package database
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// defaultCompareChunk 数据比较时每个主键区间的行数
+const defaultCompareChunk = 1000
+
+// defaultCompareMaxDiffs 默认最多记录的差异行数，超过后只计数
+const defaultCompareMaxDiffs = 1000
+
+// DataCompareOptions 表数据比较的选项
+type DataCompareOptions struct {
+	SourceDatabase string `json:"SourceDatabase"`
+	SourceSchema   string `json:"SourceSchema"`
+	SourceTable    string `json:"SourceTable"`
+	TargetDatabase string `json:"TargetDatabase"`
+	TargetSchema   string `json:"TargetSchema"`
+	// TargetTable 目标表名，为空时与源表相同
+	TargetTable string `json:"TargetTable"`
+	// KeyColumns 用于匹配行的列，为空时使用源表主键
+	KeyColumns []string `json:"KeyColumns"`
+	// Columns 参与比较的列，为空时比较两表共有的列
+	Columns   []string `json:"Columns"`
+	ChunkSize int      `json:"ChunkSize"`
+	MaxDiffs  int      `json:"MaxDiffs"`
+}
+
+// RowDiff 一行数据的差异。Added 表示只在源表中，Removed 表示只在目标表中，
+// Source 和 Target 的值与 DataDiff.Columns 一一对应
+type RowDiff struct {
+	Status  string        `json:"Status"`
+	Key     []interface{} `json:"Key"`
+	Columns []string      `json:"Columns"`
+	Source  []interface{} `json:"Source"`
+	Target  []interface{} `json:"Target"`
+
+	sourceRaw []interface{}
+	targetRaw []interface{}
+	dropped   bool
+}
+
+// DataDiff 表数据比较结果，Script 为使目标表与源表一致的同步语句（只包含已记录的差异行）
+type DataDiff struct {
+	Columns        []string  `json:"Columns"`
+	KeyColumns     []string  `json:"KeyColumns"`
+	Chunks         int       `json:"Chunks"`
+	ChunksDiffered int       `json:"ChunksDiffered"`
+	SourceRows     int64     `json:"SourceRows"`
+	TargetRows     int64     `json:"TargetRows"`
+	Identical      int64     `json:"Identical"`
+	Added          int64     `json:"Added"`
+	Removed        int64     `json:"Removed"`
+	Changed        int64     `json:"Changed"`
+	Rows           []RowDiff `json:"Rows"`
+	Truncated      bool      `json:"Truncated"`
+	Script         string    `json:"Script"`
+}
+
+// DataCompareProgress 数据比较进度
+type DataCompareProgress struct {
+	Rows        int64 `json:"Rows"`
+	TotalRows   int64 `json:"TotalRows"`
+	Chunks      int   `json:"Chunks"`
+	Differences int64 `json:"Differences"`
+}
+
+// DataCompareProgressFunc 数据比较进度回调
+type DataCompareProgressFunc func(p DataCompareProgress)
+
+// compareSide 比较的一侧
+type compareSide struct {
+	adapter DBAdapter
+	d       Dialect
+	db      string
+	table   string
+}
+
+// rowCollector 收集查询结果的 RowSink
+type rowCollector struct {
+	columns []ResultColumn
+	rows    [][]interface{}
+}
+
+func (c *rowCollector) Begin(columns []ResultColumn) error {
+	c.columns = columns
+	return nil
+}
+
+func (c *rowCollector) Row(values []interface{}) error {
+	c.rows = append(c.rows, append([]interface{}(nil), values...))
+	return nil
+}
+
+// dataComparer 按主键区间分块比较两张表
+type dataComparer struct {
+	ctx      context.Context
+	src, dst compareSide
+	keys     []string
+	columns  []string
+	chunk    int
+	maxDiffs int
+	// useChecksum 为 true 时先在服务端计算每个区间的校验和，相同的区间不再读取数据
+	useChecksum bool
+
+	srcCols, dstCols []ResultColumn
+	diff             DataDiff
+	added, removed   map[string]int
+}
+
+// CompareTableData 按主键比较两张表（可以在不同连接上）的数据。源表按主键分成若干区间，
+// 两边同为 MySQL 或 PostgreSQL 时先比较每个区间的校验和，只有校验和不同的区间才读取数据逐行比较
+func CompareTableData(ctx context.Context, source DBAdapter, sourceType string, target DBAdapter, targetType string, opts DataCompareOptions, progress DataCompareProgressFunc) (*DataDiff, error) {
+	if opts.TargetTable == "" {
+		opts.TargetTable = opts.SourceTable
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = defaultCompareChunk
+	}
+	if opts.MaxDiffs <= 0 {
+		opts.MaxDiffs = defaultCompareMaxDiffs
+	}
+
+	srcColumns, err := SchemaTableColumns(source, opts.SourceDatabase, opts.SourceSchema, opts.SourceTable)
+	if err != nil {
+		return nil, fmt.Errorf("read columns of %s: %v", opts.SourceTable, err)
+	}
+	dstColumns, err := SchemaTableColumns(target, opts.TargetDatabase, opts.TargetSchema, opts.TargetTable)
+	if err != nil {
+		return nil, fmt.Errorf("read columns of %s: %v", opts.TargetTable, err)
+	}
+	if len(srcColumns) == 0 {
+		return nil, fmt.Errorf("table %s not found", opts.SourceTable)
+	}
+	if len(dstColumns) == 0 {
+		return nil, fmt.Errorf("table %s not found", opts.TargetTable)
+	}
+	keys, columns, err := compareColumns(srcColumns, dstColumns, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	src, dst := DialectOf(sourceType), DialectOf(targetType)
+	c := &dataComparer{
+		ctx:         ctx,
+		src:         compareSide{adapter: source, d: src, db: opts.SourceDatabase, table: src.TableName(opts.SourceDatabase, opts.SourceSchema, opts.SourceTable)},
+		dst:         compareSide{adapter: target, d: dst, db: opts.TargetDatabase, table: dst.TableName(opts.TargetDatabase, opts.TargetSchema, opts.TargetTable)},
+		keys:        keys,
+		columns:     columns,
+		chunk:       opts.ChunkSize,
+		maxDiffs:    opts.MaxDiffs,
+		useChecksum: sourceType == targetType && sourceType != "sqlite",
+		diff:        DataDiff{Columns: columns, KeyColumns: keys},
+		added:       make(map[string]int),
+		removed:     make(map[string]int),
+	}
+
+	var total int64
+	if rows, err := source.ExecuteQueryArgs(opts.SourceDatabase, "SELECT COUNT(*) AS n FROM "+c.src.table, nil); err == nil && len(rows) == 1 {
+		fmt.Sscan(rows[0]["n"], &total)
+	}
+	report := func() {
+		if progress != nil {
+			progress(DataCompareProgress{Rows: c.diff.SourceRows, TotalRows: total, Chunks: c.diff.Chunks,
+				Differences: c.diff.Added + c.diff.Removed + c.diff.Changed})
+		}
+	}
+
+	var lo []interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		hi, err := c.boundary(lo)
+		if err != nil {
+			return nil, fmt.Errorf("read source keys: %v", err)
+		}
+		if err := c.compareChunk(lo, hi); err != nil {
+			return nil, err
+		}
+		c.diff.Chunks++
+		report()
+		if hi == nil {
+			break
+		}
+		lo = hi
+	}
+
+	rows := c.diff.Rows[:0]
+	for _, r := range c.diff.Rows {
+		if !r.dropped {
+			rows = append(rows, r)
+		}
+	}
+	c.diff.Rows = rows
+	c.diff.Script = c.syncScript()
+	return &c.diff, nil
+}
+
+// compareColumns 确定匹配行的键列和参与比较的列，键列排在前面
+func compareColumns(srcColumns, dstColumns []ColumnInfo, opts DataCompareOptions) ([]string, []string, error) {
+	inTarget := make(map[string]bool)
+	for _, col := range dstColumns {
+		inTarget[col.Name] = true
+	}
+	keys := opts.KeyColumns
+	if len(keys) == 0 {
+		for _, col := range srcColumns {
+			if col.IsPrimary {
+				keys = append(keys, col.Name)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("table %s has no primary key, key columns are required", opts.SourceTable)
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range opts.Columns {
+		selected[name] = true
+	}
+	isKey := make(map[string]bool)
+	for _, k := range keys {
+		isKey[k] = true
+	}
+	columns := append([]string(nil), keys...)
+	inSource := make(map[string]bool)
+	for _, col := range srcColumns {
+		inSource[col.Name] = true
+		if !isKey[col.Name] && inTarget[col.Name] && (len(selected) == 0 || selected[col.Name]) {
+			columns = append(columns, col.Name)
+		}
+	}
+	for _, name := range columns {
+		if !inSource[name] {
+			return nil, nil, fmt.Errorf("column %s not found in source table", name)
+		}
+		if !inTarget[name] {
+			return nil, nil, fmt.Errorf("column %s not found in target table", name)
+		}
+	}
+	return keys, columns, nil
+}
+
+// tuple 返回键列组成的表达式，多列时为行值
+func (s compareSide) tuple(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = s.d.QuoteIdent(k)
+	}
+	if len(keys) == 1 {
+		return quoted[0]
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// rangeWhere 返回主键区间 (lo, hi] 的过滤条件，lo 或 hi 为 nil 时该侧不设边界
+func (s compareSide) rangeWhere(keys []string, lo, hi []interface{}) (string, []interface{}) {
+	tuple := s.tuple(keys)
+	var conds []string
+	var args []interface{}
+	bound := func(op string, values []interface{}) {
+		ph := make([]string, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			ph[i] = s.d.Placeholder(len(args))
+		}
+		rhs := strings.Join(ph, ", ")
+		if len(values) > 1 {
+			rhs = "(" + rhs + ")"
+		}
+		conds = append(conds, tuple+" "+op+" "+rhs)
+	}
+	if lo != nil {
+		bound(">", lo)
+	}
+	if hi != nil {
+		bound("<=", hi)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// query 执行查询并收集全部结果
+func (s compareSide) query(ctx context.Context, query string, args []interface{}) (*rowCollector, error) {
+	c := &rowCollector{}
+	if err := s.adapter.StreamQuery(ctx, s.db, query, args, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// boundary 返回从 lo 开始第 chunk 行的主键作为区间上界，剩余行数不足时返回 nil
+func (c *dataComparer) boundary(lo []interface{}) ([]interface{}, error) {
+	where, args := c.src.rangeWhere(c.keys, lo, nil)
+	order := strings.Trim(c.src.tuple(c.keys), "()")
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT 1 OFFSET %d", order, c.src.table, where, order, c.chunk-1)
+	result, err := c.src.query(c.ctx, query, args)
+	if err != nil || len(result.rows) == 0 {
+		return nil, err
+	}
+	// 驱动返回的文本为 []byte，作为参数传给其他数据库时需要转换为字符串
+	hi := result.rows[0]
+	for i, v := range hi {
+		if b, ok := v.([]byte); ok && ColumnKind(result.columns[i].DatabaseType) != KindBinary {
+			hi[i] = string(b)
+		}
+	}
+	return hi, nil
+}
+
+// checksumQuery 返回区间内行数和校验和的查询
+func (s compareSide) checksumQuery(columns []string, where string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = s.d.QuoteIdent(col)
+	}
+	if s.d.Name == "mysql" {
+		// CONCAT_WS 会跳过 NULL，用 ISNULL 区分 NULL 和空字符串
+		parts := make([]string, len(quoted))
+		for i, q := range quoted {
+			parts[i] = "ISNULL(" + q + "), " + q
+		}
+		return fmt.Sprintf("SELECT COUNT(*) AS n, COALESCE(SUM(CRC32(CONCAT_WS('|', %s))), 0) AS h FROM %s%s",
+			strings.Join(parts, ", "), s.table, where)
+	}
+	return fmt.Sprintf("SELECT count(*) AS n, COALESCE(sum(('x' || substr(md5(ROW(%s)::text), 1, 8))::bit(32)::int::bigint), 0) AS h FROM %s%s",
+		strings.Join(quoted, ", "), s.table, where)
+}
+
+// checksum 计算区间的行数和校验和
+func (c *dataComparer) checksum(s compareSide, lo, hi []interface{}) (int64, string, error) {
+	where, args := s.rangeWhere(c.keys, lo, hi)
+	rows, err := s.adapter.ExecuteQueryArgs(s.db, s.checksumQuery(c.columns, where), args)
+	if err != nil {
+		return 0, "", err
+	}
+	if len(rows) != 1 {
+		return 0, "", fmt.Errorf("unexpected checksum result")
+	}
+	var n int64
+	fmt.Sscan(rows[0]["n"], &n)
+	return n, rows[0]["h"], nil
+}
+
+// fetch 读取区间内的全部行
+func (c *dataComparer) fetch(s compareSide, lo, hi []interface{}) (*rowCollector, error) {
+	quoted := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		quoted[i] = s.d.QuoteIdent(col)
+	}
+	where, args := s.rangeWhere(c.keys, lo, hi)
+	return s.query(c.ctx, fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(quoted, ", "), s.table, where), args)
+}
+
+// compareChunk 比较一个主键区间
+func (c *dataComparer) compareChunk(lo, hi []interface{}) error {
+	if c.useChecksum {
+		srcN, srcHash, err := c.checksum(c.src, lo, hi)
+		if err != nil {
+			return fmt.Errorf("source checksum: %v", err)
+		}
+		dstN, dstHash, err := c.checksum(c.dst, lo, hi)
+		if err != nil {
+			return fmt.Errorf("target checksum: %v", err)
+		}
+		if srcN == dstN && srcHash == dstHash {
+			c.diff.SourceRows += srcN
+			c.diff.TargetRows += dstN
+			c.diff.Identical += srcN
+			return nil
+		}
+	}
+
+	src, err := c.fetch(c.src, lo, hi)
+	if err != nil {
+		return fmt.Errorf("read source rows: %v", err)
+	}
+	dst, err := c.fetch(c.dst, lo, hi)
+	if err != nil {
+		return fmt.Errorf("read target rows: %v", err)
+	}
+	if c.srcCols == nil {
+		c.srcCols = src.columns
+	}
+	if c.dstCols == nil {
+		c.dstCols = dst.columns
+	}
+	c.diff.SourceRows += int64(len(src.rows))
+	c.diff.TargetRows += int64(len(dst.rows))
+
+	differences := c.diff.Added + c.diff.Removed + c.diff.Changed
+	targetRows := make(map[string][]interface{}, len(dst.rows))
+	for _, row := range dst.rows {
+		targetRows[c.rowKey(c.dstCols, row)] = row
+	}
+	for _, row := range src.rows {
+		key := c.rowKey(c.srcCols, row)
+		other, ok := targetRows[key]
+		if !ok {
+			c.record(key, DiffAdded, row, nil)
+			continue
+		}
+		delete(targetRows, key)
+		c.compareRows(key, row, other)
+	}
+	for _, row := range dst.rows {
+		key := c.rowKey(c.dstCols, row)
+		if _, ok := targetRows[key]; ok {
+			c.record(key, DiffRemoved, nil, row)
+		}
+	}
+	if c.diff.Added+c.diff.Removed+c.diff.Changed > differences {
+		c.diff.ChunksDiffered++
+	}
+	return nil
+}
+
+// rowKey 返回行的键列组成的比较键
+func (c *dataComparer) rowKey(columns []ResultColumn, row []interface{}) string {
+	parts := make([]string, len(c.keys))
+	for i := range c.keys {
+		if v := CompareValue(columns[i], row[i]); v != nil {
+			parts[i] = v.(string)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// compareRows 比较两边都存在的一行，返回是否有差异
+func (c *dataComparer) compareRows(key string, src, dst []interface{}) bool {
+	var changed []string
+	for i := len(c.keys); i < len(c.columns); i++ {
+		if CompareValue(c.srcCols[i], src[i]) != CompareValue(c.dstCols[i], dst[i]) {
+			changed = append(changed, c.columns[i])
+		}
+	}
+	if len(changed) == 0 {
+		c.diff.Identical++
+		return false
+	}
+	c.diff.Changed++
+	if len(c.diff.Rows) >= c.maxDiffs {
+		c.diff.Truncated = true
+		return true
+	}
+	c.diff.Rows = append(c.diff.Rows, RowDiff{
+		Status:    DiffChanged,
+		Key:       c.display(c.srcCols, src)[:len(c.keys)],
+		Columns:   changed,
+		Source:    c.display(c.srcCols, src),
+		Target:    c.display(c.dstCols, dst),
+		sourceRaw: src,
+		targetRaw: dst,
+	})
+	return true
+}
+
+// record 记录只在一侧存在的行。不同类型数据库的排序规则可能不同，
+// 同一行可能落在两边不同的区间中，因此先与已记录的另一侧的行配对
+func (c *dataComparer) record(key, status string, src, dst []interface{}) {
+	pending, other := c.added, c.removed
+	if status == DiffRemoved {
+		pending, other = c.removed, c.added
+	}
+	if i, ok := other[key]; ok {
+		r := &c.diff.Rows[i]
+		r.dropped = true
+		delete(other, key)
+		if status == DiffAdded {
+			c.diff.Removed--
+			c.compareRows(key, src, r.targetRaw)
+		} else {
+			c.diff.Added--
+			c.compareRows(key, r.sourceRaw, dst)
+		}
+		return
+	}
+
+	if status == DiffAdded {
+		c.diff.Added++
+	} else {
+		c.diff.Removed++
+	}
+	if len(c.diff.Rows) >= c.maxDiffs {
+		c.diff.Truncated = true
+		return
+	}
+	r := RowDiff{Status: status, sourceRaw: src, targetRaw: dst}
+	if src != nil {
+		r.Source = c.display(c.srcCols, src)
+		r.Key = r.Source[:len(c.keys)]
+	} else {
+		r.Target = c.display(c.dstCols, dst)
+		r.Key = r.Target[:len(c.keys)]
+	}
+	pending[key] = len(c.diff.Rows)
+	c.diff.Rows = append(c.diff.Rows, r)
+}
+
+// display 返回用于展示的值
+func (c *dataComparer) display(columns []ResultColumn, row []interface{}) []interface{} {
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		values[i] = CompareValue(columns[i], v)
+	}
+	return values
+}
+
+// CompareValue 将驱动返回的值统一为可比较的文本：数值去掉多余的零，布尔值为 1/0，
+// 日期时间统一格式，二进制为十六进制，NULL 返回 nil
+func CompareValue(col ResultColumn, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch ColumnKind(col.DatabaseType) {
+	case KindBinary:
+		if b, ok := v.([]byte); ok {
+			return "0x" + hex.EncodeToString(b)
+		}
+	case KindBool:
+		switch b := v.(type) {
+		case bool:
+			if b {
+				return "1"
+			}
+			return "0"
+		case int64:
+			return fmt.Sprint(b)
+		}
+		switch strings.ToLower(FormatValue(col.DatabaseType, v)) {
+		case "t", "true", "1":
+			return "1"
+		case "f", "false", "0":
+			return "0"
+		}
+	case KindInteger, KindNumber:
+		if text, ok := NumberText(v); ok {
+			if strings.Contains(text, ".") && !strings.ContainsAny(text, "eE") {
+				text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+			}
+			return text
+		}
+	case KindDate, KindDateTime:
+		if t, ok := ParseTimeValue(v); ok {
+			return FormatValue(col.DatabaseType, t)
+		}
+	}
+	return FormatValue(col.DatabaseType, v)
+}
+
+// syncScript 生成使目标表与源表一致的 DELETE、UPDATE、INSERT 语句
+func (c *dataComparer) syncScript() string {
+	d := c.dst.d
+	quoted := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		quoted[i] = d.QuoteIdent(col)
+	}
+	where := func(columns []ResultColumn, row []interface{}) string {
+		conds := make([]string, len(c.keys))
+		for i := range c.keys {
+			conds[i] = quoted[i] + " = " + d.Literal(columns[i], row[i])
+		}
+		return " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var deletes, updates, inserts []string
+	for _, r := range c.diff.Rows {
+		switch r.Status {
+		case DiffRemoved:
+			deletes = append(deletes, "DELETE FROM "+c.dst.table+where(c.dstCols, r.targetRaw)+";")
+		case DiffChanged:
+			var sets []string
+			for i := len(c.keys); i < len(c.columns); i++ {
+				for _, name := range r.Columns {
+					if name == c.columns[i] {
+						sets = append(sets, quoted[i]+" = "+d.Literal(c.srcCols[i], r.sourceRaw[i]))
+					}
+				}
+			}
+			updates = append(updates, "UPDATE "+c.dst.table+" SET "+strings.Join(sets, ", ")+where(c.srcCols, r.sourceRaw)+";")
+		case DiffAdded:
+			values := make([]string, len(r.sourceRaw))
+			for i, v := range r.sourceRaw {
+				values[i] = d.Literal(c.srcCols[i], v)
+			}
+			inserts = append(inserts, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", c.dst.table, strings.Join(quoted, ", "), strings.Join(values, ", ")))
+		}
+	}
+	statements := append(append(deletes, updates...), inserts...)
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, "\n") + "\n"
+}
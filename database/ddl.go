@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// columnTypeSQL 返回列类型定义，长度已包含在类型中时不再追加
+func columnTypeSQL(col ColumnInfo) string {
+	if col.Precision > 0 && !strings.Contains(col.Type, "(") {
+		return fmt.Sprintf("%s(%d,%d)", col.Type, col.Precision, col.Scale)
+	}
+	if col.Length > 0 && !strings.Contains(col.Type, "(") && typeTakesLength(col.Type) {
+		return fmt.Sprintf("%s(%d)", col.Type, col.Length)
+	}
+	return col.Type
+}
+
+// typeTakesLength 判断类型是否需要长度参数
+func typeTakesLength(t string) bool {
+	switch strings.ToLower(t) {
+	case "char", "varchar", "character", "character varying", "binary", "varbinary", "bit", "bit varying", "nvarchar", "nchar":
+		return true
+	}
+	return false
+}
+
+// BuildCreateTable 根据列信息生成 CREATE TABLE 语句
+func (d Dialect) BuildCreateTable(table string, columns []ColumnInfo, ifNotExists bool) string {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE ")
+	if ifNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(table)
+	sb.WriteString(" (\n")
+
+	var pk []string
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(",\n")
+		}
+		sb.WriteString("  " + d.QuoteIdent(col.Name) + " " + columnTypeSQL(col))
+		if !col.Nullable {
+			sb.WriteString(" NOT NULL")
+		}
+		if col.IsPrimary {
+			pk = append(pk, d.QuoteIdent(col.Name))
+		}
+	}
+	if len(pk) > 0 {
+		sb.WriteString(",\n  PRIMARY KEY (" + strings.Join(pk, ", ") + ")")
+	}
+	sb.WriteString("\n);")
+	return sb.String()
+}
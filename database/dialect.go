@@ -0,0 +1,225 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Dialect SQL方言
+type Dialect struct {
+	Name string
+}
+
+// DialectOf 根据数据库类型获取方言
+func DialectOf(dbType string) Dialect {
+	return Dialect{Name: dbType}
+}
+
+// Placeholder 第 n 个参数的占位符（从1开始）
+func (d Dialect) Placeholder(n int) string {
+	if d.Name == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// QuoteIdent 引用标识符
+func (d Dialect) QuoteIdent(name string) string {
+	if d.Name == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// TableName 返回带限定名的表名，MySQL 使用数据库名限定，其他数据库使用 schema 限定
+func (d Dialect) TableName(dbName, schema, table string) string {
+	switch {
+	case d.Name == "mysql" && dbName != "":
+		return d.QuoteIdent(dbName) + "." + d.QuoteIdent(table)
+	case d.Name != "mysql" && schema != "":
+		return d.QuoteIdent(schema) + "." + d.QuoteIdent(table)
+	}
+	return d.QuoteIdent(table)
+}
+
+// WhereClause 返回浏览表数据时的 WHERE 子句，where 为空时返回空字符串
+func WhereClause(where string) string {
+	where = strings.TrimSpace(where)
+	if where == "" {
+		return ""
+	}
+	return " WHERE " + where
+}
+
+// QuoteString 引用字符串字面量
+func (d Dialect) QuoteString(s string) string {
+	if d.Name == "mysql" {
+		// MySQL 默认把反斜杠视为转义符
+		var sb strings.Builder
+		sb.Grow(len(s) + 2)
+		sb.WriteByte('\'')
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '\'':
+				sb.WriteString("''")
+			case '\\':
+				sb.WriteString(`\\`)
+			case 0:
+				sb.WriteString(`\0`)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			case 0x1a:
+				sb.WriteString(`\Z`)
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		sb.WriteByte('\'')
+		return sb.String()
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// BinaryLiteral 二进制字面量
+func (d Dialect) BinaryLiteral(b []byte) string {
+	if d.Name == "postgres" {
+		return fmt.Sprintf(`'\x%x'::bytea`, b)
+	}
+	return fmt.Sprintf("X'%x'", b)
+}
+
+// GeometryLiteral 空间值字面量。MySQL 使用内部格式的二进制串，不依赖 ST_GeomFromText 的坐标轴顺序；
+// PostgreSQL 和 SQLite 使用 EWKT 文本，PostGIS 的 geometry 和 geography 都能直接解析
+func (d Dialect) GeometryLiteral(g *Geometry) string {
+	if d.Name == "mysql" {
+		return d.BinaryLiteral(g.MySQLGeometry())
+	}
+	return d.QuoteString(g.EWKT())
+}
+
+// GeometryValue 写入空间列时使用的参数值，规则与 GeometryLiteral 相同
+func (d Dialect) GeometryValue(g *Geometry) interface{} {
+	if d.Name == "mysql" {
+		return g.MySQLGeometry()
+	}
+	return g.EWKT()
+}
+
+// BoolLiteral 布尔字面量
+func (d Dialect) BoolLiteral(b bool) string {
+	switch {
+	case d.Name == "postgres" && b:
+		return "TRUE"
+	case d.Name == "postgres":
+		return "FALSE"
+	case b:
+		return "1"
+	}
+	return "0"
+}
+
+// Literal 按列类型将驱动返回的值转换为SQL字面量
+func (d Dialect) Literal(col ResultColumn, v interface{}) string {
+	if v == nil {
+		return "NULL"
+	}
+	if g, ok := DecodeGeometry(col.DatabaseType, v); ok {
+		return d.GeometryLiteral(g)
+	}
+	kind := ColumnKind(col.DatabaseType)
+	switch kind {
+	case KindInteger, KindNumber:
+		if text, ok := NumberText(v); ok {
+			return text
+		}
+	case KindBool:
+		switch b := v.(type) {
+		case bool:
+			return d.BoolLiteral(b)
+		case int64:
+			return d.BoolLiteral(b != 0)
+		}
+	case KindBinary:
+		if b, ok := v.([]byte); ok {
+			return d.BinaryLiteral(b)
+		}
+	}
+	switch v := v.(type) {
+	case bool:
+		return d.BoolLiteral(v)
+	case int64, float64:
+		if text, ok := NumberText(v); ok {
+			return text
+		}
+	case []byte:
+		if !utf8.Valid(v) {
+			return d.BinaryLiteral(v)
+		}
+	}
+	return d.QuoteString(FormatValue(col.DatabaseType, v))
+}
+
+// TypeForKind 返回值分类在该方言下对应的列类型，length 为文本的最大长度
+func (d Dialect) TypeForKind(kind string, length int) string {
+	switch kind {
+	case KindInteger:
+		if d.Name == "sqlite" {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case KindNumber:
+		switch d.Name {
+		case "postgres":
+			return "DOUBLE PRECISION"
+		case "sqlite":
+			return "REAL"
+		}
+		return "DOUBLE"
+	case KindBool:
+		if d.Name == "mysql" {
+			return "TINYINT(1)"
+		}
+		return "BOOLEAN"
+	case KindDate:
+		return "DATE"
+	case KindDateTime:
+		switch d.Name {
+		case "postgres":
+			return "TIMESTAMP"
+		case "sqlite":
+			return "TEXT"
+		}
+		return "DATETIME"
+	case KindTime:
+		return "TIME"
+	case KindJSON:
+		switch d.Name {
+		case "postgres":
+			return "JSONB"
+		case "sqlite":
+			return "TEXT"
+		}
+		return "JSON"
+	case KindBinary:
+		switch d.Name {
+		case "postgres":
+			return "BYTEA"
+		case "sqlite":
+			return "BLOB"
+		}
+		return "LONGBLOB"
+	}
+	if d.Name == "sqlite" {
+		return "TEXT"
+	}
+	if length > 0 && length <= 255 {
+		return "VARCHAR(255)"
+	}
+	if d.Name == "mysql" {
+		return "LONGTEXT"
+	}
+	return "TEXT"
+}
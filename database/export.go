@@ -0,0 +1,156 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 导出格式
+const (
+	FormatCSV     = "csv"
+	FormatJSON    = "json"
+	FormatNDJSON  = "ndjson"
+	FormatXLSX    = "xlsx"
+	FormatSQL     = "sql"
+	FormatGeoJSON = "geojson"
+)
+
+// ExportWriter 导出格式写入器，Close 负责写出尾部并刷新缓冲
+type ExportWriter interface {
+	RowSink
+	Close() error
+}
+
+// ExportRequest 导出请求，Query 为空时导出整张表，Format 默认为 csv。
+// GeometryColumn 为 GeoJSON 导出时作为 geometry 的列，为空时使用第一个空间列
+type ExportRequest struct {
+	Database string     `json:"Database"`
+	Schema   string     `json:"Schema"`
+	Table    string     `json:"Table"`
+	Query    string     `json:"Query"`
+	Path     string     `json:"Path"`
+	Format   string     `json:"Format"`
+	CSV      CSVOptions `json:"CSV"`
+	SQL      SQLOptions `json:"SQL"`
+
+	GeometryColumn string `json:"GeometryColumn"`
+}
+
+// SourceQuery 返回导出使用的查询语句
+func (r ExportRequest) SourceQuery(dialect Dialect) (string, error) {
+	if q := strings.TrimSpace(r.Query); q != "" {
+		return strings.TrimRight(q, "; \t\r\n"), nil
+	}
+	if r.Table == "" {
+		return "", fmt.Errorf("either table or query is required")
+	}
+	return "SELECT * FROM " + dialect.TableName(r.Database, r.Schema, r.Table), nil
+}
+
+// SQLTargetDialect SQL 脚本的目标方言，未指定时与源数据库相同
+func (r ExportRequest) SQLTargetDialect(sourceType string) Dialect {
+	if r.SQL.Dialect != "" {
+		return DialectOf(r.SQL.Dialect)
+	}
+	return DialectOf(sourceType)
+}
+
+// NeedsKeyColumns 导出为 PostgreSQL 的 replace 模式时需要冲突列，判断是否还未指定
+func (r ExportRequest) NeedsKeyColumns(sourceType string) bool {
+	return strings.EqualFold(r.Format, FormatSQL) && r.SQL.Mode == InsertReplace &&
+		r.SQLTargetDialect(sourceType).Name == "postgres" && len(r.SQL.KeyColumns) == 0
+}
+
+// SQLTargetTable SQL 脚本中写入的表名（未引用）
+func (r ExportRequest) SQLTargetTable() string {
+	switch {
+	case r.SQL.TargetTable != "":
+		return r.SQL.TargetTable
+	case r.Table != "":
+		return r.Table
+	}
+	return "query_result"
+}
+
+// NewExportWriter 按格式创建写入器，sourceType 为源数据库类型
+func NewExportWriter(w io.Writer, sourceType string, req ExportRequest) (ExportWriter, error) {
+	switch strings.ToLower(req.Format) {
+	case "", FormatCSV:
+		return NewCSVWriter(w, req.CSV)
+	case FormatJSON:
+		return NewJSONWriter(w, false), nil
+	case FormatNDJSON:
+		return NewJSONWriter(w, true), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w), nil
+	case FormatGeoJSON:
+		return NewGeoJSONWriter(w, req.GeometryColumn), nil
+	case FormatSQL:
+		dialect := req.SQLTargetDialect(sourceType)
+		sw := NewSQLInsertWriter(w, dialect, dialect.QuoteIdent(req.SQLTargetTable()), req.SQL)
+		sw.SourceType = sourceType
+		return sw, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", req.Format)
+	}
+}
+
+// exportFile 带缓冲的导出文件
+type exportFile struct {
+	file *os.File
+	buf  *bufio.Writer
+}
+
+// CreateExportFile 创建导出文件
+func CreateExportFile(path string) (*exportFile, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &exportFile{file: f, buf: bufio.NewWriterSize(f, 256*1024)}, nil
+}
+
+func (f *exportFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+// Close 刷新缓冲并关闭文件
+func (f *exportFile) Close() error {
+	if err := f.buf.Flush(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+// encodingWriter 按编码包装输出，返回的 Closer 用于刷新转换缓冲
+func encodingWriter(w io.Writer, encoding string) (io.Writer, io.Closer, error) {
+	switch strings.ToUpper(strings.ReplaceAll(encoding, "_", "-")) {
+	case "", "UTF-8", "UTF8":
+		return w, nopCloser{}, nil
+	case "UTF-8-BOM", "UTF8-BOM":
+		if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return nil, nil, err
+		}
+		return w, nopCloser{}, nil
+	case "UTF-16LE", "UTF-16":
+		tw := transform.NewWriter(w, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder())
+		return tw, tw, nil
+	case "GBK", "GB18030":
+		tw := transform.NewWriter(w, simplifiedchinese.GB18030.NewEncoder())
+		return tw, tw, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSVOptions CSV导出选项
+type CSVOptions struct {
+	Delimiter  string `json:"Delimiter"`
+	QuoteChar  string `json:"QuoteChar"`
+	QuoteAll   bool   `json:"QuoteAll"`
+	Header     bool   `json:"Header"`
+	NullValue  string `json:"NullValue"`
+	Encoding   string `json:"Encoding"`
+	LineEnding string `json:"LineEnding"`
+}
+
+// CSVWriter 将结果集写为CSV
+type CSVWriter struct {
+	w         *bufio.Writer
+	closer    io.Closer
+	opts      CSVOptions
+	delimiter string
+	quote     string
+	newline   string
+	columns   []ResultColumn
+}
+
+// NewCSVWriter 创建CSV写入器，分隔符默认为逗号，换行默认为 LF
+func NewCSVWriter(w io.Writer, opts CSVOptions) (*CSVWriter, error) {
+	ew, closer, err := encodingWriter(w, opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	cw := &CSVWriter{
+		w:         bufio.NewWriter(ew),
+		closer:    closer,
+		opts:      opts,
+		delimiter: opts.Delimiter,
+		quote:     opts.QuoteChar,
+		newline:   "\n",
+	}
+	if cw.delimiter == "" {
+		cw.delimiter = ","
+	} else if cw.delimiter == `\t` {
+		cw.delimiter = "\t"
+	}
+	if cw.quote == "" {
+		cw.quote = `"`
+	}
+	if strings.EqualFold(opts.LineEnding, "CRLF") {
+		cw.newline = "\r\n"
+	}
+	return cw, nil
+}
+
+// Begin 写入表头
+func (c *CSVWriter) Begin(columns []ResultColumn) error {
+	c.columns = columns
+	if !c.opts.Header {
+		return nil
+	}
+	for i, col := range columns {
+		if i > 0 {
+			c.w.WriteString(c.delimiter)
+		}
+		c.writeField(col.Name, false)
+	}
+	_, err := c.w.WriteString(c.newline)
+	return err
+}
+
+// Row 写入一行
+func (c *CSVWriter) Row(values []interface{}) error {
+	for i, v := range values {
+		if i > 0 {
+			c.w.WriteString(c.delimiter)
+		}
+		if v == nil {
+			c.writeField(c.opts.NullValue, true)
+			continue
+		}
+		c.writeField(FormatValue(c.columns[i].DatabaseType, v), false)
+	}
+	_, err := c.w.WriteString(c.newline)
+	return err
+}
+
+// writeField 写入字段，必要时加引号并转义引号字符
+func (c *CSVWriter) writeField(field string, isNull bool) {
+	if !c.needsQuote(field, isNull) {
+		c.w.WriteString(field)
+		return
+	}
+	c.w.WriteString(c.quote)
+	c.w.WriteString(strings.ReplaceAll(field, c.quote, c.quote+c.quote))
+	c.w.WriteString(c.quote)
+}
+
+func (c *CSVWriter) needsQuote(field string, isNull bool) bool {
+	// NULL 不加引号，以便与空字符串区分
+	if isNull {
+		return false
+	}
+	if c.opts.QuoteAll {
+		return true
+	}
+	// NULL 写为空时，空字符串加引号写为 ""
+	if field == "" {
+		return c.opts.NullValue == ""
+	}
+	if strings.Contains(field, c.delimiter) || strings.Contains(field, c.quote) ||
+		strings.ContainsAny(field, "\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return r == ' ' || r == '\t'
+}
+
+// Close 刷新缓冲
+func (c *CSVWriter) Close() error {
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+	return c.closer.Close()
+}
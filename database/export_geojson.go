@@ -0,0 +1,122 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// GeoJSONWriter 将结果集写为 GeoJSON FeatureCollection：空间列作为 geometry，其余列作为 properties。
+// 坐标按数据库中保存的坐标系写出，不转换为 WGS84
+type GeoJSONWriter struct {
+	w       *bufio.Writer
+	column  string
+	geom    int
+	columns []ResultColumn
+	keys    [][]byte
+	kinds   []string
+	rows    int64
+	// pending 找到空间列之前的行，空间值都为 NULL 时无法判断哪一列是空间列
+	pending [][]interface{}
+}
+
+// NewGeoJSONWriter 创建 GeoJSON 写入器，column 为作为 geometry 的列，为空时使用第一个空间列
+func NewGeoJSONWriter(w io.Writer, column string) *GeoJSONWriter {
+	return &GeoJSONWriter{w: bufio.NewWriter(w), column: column, geom: -1}
+}
+
+// Begin 确定空间列并预先编码属性名。PostgreSQL 的 PostGIS 列没有类型名，在第一行中查找
+func (g *GeoJSONWriter) Begin(columns []ResultColumn) error {
+	g.columns = columns
+	g.keys = make([][]byte, len(columns))
+	g.kinds = make([]string, len(columns))
+	for i, col := range columns {
+		key, err := marshalString(col.Name)
+		if err != nil {
+			return err
+		}
+		g.keys[i] = key
+		g.kinds[i] = ColumnKind(col.DatabaseType)
+		if g.geom < 0 && (col.Name == g.column || g.column == "" && g.kinds[i] == KindGeometry) {
+			g.geom = i
+		}
+	}
+	if g.column != "" && g.geom < 0 {
+		return fmt.Errorf("geometry column %s not found", g.column)
+	}
+	_, err := g.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+// Row 写入一个 Feature，空间值无法解码时 geometry 为 null。未确定空间列时暂存该行，
+// 直到某一行中有可解码的空间值
+func (g *GeoJSONWriter) Row(values []interface{}) error {
+	if g.geom < 0 {
+		for i, v := range values {
+			if _, ok := DecodeGeometry(g.columns[i].DatabaseType, v); ok {
+				g.geom = i
+				break
+			}
+		}
+		if g.geom < 0 {
+			g.pending = append(g.pending, append([]interface{}(nil), values...))
+			return nil
+		}
+		pending := g.pending
+		g.pending = nil
+		for _, row := range pending {
+			if err := g.feature(row); err != nil {
+				return err
+			}
+		}
+	}
+	return g.feature(values)
+}
+
+// feature 按已确定的空间列写入一个 Feature
+func (g *GeoJSONWriter) feature(values []interface{}) error {
+	if g.rows > 0 {
+		g.w.WriteByte(',')
+	}
+	g.w.WriteString("\n  {\"type\":\"Feature\",\"geometry\":")
+	if geo, ok := DecodeGeometry(g.columns[g.geom].DatabaseType, values[g.geom]); ok {
+		g.w.Write(geo.GeoJSON)
+	} else {
+		g.w.WriteString("null")
+	}
+	g.w.WriteString(`,"properties":{`)
+	first := true
+	for i, v := range values {
+		if i == g.geom {
+			continue
+		}
+		if !first {
+			g.w.WriteByte(',')
+		}
+		first = false
+		g.w.Write(g.keys[i])
+		g.w.WriteByte(':')
+		data, err := JSONValue(g.columns[i], g.kinds[i], v)
+		if err != nil {
+			return err
+		}
+		g.w.Write(data)
+	}
+	g.w.WriteString("}}")
+	g.rows++
+	return nil
+}
+
+// Close 写出结尾并刷新缓冲，所有行的空间值都无法解码时返回错误
+func (g *GeoJSONWriter) Close() error {
+	if len(g.pending) > 0 {
+		g.pending = nil
+		g.w.Flush()
+		return fmt.Errorf("no geometry column in the result")
+	}
+	if g.rows > 0 {
+		g.w.WriteString("\n")
+	}
+	g.w.WriteString("]}\n")
+	return g.w.Flush()
+}
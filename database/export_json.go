@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// JSONWriter 将结果集写为 JSON 数组或 NDJSON（每行一个对象）
+type JSONWriter struct {
+	w       *bufio.Writer
+	lines   bool
+	columns []ResultColumn
+	keys    [][]byte
+	kinds   []string
+	rows    int64
+}
+
+// NewJSONWriter 创建 JSON 写入器，lines 为 true 时输出 NDJSON
+func NewJSONWriter(w io.Writer, lines bool) *JSONWriter {
+	return &JSONWriter{w: bufio.NewWriter(w), lines: lines}
+}
+
+// Begin 预先编码列名
+func (j *JSONWriter) Begin(columns []ResultColumn) error {
+	j.columns = columns
+	j.keys = make([][]byte, len(columns))
+	j.kinds = make([]string, len(columns))
+	for i, col := range columns {
+		key, err := marshalString(col.Name)
+		if err != nil {
+			return err
+		}
+		j.keys[i] = key
+		j.kinds[i] = ColumnKind(col.DatabaseType)
+	}
+	if !j.lines {
+		_, err := j.w.WriteString("[")
+		return err
+	}
+	return nil
+}
+
+// Row 写入一个对象，列顺序与结果集一致
+func (j *JSONWriter) Row(values []interface{}) error {
+	if !j.lines {
+		if j.rows > 0 {
+			j.w.WriteString(",")
+		}
+		j.w.WriteString("\n  ")
+	}
+	j.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			j.w.WriteByte(',')
+		}
+		j.w.Write(j.keys[i])
+		j.w.WriteByte(':')
+		data, err := JSONValue(j.columns[i], j.kinds[i], v)
+		if err != nil {
+			return err
+		}
+		j.w.Write(data)
+	}
+	j.w.WriteByte('}')
+	if j.lines {
+		j.w.WriteByte('\n')
+	}
+	j.rows++
+	return nil
+}
+
+// Close 写出数组结尾并刷新缓冲
+func (j *JSONWriter) Close() error {
+	if !j.lines {
+		if j.rows > 0 {
+			j.w.WriteString("\n")
+		}
+		j.w.WriteString("]\n")
+	}
+	return j.w.Flush()
+}
+
+// JSONValue 按列类型将值编码为 JSON：数值保持数值，JSON 列保持原样，空间值为 GeoJSON geometry 对象，NULL 为 null
+func JSONValue(col ResultColumn, kind string, v interface{}) ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	if g, ok := DecodeGeometry(col.DatabaseType, v); ok {
+		return g.GeoJSON, nil
+	}
+	switch kind {
+	case KindInteger, KindNumber:
+		if text, ok := NumberText(v); ok {
+			return []byte(text), nil
+		}
+	case KindJSON:
+		if raw, ok := JSONRaw(v); ok {
+			return raw, nil
+		}
+	case KindBool:
+		switch b := v.(type) {
+		case bool:
+			return json.Marshal(b)
+		case int64:
+			return json.Marshal(b != 0)
+		}
+	}
+	switch v := v.(type) {
+	case bool:
+		return json.Marshal(v)
+	case int64, float64:
+		if text, ok := NumberText(v); ok {
+			return []byte(text), nil
+		}
+	}
+	return marshalString(FormatValue(col.DatabaseType, v))
+}
+
+// marshalString 编码字符串，不转义 HTML 字符
+func marshalString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// JSONRaw 将 JSON 列的值转换为 json.RawMessage，内容不是有效的 JSON 时返回 false
+func JSONRaw(v interface{}) (json.RawMessage, bool) {
+	var b []byte
+	switch v := v.(type) {
+	case []byte:
+		b = append([]byte(nil), v...)
+	case string:
+		b = []byte(v)
+	default:
+		return nil, false
+	}
+	if !json.Valid(b) {
+		return nil, false
+	}
+	return json.RawMessage(b), true
+}
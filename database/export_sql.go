@@ -0,0 +1,202 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// INSERT 语句的冲突处理方式
+const (
+	InsertPlain   = "insert"
+	InsertIgnore  = "ignore"
+	InsertReplace = "replace"
+)
+
+// defaultInsertBatch 每条 INSERT 默认包含的行数
+const defaultInsertBatch = 100
+
+// SQLOptions SQL 脚本导出选项
+type SQLOptions struct {
+	// Dialect 目标数据库类型，为空时与源数据库相同
+	Dialect string `json:"Dialect"`
+	// TargetTable 写入的表名，为空时使用源表名
+	TargetTable string `json:"TargetTable"`
+	BatchSize   int    `json:"BatchSize"`
+	// Mode 为 insert、ignore 或 replace
+	Mode        string `json:"Mode"`
+	CreateTable bool   `json:"CreateTable"`
+	// KeyColumns PostgreSQL 使用 replace 模式时的冲突列。导出整张表时为空则使用表的主键列，导出查询结果时必须指定
+	KeyColumns []string `json:"KeyColumns"`
+}
+
+// SQLInsertWriter 将结果集写为 INSERT 语句
+type SQLInsertWriter struct {
+	w       *bufio.Writer
+	dialect Dialect
+	opts    SQLOptions
+	table   string
+	// Header 写在 INSERT 语句之前的内容，如建表语句
+	Header string
+	// SourceType 源数据库类型，建表时用于转换列类型
+	SourceType string
+	// Columns 源表的列信息，有值时建表语句使用其中的类型、可空性和主键，否则按结果集的列类型推断
+	Columns []ColumnInfo
+	columns []ResultColumn
+	prefix  string
+	suffix  string
+	pending int
+}
+
+// NewSQLInsertWriter 创建 INSERT 脚本写入器，table 为已引用的目标表名
+func NewSQLInsertWriter(w io.Writer, dialect Dialect, table string, opts SQLOptions) *SQLInsertWriter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultInsertBatch
+	}
+	return &SQLInsertWriter{w: bufio.NewWriter(w), dialect: dialect, opts: opts, table: table}
+}
+
+// Begin 写入表头并准备 INSERT 前缀
+func (s *SQLInsertWriter) Begin(columns []ResultColumn) error {
+	s.columns = columns
+	if s.Header == "" && s.opts.CreateTable {
+		s.Header = s.dialect.BuildCreateTable(s.table, resultColumnInfos(s.dialect, s.SourceType, columns, s.Columns, s.opts.KeyColumns), true)
+	}
+	if s.Header != "" {
+		s.w.WriteString(strings.TrimRight(s.Header, "\n") + "\n\n")
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = s.dialect.QuoteIdent(col.Name)
+	}
+	verb, suffix, err := s.dialect.InsertClause(s.opts.Mode, names, s.opts.KeyColumns)
+	if err != nil {
+		return err
+	}
+	s.prefix = fmt.Sprintf("%s %s (%s) VALUES", verb, s.table, strings.Join(names, ", "))
+	s.suffix = suffix
+	return nil
+}
+
+// InsertClause 按方言返回 INSERT 关键字和冲突处理后缀，names 为已引用的列名，
+// keys 为 PostgreSQL 使用 replace 模式时的冲突列
+func (d Dialect) InsertClause(mode string, names, keys []string) (string, string, error) {
+	switch mode {
+	case "", InsertPlain:
+		return "INSERT INTO", "", nil
+	case InsertIgnore:
+		switch d.Name {
+		case "mysql":
+			return "INSERT IGNORE INTO", "", nil
+		case "sqlite":
+			return "INSERT OR IGNORE INTO", "", nil
+		default:
+			return "INSERT INTO", " ON CONFLICT DO NOTHING", nil
+		}
+	case InsertReplace:
+		switch d.Name {
+		case "mysql":
+			return "REPLACE INTO", "", nil
+		case "sqlite":
+			return "INSERT OR REPLACE INTO", "", nil
+		}
+		if len(keys) == 0 {
+			return "", "", fmt.Errorf("replace mode requires key columns for %s", d.Name)
+		}
+		quoted := make([]string, len(keys))
+		isKey := make(map[string]bool)
+		for i, k := range keys {
+			quoted[i] = d.QuoteIdent(k)
+			isKey[quoted[i]] = true
+		}
+		var sets []string
+		for _, name := range names {
+			if !isKey[name] {
+				sets = append(sets, name+" = EXCLUDED."+name)
+			}
+		}
+		if len(sets) == 0 {
+			return "INSERT INTO", " ON CONFLICT (" + strings.Join(quoted, ", ") + ") DO NOTHING", nil
+		}
+		return "INSERT INTO", " ON CONFLICT (" + strings.Join(quoted, ", ") + ") DO UPDATE SET " + strings.Join(sets, ", "), nil
+	}
+	return "", "", fmt.Errorf("unsupported insert mode: %s", mode)
+}
+
+// Row 写入一行，累计到批量大小时结束当前语句
+func (s *SQLInsertWriter) Row(values []interface{}) error {
+	if s.pending == 0 {
+		s.w.WriteString(s.prefix)
+		s.w.WriteString("\n  (")
+	} else {
+		s.w.WriteString(",\n  (")
+	}
+	for i, v := range values {
+		if i > 0 {
+			s.w.WriteString(", ")
+		}
+		s.w.WriteString(s.dialect.Literal(s.columns[i], v))
+	}
+	s.w.WriteByte(')')
+	s.pending++
+	if s.pending >= s.opts.BatchSize {
+		return s.endStatement()
+	}
+	return nil
+}
+
+// endStatement 结束当前 INSERT 语句
+func (s *SQLInsertWriter) endStatement() error {
+	if s.pending == 0 {
+		return nil
+	}
+	s.pending = 0
+	_, err := s.w.WriteString(s.suffix + ";\n")
+	return err
+}
+
+// Close 结束最后一条语句并刷新缓冲
+func (s *SQLInsertWriter) Close() error {
+	if err := s.endStatement(); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// resultColumnInfos 将结果集列转换为目标方言中建表使用的列信息。源表中存在的列按其完整的列信息转换类型，
+// 其他列（如查询中的表达式）按驱动返回的类型名转换，驱动返回的类型名不带长度，因此同类数据库也要转换。
+// keys 不为空时作为主键，否则使用源表的主键
+func resultColumnInfos(d Dialect, sourceType string, columns []ResultColumn, table []ColumnInfo, keys []string) []ColumnInfo {
+	byName := make(map[string]ColumnInfo, len(table))
+	for _, col := range table {
+		byName[col.Name] = col
+	}
+	isKey := make(map[string]bool)
+	for _, k := range keys {
+		isKey[k] = true
+	}
+	infos := make([]ColumnInfo, len(columns))
+	for i, col := range columns {
+		var info ColumnInfo
+		if src, ok := byName[col.Name]; ok {
+			info = ColumnInfo{Name: col.Name, Type: MapColumnType(d, sourceType, src), Nullable: src.Nullable, IsPrimary: src.IsPrimary}
+		} else {
+			typ := strings.ToLower(col.DatabaseType)
+			if typ == "" {
+				typ = "text"
+			}
+			info = ColumnInfo{Name: col.Name, Type: MapColumnType(d, "", ColumnInfo{Type: typ}), Nullable: col.Nullable}
+		}
+		if len(keys) > 0 {
+			info.IsPrimary = isKey[col.Name]
+		}
+		if info.IsPrimary {
+			info.Nullable = false
+			info.Type = keyColumnType(d, info.Type)
+		}
+		infos[i] = info
+	}
+	return infos
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Excel 单个工作表的行数上限
+const xlsxMaxRows = 1048576
+
+// xlsxMaxCellText Excel 单元格文本长度上限
+const xlsxMaxCellText = 32767
+
+// 样式索引，对应 styles.xml 中的 cellXfs
+const (
+	xlsxStyleDefault  = 0
+	xlsxStyleDate     = 1
+	xlsxStyleDateTime = 2
+	xlsxStyleHeader   = 3
+)
+
+// excelEpoch Excel 日期序列号的起点
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// XLSXWriter 以流式方式写出 .xlsx 文件，超过行数上限时自动拆分工作表
+type XLSXWriter struct {
+	zw        *zip.Writer
+	sheet     *bufio.Writer
+	sheets    int
+	sheetRows int
+	columns   []ResultColumn
+	kinds     []string
+	refs      []string
+}
+
+// NewXLSXWriter 创建 XLSX 写入器
+func NewXLSXWriter(w io.Writer) *XLSXWriter {
+	return &XLSXWriter{zw: zip.NewWriter(w)}
+}
+
+// Begin 记录列信息并开始第一个工作表
+func (x *XLSXWriter) Begin(columns []ResultColumn) error {
+	x.columns = columns
+	x.kinds = make([]string, len(columns))
+	x.refs = make([]string, len(columns))
+	for i, col := range columns {
+		x.kinds[i] = ColumnKind(col.DatabaseType)
+		x.refs[i] = xlsxColumnName(i)
+	}
+	return x.newSheet()
+}
+
+// newSheet 结束当前工作表并开始新的工作表，首行写入表头
+func (x *XLSXWriter) newSheet() error {
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+	x.sheets++
+	w, err := x.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", x.sheets))
+	if err != nil {
+		return err
+	}
+	x.sheet = bufio.NewWriterSize(w, 64*1024)
+	x.sheet.WriteString(xml.Header)
+	x.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	x.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	x.sheet.WriteString(`<sheetData>`)
+
+	x.sheetRows = 1
+	x.sheet.WriteString(`<row r="1">`)
+	for i, col := range x.columns {
+		x.writeString(x.refs[i]+"1", col.Name, xlsxStyleHeader)
+	}
+	_, err = x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// endSheet 写出当前工作表的结尾
+func (x *XLSXWriter) endSheet() error {
+	if x.sheet == nil {
+		return nil
+	}
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	err := x.sheet.Flush()
+	x.sheet = nil
+	return err
+}
+
+// Row 写入一行
+func (x *XLSXWriter) Row(values []interface{}) error {
+	if x.sheetRows >= xlsxMaxRows {
+		if err := x.newSheet(); err != nil {
+			return err
+		}
+	}
+	x.sheetRows++
+	row := strconv.Itoa(x.sheetRows)
+	x.sheet.WriteString(`<row r="` + row + `">`)
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		x.writeCell(x.refs[i]+row, i, v)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// writeCell 按列类型写入单元格：数值写为数字，日期写为 Excel 日期
+func (x *XLSXWriter) writeCell(ref string, col int, v interface{}) {
+	switch x.kinds[col] {
+	case KindInteger, KindNumber:
+		// 超过15位有效数字的数值在 Excel 中会丢失精度，保留为文本
+		if text, ok := NumberText(v); ok && significantDigits(text) <= 15 {
+			x.sheet.WriteString(`<c r="` + ref + `"><v>` + text + `</v></c>`)
+			return
+		}
+	case KindBool:
+		if b, ok := v.(bool); ok {
+			value := "0"
+			if b {
+				value = "1"
+			}
+			x.sheet.WriteString(`<c r="` + ref + `" t="b"><v>` + value + `</v></c>`)
+			return
+		}
+	case KindDate, KindDateTime:
+		if t, ok := ParseTimeValue(v); ok {
+			style := xlsxStyleDateTime
+			if x.kinds[col] == KindDate {
+				style = xlsxStyleDate
+			}
+			serial := strconv.FormatFloat(excelSerial(t), 'f', -1, 64)
+			x.sheet.WriteString(fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, style, serial))
+			return
+		}
+	}
+	x.writeString(ref, FormatValue(x.columns[col].DatabaseType, v), xlsxStyleDefault)
+}
+
+// writeString 写入内联字符串单元格
+func (x *XLSXWriter) writeString(ref, text string, style int) {
+	if len(text) > xlsxMaxCellText {
+		text = text[:xlsxMaxCellText]
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	if style != xlsxStyleDefault {
+		x.sheet.WriteString(fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style))
+	} else {
+		x.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+	}
+	xmlEscape(x.sheet, text)
+	x.sheet.WriteString(`</t></is></c>`)
+}
+
+// Close 写出工作簿、样式等其余部件并关闭 zip
+func (x *XLSXWriter) Close() error {
+	if x.sheets == 0 {
+		if err := x.Begin(nil); err != nil {
+			return err
+		}
+	}
+	if err := x.endSheet(); err != nil {
+		return err
+	}
+
+	var sheets, sheetRels, sheetTypes strings.Builder
+	for i := 1; i <= x.sheets; i++ {
+		fmt.Fprintf(&sheets, `<sheet name="Sheet%d" sheetId="%d" r:id="rId%d"/>`, i, i, i)
+		fmt.Fprintf(&sheetRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+		fmt.Fprintf(&sheetTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			sheetTypes.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			sheetRels.String() +
+			fmt.Sprintf(`<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, x.sheets+1) +
+			`</Relationships>`},
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="4">` +
+			`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+			`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+			`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+			`</cellXfs></styleSheet>`},
+	}
+	for _, part := range parts {
+		w, err := x.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return err
+		}
+	}
+	return x.zw.Close()
+}
+
+// xlsxColumnName 将从0开始的列序号转换为 A、B、…、AA 形式
+func xlsxColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// excelSerial 将时间转换为 Excel 日期序列号（按本地时钟，不做时区换算）
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(excelEpoch).Hours() / 24
+}
+
+// significantDigits 统计数值文本中的有效数字位数
+func significantDigits(text string) int {
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		text = text[:i]
+	}
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(text), "0")
+	return len(digits)
+}
+
+// xmlEscape 写出转义后的文本，并去掉 XML 不允许的控制字符
+func xmlEscape(w *bufio.Writer, s string) {
+	for _, r := range s {
+		switch {
+		case r == '<':
+			w.WriteString("&lt;")
+		case r == '>':
+			w.WriteString("&gt;")
+		case r == '&':
+			w.WriteString("&amp;")
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r', r == 0xFFFE, r == 0xFFFF:
+			continue
+		default:
+			w.WriteRune(r)
+		}
+	}
+}
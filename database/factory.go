@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"strings"
@@ -16,11 +17,29 @@ type DBAdapter interface {
 	GetSchemas(dbName string) ([]SchemaInfo, error)
 	GetTables(dbName, schema string) ([]TableInfo, error)
 	GetTableColumns(dbName, tableName string) ([]ColumnInfo, error)
-	GetTableRowCount(dbName, tableName string) (int64, error)
-	QueryTableData(dbName, tableName string, offset, limit int) ([]map[string]string, error)
+	GetRoutines(dbName, schema string) ([]RoutineInfo, error)
+	GetTableDDL(dbName, tableName string) (string, error)
+	GetTableRowCount(dbName, tableName, where string) (int64, error)
+	QueryTableData(dbName, tableName, where string, offset, limit int) ([]map[string]string, error)
 	CreateDatabase(name string, charset string, collation string) error
 	GetCharsets() ([]CharsetInfo, error)
 	ExecuteQuery(dbName, sql string) ([]map[string]string, error)
+	ExecuteQueryArgs(dbName, query string, args []interface{}) ([]map[string]string, error)
+	StreamQuery(ctx context.Context, dbName, query string, args []interface{}, sink RowSink) error
+	Conn(ctx context.Context, dbName string) (*sqlx.Conn, error)
+	GetDumpSchema(dbName string) (*DumpSchema, error)
+	GetIndexes(dbName, schema string) ([]IndexInfo, error)
+	GetForeignKeys(dbName, schema string) ([]ForeignKeyInfo, error)
+	GetServerProcesses() ([]ProcessInfo, error)
+	KillProcess(id int64, queryOnly bool) error
+	GetLockWaits() ([]LockWait, error)
+	GetServerVariables() ([]ServerVariable, error)
+	GetServerStatus() ([]ServerVariable, error)
+	GetUsers() ([]UserInfo, error)
+	GetUserGrants(name, host string) ([]GrantInfo, error)
+	ApplyUserChange(change UserChange) error
+	GetTableStats(dbName, schema string) ([]TableStats, error)
+	RunMaintenance(ctx context.Context, dbName, schema, table, operation string) ([]MaintenanceResult, error)
 }
 
 // DatabaseInfo 数据库信息
@@ -44,12 +63,43 @@ type ColumnInfo struct {
 	Name      string `json:"Name"`
 	Type      string `json:"Type"`
 	Length    int    `json:"Length"`
+	Precision int    `json:"Precision"`
+	Scale     int    `json:"Scale"`
 	Nullable  bool   `json:"Nullable"`
 	IsPrimary bool   `json:"IsPrimary"`
 }
 
+// RoutineInfo 存储过程/函数信息
+type RoutineInfo struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"`
+}
+
+// IndexInfo 索引信息，Constraint 表示索引由主键或唯一约束创建
+type IndexInfo struct {
+	Table      string   `json:"Table"`
+	Name       string   `json:"Name"`
+	Columns    []string `json:"Columns"`
+	Unique     bool     `json:"Unique"`
+	Primary    bool     `json:"Primary"`
+	Constraint bool     `json:"Constraint"`
+}
+
+// ForeignKeyInfo 外键信息
+type ForeignKeyInfo struct {
+	Table      string   `json:"Table"`
+	Name       string   `json:"Name"`
+	Columns    []string `json:"Columns"`
+	RefSchema  string   `json:"RefSchema"`
+	RefTable   string   `json:"RefTable"`
+	RefColumns []string `json:"RefColumns"`
+	OnDelete   string   `json:"OnDelete"`
+	OnUpdate   string   `json:"OnUpdate"`
+}
+
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Name     string `json:"Name"`
 	Type     string `json:"Type"`
 	Host     string `json:"Host"`
 	Port     int    `json:"Port"`
@@ -57,6 +107,21 @@ type DatabaseConfig struct {
 	Password string `json:"Password"`
 	Database string `json:"Database"`
 	SSLMode  string `json:"SSLMode"`
+	// 以下只用于 SQLite：只读打开、附加的数据库文件、每个连接执行的 PRAGMA
+	ReadOnly bool               `json:"ReadOnly"`
+	Attached []AttachedDatabase `json:"Attached"`
+	Pragmas  map[string]string  `json:"Pragmas"`
+}
+
+// ProfileKey 连接标识，优先使用连接名称
+func (c DatabaseConfig) ProfileKey() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if c.Type == "sqlite" {
+		return fmt.Sprintf("sqlite://%s", c.Database)
+	}
+	return fmt.Sprintf("%s://%s@%s:%d/%s", c.Type, c.User, c.Host, c.Port, c.Database)
 }
 
 // CreateDatabaseOptions 创建数据库的选项
@@ -167,6 +232,37 @@ func (a *BaseAdapter) Ping() error {
 	return a.db.Ping()
 }
 
+// scanStringRows 读取全部结果行并转换为字符串map
+func scanStringRows(rows *sqlx.Rows) ([]map[string]string, error) {
+	types, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]string{}
+	for rows.Next() {
+		row := make(map[string]interface{})
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+
+		strRow := make(map[string]string)
+		for k, v := range row {
+			if v == nil {
+				strRow[k] = ""
+			} else {
+				switch v := v.(type) {
+				case []byte:
+					strRow[k] = BytesText(types[k], v)
+				default:
+					strRow[k] = fmt.Sprintf("%v", v)
+				}
+			}
+		}
+		result = append(result, strRow)
+	}
+	return result, rows.Err()
+}
+
 // SQLHelper SQL辅助函数
 type SQLHelper struct{}
 
@@ -0,0 +1,429 @@
+package database
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 关系基数
+const (
+	CardinalityManyToOne = "many-to-one"
+	CardinalityOneToOne  = "one-to-one"
+)
+
+// 图导出格式
+const (
+	GraphFormatDOT      = "dot"
+	GraphFormatMermaid  = "mermaid"
+	GraphFormatPlantUML = "plantuml"
+)
+
+// GraphColumn 关系图中的列
+type GraphColumn struct {
+	Name      string `json:"Name"`
+	Type      string `json:"Type"`
+	Nullable  bool   `json:"Nullable"`
+	IsPrimary bool   `json:"IsPrimary"`
+	IsForeign bool   `json:"IsForeign"`
+	IsUnique  bool   `json:"IsUnique"`
+}
+
+// GraphNode 关系图中的表。Layer 为按外键依赖计算的层次（被引用的表在前），
+// Order 为同一层中的顺序，Component 为连通分量编号，供前端布局使用
+type GraphNode struct {
+	Name      string        `json:"Name"`
+	Comment   string        `json:"Comment"`
+	Columns   []GraphColumn `json:"Columns"`
+	Layer     int           `json:"Layer"`
+	Order     int           `json:"Order"`
+	Component int           `json:"Component"`
+}
+
+// GraphEdge 外键关系，From 为引用方，To 为被引用的表。Optional 表示外键列可为空
+type GraphEdge struct {
+	Name        string   `json:"Name"`
+	From        string   `json:"From"`
+	FromColumns []string `json:"FromColumns"`
+	To          string   `json:"To"`
+	ToColumns   []string `json:"ToColumns"`
+	Cardinality string   `json:"Cardinality"`
+	Optional    bool     `json:"Optional"`
+	OnDelete    string   `json:"OnDelete"`
+	OnUpdate    string   `json:"OnUpdate"`
+}
+
+// SchemaGraph 数据库关系图
+type SchemaGraph struct {
+	Name  string      `json:"Name"`
+	Nodes []GraphNode `json:"Nodes"`
+	Edges []GraphEdge `json:"Edges"`
+}
+
+// GetSchemaGraph 根据表、列、索引和外键生成关系图，PostgreSQL 的 schema 为空时使用 public
+func GetSchemaGraph(adapter DBAdapter, dbType, dbName, schema string) (*SchemaGraph, error) {
+	if dbType == "postgres" && schema == "" {
+		schema = "public"
+	}
+	tables, err := adapter.GetTables(dbName, schema)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := adapter.GetIndexes(dbName, schema)
+	if err != nil {
+		return nil, fmt.Errorf("read indexes: %v", err)
+	}
+	fks, err := adapter.GetForeignKeys(dbName, schema)
+	if err != nil {
+		return nil, fmt.Errorf("read foreign keys: %v", err)
+	}
+
+	name := dbName
+	if schema != "" && dbType == "postgres" {
+		name = schema
+	}
+	g := &SchemaGraph{Name: name}
+	unique := make(map[string][]string)
+	for _, idx := range indexes {
+		if idx.Unique || idx.Primary {
+			unique[idx.Table] = append(unique[idx.Table], strings.Join(idx.Columns, ","))
+		}
+	}
+	foreign := make(map[string]map[string]bool)
+	for _, fk := range fks {
+		if foreign[fk.Table] == nil {
+			foreign[fk.Table] = make(map[string]bool)
+		}
+		for _, c := range fk.Columns {
+			foreign[fk.Table][c] = true
+		}
+	}
+
+	nullable := make(map[string]map[string]bool)
+	for _, t := range tables {
+		columns, err := SchemaTableColumns(adapter, dbName, schema, t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("read columns of %s: %v", t.Name, err)
+		}
+		node := GraphNode{Name: t.Name, Comment: t.Comment}
+		nullable[t.Name] = make(map[string]bool)
+		var pk []string
+		for _, col := range columns {
+			if col.IsPrimary {
+				pk = append(pk, col.Name)
+			}
+		}
+		if len(pk) > 0 {
+			unique[t.Name] = append(unique[t.Name], strings.Join(pk, ","))
+		}
+		for _, col := range columns {
+			// SQLite 的主键列可能未声明 NOT NULL，图中按非空处理
+			notNull := !col.Nullable || col.IsPrimary
+			nullable[t.Name][col.Name] = !notNull
+			node.Columns = append(node.Columns, GraphColumn{
+				Name:      col.Name,
+				Type:      columnTypeSQL(col),
+				Nullable:  !notNull,
+				IsPrimary: col.IsPrimary,
+				IsForeign: foreign[t.Name][col.Name],
+				IsUnique:  containsString(unique[t.Name], col.Name),
+			})
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Slice(g.Nodes, func(i, k int) bool { return g.Nodes[i].Name < g.Nodes[k].Name })
+
+	for _, fk := range fks {
+		edge := GraphEdge{
+			Name:        fk.Name,
+			From:        fk.Table,
+			FromColumns: fk.Columns,
+			To:          fk.RefTable,
+			ToColumns:   fk.RefColumns,
+			Cardinality: CardinalityManyToOne,
+			OnDelete:    fk.OnDelete,
+			OnUpdate:    fk.OnUpdate,
+		}
+		// 外键列本身唯一时每行最多对应一行
+		if containsSet(unique[fk.Table], fk.Columns) {
+			edge.Cardinality = CardinalityOneToOne
+		}
+		for _, c := range fk.Columns {
+			if nullable[fk.Table][c] {
+				edge.Optional = true
+			}
+		}
+		g.Edges = append(g.Edges, edge)
+	}
+	g.layout()
+	return g, nil
+}
+
+// SchemaTableColumns 获取 schema 中表的列。PostgreSQL 的 GetTableColumns 不区分 schema，
+// 不同 schema 中的同名表会合并在一起；SQLite 的 GetTableColumns 只查找搜索顺序中的第一张同名表，因此按 schema 查询
+func SchemaTableColumns(adapter DBAdapter, dbName, schema, tableName string) ([]ColumnInfo, error) {
+	switch a := adapter.(type) {
+	case *PostgresAdapter:
+		return a.GetSchemaTableColumns(schema, tableName)
+	case *SQLiteAdapter:
+		return a.GetSchemaTableColumns(schema, tableName)
+	}
+	return adapter.GetTableColumns(dbName, tableName)
+}
+
+// containsString 判断单列唯一键列表中是否包含该列
+func containsString(keys []string, column string) bool {
+	for _, k := range keys {
+		if k == column {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSet 判断唯一键列表中是否有与 columns 列集合相同的键
+func containsSet(keys []string, columns []string) bool {
+	sorted := append([]string(nil), columns...)
+	sort.Strings(sorted)
+	for _, k := range keys {
+		parts := strings.Split(k, ",")
+		sort.Strings(parts)
+		if strings.Join(parts, ",") == strings.Join(sorted, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// layout 计算布局提示：被引用的表在较低的层，同层按名称排序，并按连通分量分组
+func (g *SchemaGraph) layout() {
+	index := make(map[string]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		index[n.Name] = i
+	}
+	parents := make(map[string][]string)
+	parent := make([]int, len(g.Nodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for _, e := range g.Edges {
+		from, ok1 := index[e.From]
+		to, ok2 := index[e.To]
+		if !ok1 || !ok2 || from == to {
+			continue
+		}
+		parents[e.From] = append(parents[e.From], e.To)
+		parent[find(from)] = find(to)
+	}
+
+	// 层次为到最远的被引用表的距离，循环引用时忽略回边
+	layer := make(map[string]int)
+	visiting := make(map[string]bool)
+	var visit func(name string) int
+	visit = func(name string) int {
+		if l, ok := layer[name]; ok {
+			return l
+		}
+		if visiting[name] {
+			return -1
+		}
+		visiting[name] = true
+		l := 0
+		for _, p := range parents[name] {
+			if pl := visit(p); pl+1 > l {
+				l = pl + 1
+			}
+		}
+		visiting[name] = false
+		layer[name] = l
+		return l
+	}
+
+	components := make(map[int]int)
+	orders := make(map[int]int)
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		n.Layer = visit(n.Name)
+		root := find(i)
+		if _, ok := components[root]; !ok {
+			components[root] = len(components)
+		}
+		n.Component = components[root]
+		n.Order = orders[n.Layer]
+		orders[n.Layer]++
+	}
+}
+
+// Export 将关系图导出为 DOT、Mermaid 或 PlantUML 文本
+func (g *SchemaGraph) Export(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case GraphFormatDOT:
+		return g.dot(), nil
+	case GraphFormatMermaid:
+		return g.mermaid(), nil
+	case GraphFormatPlantUML:
+		return g.plantUML(), nil
+	}
+	return "", fmt.Errorf("unsupported graph format: %s", format)
+}
+
+// keys 列的键标记（PK、FK、UK）
+func (c GraphColumn) keys() []string {
+	var keys []string
+	if c.IsPrimary {
+		keys = append(keys, "PK")
+	}
+	if c.IsForeign {
+		keys = append(keys, "FK")
+	}
+	if c.IsUnique && !c.IsPrimary {
+		keys = append(keys, "UK")
+	}
+	return keys
+}
+
+// dotQuote 引用 DOT 标识符
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (g *SchemaGraph) dot() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(g.Name))
+	sb.WriteString("  rankdir=RL;\n  node [shape=plaintext, fontname=\"Helvetica\"];\n  edge [dir=both];\n\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "  %s [label=<<TABLE BORDER=\"0\" CELLBORDER=\"1\" CELLSPACING=\"0\" CELLPADDING=\"4\">\n", dotQuote(n.Name))
+		fmt.Fprintf(&sb, "    <TR><TD BGCOLOR=\"lightgrey\"><B>%s</B></TD></TR>\n", html.EscapeString(n.Name))
+		for _, c := range n.Columns {
+			text := html.EscapeString(c.Name + " : " + c.Type)
+			if keys := c.keys(); len(keys) > 0 {
+				text += " <I>" + strings.Join(keys, ", ") + "</I>"
+			}
+			if c.IsPrimary {
+				text = "<U>" + text + "</U>"
+			}
+			fmt.Fprintf(&sb, "    <TR><TD ALIGN=\"LEFT\" PORT=%s>%s</TD></TR>\n", dotQuote(c.Name), text)
+		}
+		sb.WriteString("  </TABLE>>];\n")
+	}
+	if len(g.Edges) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, e := range g.Edges {
+		tail := "crow"
+		if e.Cardinality == CardinalityOneToOne {
+			tail = "tee"
+		}
+		head := "teetee"
+		if e.Optional {
+			head = "teeodot"
+		}
+		from := dotQuote(e.From)
+		if len(e.FromColumns) == 1 {
+			from += ":" + dotQuote(e.FromColumns[0])
+		}
+		to := dotQuote(e.To)
+		if len(e.ToColumns) == 1 {
+			to += ":" + dotQuote(e.ToColumns[0])
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [arrowtail=%s, arrowhead=%s, tooltip=%s];\n", from, to, tail, head, dotQuote(e.Name))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// diagramIdentRe Mermaid 和 PlantUML 标识符中不允许的字符
+var diagramIdentRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// diagramIdent 将名称转换为 Mermaid/PlantUML 可用的标识符
+func diagramIdent(name string) string {
+	id := diagramIdentRe.ReplaceAllString(name, "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "_" + id
+	}
+	return id
+}
+
+// crowFoot 返回关系两端的鸦脚符号，左侧为引用方，右侧为被引用方
+func (e GraphEdge) crowFoot() (string, string) {
+	left := "}o"
+	if e.Cardinality == CardinalityOneToOne {
+		left = "|o"
+	}
+	right := "||"
+	if e.Optional {
+		right = "o|"
+	}
+	return left, right
+}
+
+func (g *SchemaGraph) mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("erDiagram\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "    %s[\"%s\"] {\n", diagramIdent(n.Name), strings.ReplaceAll(n.Name, `"`, "'"))
+		for _, c := range n.Columns {
+			fmt.Fprintf(&sb, "        %s %s", diagramIdent(strings.ReplaceAll(c.Type, " ", "_")), diagramIdent(c.Name))
+			if keys := c.keys(); len(keys) > 0 {
+				sb.WriteString(" " + strings.Join(keys, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("    }\n")
+	}
+	for _, e := range g.Edges {
+		left, right := e.crowFoot()
+		fmt.Fprintf(&sb, "    %s %s--%s %s : \"%s\"\n", diagramIdent(e.From), left, right, diagramIdent(e.To),
+			strings.ReplaceAll(strings.Join(e.FromColumns, ", "), `"`, "'"))
+	}
+	return sb.String()
+}
+
+func (g *SchemaGraph) plantUML() string {
+	var sb strings.Builder
+	sb.WriteString("@startuml\nhide circle\nskinparam linetype ortho\n\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "entity \"%s\" as %s {\n", strings.ReplaceAll(n.Name, `"`, "'"), diagramIdent(n.Name))
+		var keys, others []string
+		for _, c := range n.Columns {
+			line := c.Name + " : " + c.Type
+			if !c.Nullable {
+				line = "* " + line
+			}
+			if marks := c.keys(); len(marks) > 0 {
+				line += " <<" + strings.Join(marks, ",") + ">>"
+			}
+			if c.IsPrimary {
+				keys = append(keys, line)
+			} else {
+				others = append(others, line)
+			}
+		}
+		for _, line := range keys {
+			sb.WriteString("  " + line + "\n")
+		}
+		if len(keys) > 0 {
+			sb.WriteString("  --\n")
+		}
+		for _, line := range others {
+			sb.WriteString("  " + line + "\n")
+		}
+		sb.WriteString("}\n\n")
+	}
+	for _, e := range g.Edges {
+		left, right := e.crowFoot()
+		fmt.Fprintf(&sb, "%s %s--%s %s : %s\n", diagramIdent(e.From), left, right, diagramIdent(e.To), strings.Join(e.FromColumns, ", "))
+	}
+	sb.WriteString("@enduml\n")
+	return sb.String()
+}
@@ -0,0 +1,408 @@
+package database
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 导入时单行出错的处理方式
+const (
+	OnErrorAbort  = "abort"
+	OnErrorSkip   = "skip"
+	OnErrorReject = "reject"
+)
+
+// defaultImportBatch 每个事务默认写入的行数
+const defaultImportBatch = 500
+
+// maxImportErrors 导入结果中保留的错误信息条数
+const maxImportErrors = 20
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Source   ImportSourceOptions `json:"Source"`
+	Database string              `json:"Database"`
+	Schema   string              `json:"Schema"`
+	Table    string              `json:"Table"`
+	// CreateTable 为 true 时按推断的类型新建表，表已存在时按列名映射到已有的列
+	CreateTable bool `json:"CreateTable"`
+	// Mapping 源字段到目标列的映射，值为空表示忽略该字段；为空时按名称（不区分大小写）匹配
+	Mapping   map[string]string `json:"Mapping"`
+	BatchSize int               `json:"BatchSize"`
+	// OnError 为 abort、skip 或 reject，reject 会把出错的行写入 RejectPath
+	OnError    string `json:"OnError"`
+	RejectPath string `json:"RejectPath"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Rows       int64    `json:"Rows"`
+	Inserted   int64    `json:"Inserted"`
+	Failed     int64    `json:"Failed"`
+	RejectPath string   `json:"RejectPath"`
+	Errors     []string `json:"Errors"`
+}
+
+// ImportProgressFunc 导入进度回调
+type ImportProgressFunc func(result ImportResult, bytesRead, size int64)
+
+// importer 一次导入的执行状态
+type importer struct {
+	opts    ImportOptions
+	dialect Dialect
+	src     *ImportSource
+	result  ImportResult
+	// fieldIndex 参与写入的源字段下标，与 INSERT 列顺序一致
+	fieldIndex []int
+	// kinds 各目标列的值分类，与 fieldIndex 对应
+	kinds  []string
+	reject *rejectWriter
+}
+
+// RunImport 将文件按批次写入目标表，每批一个事务
+func RunImport(ctx context.Context, adapter DBAdapter, dbType string, opts ImportOptions, progress ImportProgressFunc) (ImportResult, error) {
+	if opts.Table == "" {
+		return ImportResult{}, fmt.Errorf("target table is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatch
+	}
+	if opts.OnError == "" {
+		opts.OnError = OnErrorAbort
+	}
+
+	src, err := OpenImportSource(opts.Source)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	defer src.Close()
+
+	im := &importer{opts: opts, dialect: DialectOf(dbType), src: src}
+	defer im.closeReject()
+
+	conn, err := adapter.Conn(ctx, opts.Database)
+	if err != nil {
+		return im.result, err
+	}
+	defer conn.Close()
+
+	table := im.dialect.TableName(opts.Database, opts.Schema, opts.Table)
+	existing, err := SchemaTableColumns(adapter, opts.Database, opts.Schema, opts.Table)
+	if err != nil {
+		return im.result, err
+	}
+	var targets []string
+	if opts.CreateTable && len(existing) == 0 {
+		targets, err = im.createTable(ctx, conn, table)
+	} else {
+		targets, err = im.mapColumns(existing)
+	}
+	if err != nil {
+		return im.result, err
+	}
+
+	quoted := make([]string, len(targets))
+	placeholders := make([]string, len(targets))
+	for i, name := range targets {
+		quoted[i] = im.dialect.QuoteIdent(name)
+		placeholders[i] = im.dialect.Placeholder(i + 1)
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+
+	for {
+		done, err := im.runBatch(ctx, conn, query)
+		if progress != nil {
+			progress(im.result, src.BytesRead(), src.Size())
+		}
+		if err != nil || done {
+			return im.result, err
+		}
+	}
+}
+
+// createTable 按推断的字段类型建表，返回目标列名
+func (im *importer) createTable(ctx context.Context, conn *sqlx.Conn, table string) ([]string, error) {
+	preview, err := PreviewImport(im.opts.Source, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]ColumnInfo, 0, len(preview.Fields))
+	var targets []string
+	for i, f := range preview.Fields {
+		name := f.Name
+		if im.opts.Mapping != nil {
+			mapped, ok := im.opts.Mapping[f.Name]
+			if ok && mapped == "" {
+				continue
+			}
+			if mapped != "" {
+				name = mapped
+			}
+		}
+		columns = append(columns, ColumnInfo{Name: name, Type: im.dialect.TypeForKind(f.Kind, f.MaxLength), Nullable: true})
+		targets = append(targets, name)
+		im.fieldIndex = append(im.fieldIndex, i)
+		im.kinds = append(im.kinds, f.Kind)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no fields to import")
+	}
+	if _, err := conn.ExecContext(ctx, im.dialect.BuildCreateTable(table, columns, false)); err != nil {
+		return nil, fmt.Errorf("create table: %v", err)
+	}
+	return targets, nil
+}
+
+// mapColumns 将源字段映射到已有表的列
+func (im *importer) mapColumns(columns []ColumnInfo) ([]string, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s not found", im.opts.Table)
+	}
+	byName := make(map[string]ColumnInfo)
+	for _, col := range columns {
+		byName[strings.ToLower(col.Name)] = col
+	}
+
+	var targets []string
+	for i, field := range im.src.Fields() {
+		target := field
+		if im.opts.Mapping != nil {
+			mapped, ok := im.opts.Mapping[field]
+			if ok && mapped == "" {
+				continue
+			}
+			if mapped != "" {
+				target = mapped
+			}
+		}
+		col, ok := byName[strings.ToLower(target)]
+		if !ok {
+			if im.opts.Mapping != nil && im.opts.Mapping[field] != "" {
+				return nil, fmt.Errorf("column %s not found in table %s", target, im.opts.Table)
+			}
+			continue
+		}
+		targets = append(targets, col.Name)
+		im.fieldIndex = append(im.fieldIndex, i)
+		im.kinds = append(im.kinds, ColumnKind(col.Type))
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no fields match the columns of table %s", im.opts.Table)
+	}
+	return targets, nil
+}
+
+// runBatch 在一个事务中写入一批记录，文件读完时 done 为 true
+func (im *importer) runBatch(ctx context.Context, conn *sqlx.Conn, query string) (done bool, err error) {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	// PostgreSQL 中任何错误都会使整个事务失效，逐行使用保存点隔离
+	savepoint := im.dialect.Name == "postgres" && im.opts.OnError != OnErrorAbort
+
+	var inserted int64
+	for n := 0; n < im.opts.BatchSize; n++ {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		rec, readErr := im.src.Next()
+		if readErr == io.EOF {
+			done = true
+			break
+		}
+		if recErr, ok := readErr.(*RecordError); ok {
+			im.result.Rows++
+			if err := im.fail(recErr); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if readErr != nil {
+			return false, readErr
+		}
+		im.result.Rows++
+
+		args := make([]interface{}, len(im.fieldIndex))
+		for i, idx := range im.fieldIndex {
+			args[i] = im.value(im.kinds[i], rec.Values[idx])
+		}
+		if savepoint {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+				return false, err
+			}
+		}
+		if _, execErr := stmt.ExecContext(ctx, args...); execErr != nil {
+			if savepoint {
+				if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); err != nil {
+					return false, err
+				}
+			}
+			if err := im.fail(&RecordError{Line: rec.Line, Raw: rec.Raw, Err: execErr}); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if savepoint {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT import_row"); err != nil {
+				return false, err
+			}
+		}
+		inserted++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	im.result.Inserted += inserted
+	return done, nil
+}
+
+// value 按目标列的值分类转换源值：除文本列外空字符串写入 NULL，整数列中的 true/false 写入 1/0，
+// 布尔列接受 true/false、t/f、yes/no、1/0，MySQL 和 PostgreSQL 的日期时间解析后传入（MySQL 不接受 ISO 8601 的 T 和时区）。
+// 无法转换的值保持原样，由数据库报错
+func (im *importer) value(kind string, v interface{}) interface{} {
+	if b, ok := v.(bool); ok {
+		switch kind {
+		case KindInteger, KindNumber:
+			if b {
+				return 1
+			}
+			return 0
+		case KindText:
+			return strconv.FormatBool(b)
+		}
+		return b
+	}
+	s, ok := v.(string)
+	if !ok || kind == KindText {
+		return v
+	}
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return nil
+	}
+	switch kind {
+	case KindInteger, KindNumber:
+		switch strings.ToLower(trimmed) {
+		case "true":
+			return 1
+		case "false":
+			return 0
+		}
+		return trimmed
+	case KindBool:
+		switch strings.ToLower(trimmed) {
+		case "true", "t", "yes", "y", "1":
+			return true
+		case "false", "f", "no", "n", "0":
+			return false
+		}
+	case KindDate:
+		if t, ok := parseTimeText(trimmed); ok && im.dialect.Name != "sqlite" {
+			return t.Format("2006-01-02")
+		}
+	case KindDateTime:
+		if t, ok := parseTimeText(trimmed); ok && im.dialect.Name != "sqlite" {
+			return t
+		}
+	}
+	return s
+}
+
+// fail 按 OnError 处理单行错误，abort 时返回错误
+func (im *importer) fail(recErr *RecordError) error {
+	if im.opts.OnError == OnErrorAbort {
+		return recErr
+	}
+	im.result.Failed++
+	if len(im.result.Errors) < maxImportErrors {
+		im.result.Errors = append(im.result.Errors, recErr.Error())
+	}
+	if im.opts.OnError != OnErrorReject {
+		return nil
+	}
+	if im.reject == nil {
+		path := im.opts.RejectPath
+		if path == "" {
+			ext := ".rejects.ndjson"
+			if im.src.csv != nil {
+				ext = ".rejects.csv"
+			}
+			path = im.opts.Source.Path + ext
+		}
+		reject, err := newRejectWriter(path, im.src.Fields(), im.src.csv != nil)
+		if err != nil {
+			return err
+		}
+		im.reject = reject
+		im.result.RejectPath = path
+	}
+	return im.reject.write(recErr)
+}
+
+func (im *importer) closeReject() {
+	if im.reject != nil {
+		im.reject.close()
+	}
+}
+
+// rejectWriter 记录出错的行：CSV 源写为 CSV 并追加错误列，NDJSON 源每行写一个对象
+type rejectWriter struct {
+	file *os.File
+	csv  *csv.Writer
+	enc  *json.Encoder
+}
+
+func newRejectWriter(path string, fields []string, isCSV bool) (*rejectWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &rejectWriter{file: f}
+	if isCSV {
+		w.csv = csv.NewWriter(f)
+		w.csv.Write(append(append([]string{}, fields...), "_line", "_error"))
+	} else {
+		w.enc = json.NewEncoder(f)
+	}
+	return w, nil
+}
+
+func (w *rejectWriter) write(recErr *RecordError) error {
+	if w.csv != nil {
+		return w.csv.Write(append(append([]string{}, recErr.Raw...), fmt.Sprint(recErr.Line), recErr.Err.Error()))
+	}
+	raw := ""
+	if len(recErr.Raw) > 0 {
+		raw = recErr.Raw[0]
+	}
+	return w.enc.Encode(map[string]interface{}{"line": recErr.Line, "error": recErr.Err.Error(), "record": raw})
+}
+
+func (w *rejectWriter) close() {
+	if w.csv != nil {
+		w.csv.Flush()
+	}
+	w.file.Close()
+}
@@ -0,0 +1,504 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 导入文件格式
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// defaultSampleRows 推断列类型时默认读取的行数
+const defaultSampleRows = 1000
+
+// ImportSourceOptions 导入文件的读取选项
+type ImportSourceOptions struct {
+	Path   string `json:"Path"`
+	Format string `json:"Format"`
+	// Delimiter CSV 分隔符，默认逗号，\t 表示制表符
+	Delimiter string `json:"Delimiter"`
+	// NoHeader 为 true 时 CSV 第一行是数据，列名为 column1、column2…
+	NoHeader  bool   `json:"NoHeader"`
+	NullValue string `json:"NullValue"`
+	// EmptyAsNull 为 true 时 CSV 中的空字段视为 NULL
+	EmptyAsNull bool   `json:"EmptyAsNull"`
+	Encoding    string `json:"Encoding"`
+}
+
+// ImportField 推断出的字段信息
+type ImportField struct {
+	Name      string `json:"Name"`
+	Kind      string `json:"Kind"`
+	MaxLength int    `json:"MaxLength"`
+	Nullable  bool   `json:"Nullable"`
+}
+
+// ImportPreview 导入预览：字段及前几行数据
+type ImportPreview struct {
+	Fields []ImportField `json:"Fields"`
+	Rows   [][]string    `json:"Rows"`
+}
+
+// ImportRecord 读取到的一条记录，Values 与字段一一对应，nil 表示 NULL
+type ImportRecord struct {
+	Line   int
+	Values []interface{}
+	Raw    []string
+}
+
+// countingReader 统计已读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ImportSource 按行读取导入文件
+type ImportSource struct {
+	opts    ImportSourceOptions
+	file    *os.File
+	counter *countingReader
+	size    int64
+	fields  []string
+	csv     *csv.Reader
+	lines   *bufio.Reader
+	line    int
+	// first 无表头 CSV 中已读取的第一行
+	first []ImportRecord
+}
+
+// OpenImportSource 打开导入文件并读取字段名
+func OpenImportSource(opts ImportSourceOptions) (*ImportSource, error) {
+	f, err := os.Open(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	s := &ImportSource{opts: opts, file: f, size: info.Size()}
+	s.counter = &countingReader{r: f}
+	r, err := decodingReader(s.counter, opts.Encoding)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	switch strings.ToLower(opts.Format) {
+	case "", ImportCSV:
+		err = s.openCSV(r)
+	case ImportNDJSON, "jsonl":
+		err = s.openNDJSON(r)
+	default:
+		err = fmt.Errorf("unsupported import format: %s", opts.Format)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// decodingReader 按编码解码输入并去掉 UTF-8 BOM
+func decodingReader(r io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToUpper(strings.ReplaceAll(encoding, "_", "-")) {
+	case "", "UTF-8", "UTF8", "UTF-8-BOM", "UTF8-BOM":
+		br := bufio.NewReader(r)
+		if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+			br.Discard(3)
+		}
+		return br, nil
+	case "UTF-16LE", "UTF-16":
+		return transform.NewReader(r, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()), nil
+	case "GBK", "GB18030":
+		return transform.NewReader(r, simplifiedchinese.GB18030.NewDecoder()), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+}
+
+func (s *ImportSource) openCSV(r io.Reader) error {
+	s.csv = csv.NewReader(r)
+	s.csv.FieldsPerRecord = -1
+	s.csv.LazyQuotes = true
+	s.csv.ReuseRecord = false
+	if d := s.opts.Delimiter; d != "" {
+		if d == `\t` {
+			d = "\t"
+		}
+		comma, _ := utf8.DecodeRuneInString(d)
+		s.csv.Comma = comma
+	}
+
+	header, err := s.csv.Read()
+	if err == io.EOF {
+		return fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return err
+	}
+	if s.opts.NoHeader {
+		for i := range header {
+			s.fields = append(s.fields, "column"+strconv.Itoa(i+1))
+		}
+		s.first = append(s.first, s.csvRecord(header, 1))
+		return nil
+	}
+	s.line = 1
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = "column" + strconv.Itoa(i+1)
+		}
+		s.fields = append(s.fields, name)
+	}
+	return nil
+}
+
+// csvRecord 将 CSV 字段转换为记录，匹配 NullValue 的字段视为 NULL
+func (s *ImportSource) csvRecord(fields []string, line int) ImportRecord {
+	rec := ImportRecord{Line: line, Values: make([]interface{}, len(s.fields)), Raw: fields}
+	for i := range s.fields {
+		if i >= len(fields) {
+			continue
+		}
+		if s.opts.NullValue != "" && fields[i] == s.opts.NullValue || s.opts.EmptyAsNull && fields[i] == "" {
+			continue
+		}
+		rec.Values[i] = fields[i]
+	}
+	return rec
+}
+
+// openNDJSON 预读前若干行，以出现过的键作为字段，然后回到文件开头
+func (s *ImportSource) openNDJSON(r io.Reader) error {
+	s.lines = bufio.NewReaderSize(r, 1024*1024)
+	for n := 0; n < defaultSampleRows; n++ {
+		_, raw, err := s.readObject()
+		if err == io.EOF {
+			break
+		}
+		if _, ok := err.(*RecordError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		// 保持字段在文件中首次出现的顺序
+		for _, key := range orderedKeys(raw) {
+			s.addField(key)
+		}
+	}
+	if len(s.fields) == 0 {
+		return fmt.Errorf("no JSON objects found")
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	s.counter.n = 0
+	s.line = 0
+	r, err := decodingReader(s.counter, s.opts.Encoding)
+	if err != nil {
+		return err
+	}
+	s.lines = bufio.NewReaderSize(r, 1024*1024)
+	return nil
+}
+
+func (s *ImportSource) addField(name string) {
+	for _, f := range s.fields {
+		if f == name {
+			return
+		}
+	}
+	s.fields = append(s.fields, name)
+}
+
+// readObject 读取下一个非空行并解析为对象
+func (s *ImportSource) readObject() (map[string]json.RawMessage, string, error) {
+	for {
+		line, err := s.lines.ReadString('\n')
+		if line == "" && err != nil {
+			return nil, "", err
+		}
+		s.line++
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if jsonErr := json.Unmarshal([]byte(line), &obj); jsonErr != nil {
+			return nil, line, &RecordError{Line: s.line, Raw: []string{line}, Err: jsonErr}
+		}
+		return obj, line, nil
+	}
+}
+
+// orderedKeys 按出现顺序返回 JSON 对象的键
+func orderedKeys(raw string) []string {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return nil
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		key, _ := tok.(string)
+		keys = append(keys, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+// jsonRecord 将 JSON 对象按字段顺序转换为记录，嵌套对象和数组保留为 JSON 文本
+func (s *ImportSource) jsonRecord(obj map[string]json.RawMessage, raw string, line int) ImportRecord {
+	rec := ImportRecord{Line: line, Values: make([]interface{}, len(s.fields)), Raw: []string{raw}}
+	for i, name := range s.fields {
+		value, ok := obj[name]
+		if !ok {
+			continue
+		}
+		rec.Values[i] = jsonScalar(value)
+	}
+	return rec
+}
+
+func jsonScalar(raw json.RawMessage) interface{} {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return nil
+	}
+	switch trimmed[0] {
+	case '"':
+		var s string
+		json.Unmarshal(trimmed, &s)
+		return s
+	case 't', 'f':
+		return string(trimmed) == "true"
+	case '{', '[':
+		return string(trimmed)
+	}
+	// 数值保留原始文本，避免大整数丢失精度
+	return string(trimmed)
+}
+
+// RecordError 单条记录的读取或写入错误
+type RecordError struct {
+	Line int
+	Raw  []string
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Fields 字段名
+func (s *ImportSource) Fields() []string {
+	return s.fields
+}
+
+// Next 读取下一条记录，文件结束时返回 io.EOF，格式错误时返回 *RecordError
+func (s *ImportSource) Next() (ImportRecord, error) {
+	if len(s.first) > 0 {
+		rec := s.first[0]
+		s.first = s.first[1:]
+		return rec, nil
+	}
+	if s.csv != nil {
+		fields, err := s.csv.Read()
+		if err != nil {
+			if err == io.EOF {
+				return ImportRecord{}, err
+			}
+			line, _ := s.csv.FieldPos(0)
+			return ImportRecord{}, &RecordError{Line: line, Raw: fields, Err: err}
+		}
+		line, _ := s.csv.FieldPos(0)
+		return s.csvRecord(fields, line), nil
+	}
+	obj, raw, err := s.readObject()
+	if err != nil {
+		return ImportRecord{}, err
+	}
+	return s.jsonRecord(obj, raw, s.line), nil
+}
+
+// BytesRead 已读取的字节数
+func (s *ImportSource) BytesRead() int64 {
+	return s.counter.n
+}
+
+// Size 文件大小
+func (s *ImportSource) Size() int64 {
+	return s.size
+}
+
+// Close 关闭文件
+func (s *ImportSource) Close() error {
+	return s.file.Close()
+}
+
+// PreviewImport 读取样本并推断字段类型，返回前 previewRows 行数据
+func PreviewImport(opts ImportSourceOptions, sampleRows, previewRows int) (ImportPreview, error) {
+	if sampleRows <= 0 {
+		sampleRows = defaultSampleRows
+	}
+	src, err := OpenImportSource(opts)
+	if err != nil {
+		return ImportPreview{}, err
+	}
+	defer src.Close()
+
+	inf := newTypeInference(len(src.Fields()))
+	preview := ImportPreview{Rows: [][]string{}}
+	for n := 0; n < sampleRows; n++ {
+		rec, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if _, ok := err.(*RecordError); ok {
+			continue
+		}
+		if err != nil {
+			return preview, err
+		}
+		inf.add(rec.Values)
+		if len(preview.Rows) < previewRows {
+			row := make([]string, len(rec.Values))
+			for i, v := range rec.Values {
+				if v != nil {
+					row[i] = fmt.Sprint(v)
+				}
+			}
+			preview.Rows = append(preview.Rows, row)
+		}
+	}
+	preview.Fields = inf.fields(src.Fields())
+	return preview, nil
+}
+
+// typeInference 按样本推断每个字段的类型
+type typeInference struct {
+	kinds    []string
+	lengths  []int
+	nullable []bool
+	seen     []bool
+}
+
+func newTypeInference(n int) *typeInference {
+	return &typeInference{
+		kinds:    make([]string, n),
+		lengths:  make([]int, n),
+		nullable: make([]bool, n),
+		seen:     make([]bool, n),
+	}
+}
+
+func (t *typeInference) add(values []interface{}) {
+	for i := range t.kinds {
+		var v interface{}
+		if i < len(values) {
+			v = values[i]
+		}
+		if v == nil || v == "" {
+			t.nullable[i] = true
+			continue
+		}
+		kind := valueKind(v)
+		if text, ok := v.(string); ok && utf8.RuneCountInString(text) > t.lengths[i] {
+			t.lengths[i] = utf8.RuneCountInString(text)
+		}
+		if !t.seen[i] {
+			t.seen[i] = true
+			t.kinds[i] = kind
+			continue
+		}
+		t.kinds[i] = widenKind(t.kinds[i], kind)
+	}
+}
+
+func (t *typeInference) fields(names []string) []ImportField {
+	fields := make([]ImportField, len(names))
+	for i, name := range names {
+		kind := t.kinds[i]
+		if kind == "" {
+			kind = KindText
+		}
+		fields[i] = ImportField{Name: name, Kind: kind, MaxLength: t.lengths[i], Nullable: t.nullable[i] || !t.seen[i]}
+	}
+	return fields
+}
+
+// valueKind 推断单个值的类型
+func valueKind(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return KindBool
+	case string:
+		s := strings.TrimSpace(v)
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return KindInteger
+		}
+		if _, ok := numberText(s); ok {
+			return KindNumber
+		}
+		switch strings.ToLower(s) {
+		case "true", "false":
+			return KindBool
+		}
+		if t, ok := parseTimeText(s); ok {
+			if len(s) == len("2006-01-02") && t.Hour() == 0 && t.Minute() == 0 {
+				return KindDate
+			}
+			return KindDateTime
+		}
+	}
+	return KindText
+}
+
+// widenKind 合并两个类型，取能同时容纳两者的类型
+func widenKind(a, b string) string {
+	if a == b {
+		return a
+	}
+	if (a == KindInteger && b == KindNumber) || (a == KindNumber && b == KindInteger) {
+		return KindNumber
+	}
+	if (a == KindDate && b == KindDateTime) || (a == KindDateTime && b == KindDate) {
+		return KindDateTime
+	}
+	return KindText
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPathStep JSON 路径中的一级，IsIndex 为 true 时表示数组下标
+type JSONPathStep struct {
+	Key     string `json:"Key"`
+	Index   int    `json:"Index"`
+	IsIndex bool   `json:"IsIndex"`
+}
+
+// JSON 过滤条件支持的运算符
+const (
+	JSONOpEqual        = "="
+	JSONOpNotEqual     = "!="
+	JSONOpGreater      = ">"
+	JSONOpGreaterEqual = ">="
+	JSONOpLess         = "<"
+	JSONOpLessEqual    = "<="
+	JSONOpLike         = "LIKE"
+	JSONOpNotLike      = "NOT LIKE"
+	JSONOpIsNull       = "IS NULL"
+	JSONOpIsNotNull    = "IS NOT NULL"
+)
+
+// ParseJSONPath 解析 JSON 路径，支持 $.a.b[0]、a.b[0] 以及 $."a.b" 形式的带引号键名，开头的 $ 可省略
+func ParseJSONPath(path string) ([]JSONPathStep, error) {
+	s := strings.TrimSpace(path)
+	s = strings.TrimPrefix(s, "$")
+	var steps []JSONPathStep
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in JSON path %q", path)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(s[i+1 : i+end]))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index %q in JSON path %q", s[i+1:i+end], path)
+			}
+			steps = append(steps, JSONPathStep{Index: n, IsIndex: true})
+			i += end + 1
+		case c == '.' || i == 0:
+			if c == '.' {
+				i++
+			}
+			if i < len(s) && s[i] == '"' {
+				end := strings.IndexByte(s[i+1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated key in JSON path %q", path)
+				}
+				steps = append(steps, JSONPathStep{Key: s[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("empty key in JSON path %q", path)
+			}
+			steps = append(steps, JSONPathStep{Key: s[start:i]})
+		default:
+			return nil, fmt.Errorf("unexpected %q in JSON path %q", c, path)
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("JSON path is empty")
+	}
+	return steps, nil
+}
+
+// jsonPathText 生成 MySQL 和 SQLite 使用的 $.a.b[0] 形式的路径，非标识符的键名加双引号
+func jsonPathText(steps []JSONPathStep) (string, error) {
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for _, step := range steps {
+		if step.IsIndex {
+			sb.WriteString("[" + strconv.Itoa(step.Index) + "]")
+			continue
+		}
+		if isPlainKey(step.Key) {
+			sb.WriteString("." + step.Key)
+			continue
+		}
+		if strings.ContainsAny(step.Key, `"\`) {
+			return "", fmt.Errorf("JSON key %q contains unsupported characters", step.Key)
+		}
+		sb.WriteString(`."` + step.Key + `"`)
+	}
+	return sb.String(), nil
+}
+
+// isPlainKey 判断键名是否可以不加引号写在路径中
+func isPlainKey(key string) bool {
+	for i, c := range key {
+		switch {
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return key != ""
+}
+
+// JSONExtract 返回按路径取出 JSON 列中的值的文本表达式：MySQL 使用 JSON_UNQUOTE(JSON_EXTRACT())，
+// PostgreSQL 使用 -> 和 ->> 运算符，SQLite 使用 json_extract()，内容不是有效 JSON 的行按 NULL 处理
+func (d Dialect) JSONExtract(column string, steps []JSONPathStep) (string, error) {
+	col := d.QuoteIdent(column)
+	switch d.Name {
+	case "postgres":
+		return pgJSONPath(d, col, steps, "->>"), nil
+	case "mysql", "sqlite":
+		path, err := jsonPathText(steps)
+		if err != nil {
+			return "", err
+		}
+		if d.Name == "mysql" {
+			return "JSON_UNQUOTE(JSON_EXTRACT(" + col + ", " + d.QuoteString(path) + "))", nil
+		}
+		// SQLite 不校验列内容，json_extract 遇到无效的 JSON 会使整个查询失败
+		return "json_extract(CASE WHEN json_valid(" + col + ") THEN " + col + " END, " + d.QuoteString(path) + ")", nil
+	}
+	return "", fmt.Errorf("unsupported database type: %s", d.Name)
+}
+
+// pgJSONPath 用 -> 逐级取值，最后一级使用 last 运算符
+func pgJSONPath(d Dialect, expr string, steps []JSONPathStep, last string) string {
+	var sb strings.Builder
+	sb.WriteString(expr)
+	for i, step := range steps {
+		if i == len(steps)-1 {
+			sb.WriteString(last)
+		} else {
+			sb.WriteString("->")
+		}
+		if step.IsIndex {
+			sb.WriteString(strconv.Itoa(step.Index))
+		} else {
+			sb.WriteString(d.QuoteString(step.Key))
+		}
+	}
+	return sb.String()
+}
+
+// JSONFilter 生成按 JSON 路径过滤的条件。数值按数值比较，PostgreSQL 会把 JSON 数值转换为 numeric，其他值不匹配；
+// true/false 在 SQLite 中按 1/0 比较；IS NULL 同时匹配路径不存在和值为 null
+func (d Dialect) JSONFilter(column, path, op, value string) (string, error) {
+	steps, err := ParseJSONPath(path)
+	if err != nil {
+		return "", err
+	}
+	expr, err := d.JSONExtract(column, steps)
+	if err != nil {
+		return "", err
+	}
+
+	op = strings.ToUpper(strings.Join(strings.Fields(op), " "))
+	switch op {
+	case JSONOpIsNull, JSONOpIsNotNull:
+		return expr + " " + op, nil
+	case JSONOpLike, JSONOpNotLike:
+		return expr + " " + op + " " + d.QuoteString(value), nil
+	case "<>":
+		op = JSONOpNotEqual
+	case JSONOpEqual, JSONOpNotEqual, JSONOpGreater, JSONOpGreaterEqual, JSONOpLess, JSONOpLessEqual:
+	default:
+		return "", fmt.Errorf("unsupported operator: %s", op)
+	}
+
+	_, number := numberText(value)
+	switch {
+	case number && d.Name == "postgres":
+		// ->> 取出的是文本，按数值比较使 10 与 10.0 相等；只转换 JSON 数值，其他行的值不是数字时转换会使查询失败
+		typed := pgJSONPath(d, "("+d.QuoteIdent(column)+")::jsonb", steps, "->")
+		return "CASE WHEN jsonb_typeof(" + typed + ") = 'number' THEN (" + expr + ")::numeric END " + op + " " + value, nil
+	case number:
+		return expr + " " + op + " " + value, nil
+	case d.Name == "sqlite" && (value == "true" || value == "false"):
+		if value == "true" {
+			return expr + " " + op + " 1", nil
+		}
+		return expr + " " + op + " 0", nil
+	}
+	return expr + " " + op + " " + d.QuoteString(value), nil
+}
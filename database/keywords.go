@@ -0,0 +1,62 @@
+package database
+
+import (
+	"sort"
+	"strings"
+)
+
+// commonKeywords 各数据库通用的SQL关键字
+var commonKeywords = []string{
+	"ADD", "ALL", "ALTER", "AND", "ANY", "AS", "ASC", "BEGIN", "BETWEEN", "BY",
+	"CASCADE", "CASE", "CHECK", "COLUMN", "COMMIT", "CONSTRAINT", "CREATE", "CROSS",
+	"DATABASE", "DEFAULT", "DELETE", "DESC", "DISTINCT", "DROP", "ELSE", "END",
+	"EXCEPT", "EXISTS", "FALSE", "FOREIGN", "FROM", "FULL", "GROUP", "HAVING", "IF",
+	"IN", "INDEX", "INNER", "INSERT", "INTERSECT", "INTO", "IS", "JOIN", "KEY", "LEFT",
+	"LIKE", "LIMIT", "NOT", "NULL", "OFFSET", "ON", "OR", "ORDER", "OUTER", "PRIMARY",
+	"REFERENCES", "RENAME", "RIGHT", "ROLLBACK", "SELECT", "SET", "TABLE", "THEN", "TRANSACTION",
+	"TRIGGER", "TRUE", "TRUNCATE", "UNION", "UNIQUE", "UPDATE", "USING", "VALUES",
+	"VIEW", "WHEN", "WHERE", "WITH",
+}
+
+// dialectKeywords 各数据库特有的关键字
+var dialectKeywords = map[string][]string{
+	"mysql": {
+		"AUTO_INCREMENT", "CHARSET", "COLLATE", "DESCRIBE", "DUPLICATE", "ENGINE",
+		"EXPLAIN", "IGNORE", "LOCK", "REGEXP", "REPLACE", "SHOW", "STRAIGHT_JOIN",
+		"UNLOCK", "UNSIGNED", "USE", "ZEROFILL",
+	},
+	"postgres": {
+		"ANALYZE", "ARRAY", "CONFLICT", "DO", "EXPLAIN", "ILIKE", "LATERAL", "NOTHING",
+		"RETURNING", "SCHEMA", "SEQUENCE", "SIMILAR", "VACUUM", "WINDOW",
+	},
+	"sqlite": {
+		"ATTACH", "AUTOINCREMENT", "DETACH", "EXPLAIN", "GLOB", "IGNORE", "PRAGMA",
+		"REPLACE", "VACUUM", "WITHOUT",
+	},
+}
+
+var keywordSet = func() map[string]bool {
+	set := make(map[string]bool)
+	for _, k := range commonKeywords {
+		set[k] = true
+	}
+	for _, list := range dialectKeywords {
+		for _, k := range list {
+			set[k] = true
+		}
+	}
+	return set
+}()
+
+// IsKeyword 判断单词是否为SQL关键字
+func IsKeyword(word string) bool {
+	return keywordSet[strings.ToUpper(word)]
+}
+
+// Keywords 返回指定数据库类型可用的关键字
+func Keywords(dbType string) []string {
+	list := append([]string{}, commonKeywords...)
+	list = append(list, dialectKeywords[dbType]...)
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"sort"
+	"strings"
+)
+
+// LockWait 一个等待中的锁请求，WaitingID 等待 BlockingID 释放锁。
+// LockMode 为请求的锁模式，HeldMode 为阻塞方持有的锁模式，WaitTime 为已等待的秒数
+type LockWait struct {
+	WaitingID  int64   `json:"WaitingID"`
+	BlockingID int64   `json:"BlockingID"`
+	LockMode   string  `json:"LockMode"`
+	HeldMode   string  `json:"HeldMode"`
+	LockType   string  `json:"LockType"`
+	Object     string  `json:"Object"`
+	LockData   string  `json:"LockData"`
+	WaitTime   float64 `json:"WaitTime"`
+}
+
+// BlockingNode 阻塞树中的一个连接，Children 为被它阻塞的连接。
+// 根节点不等待任何锁；Deadlock 表示该节点处在循环等待中
+type BlockingNode struct {
+	Process  ProcessInfo    `json:"Process"`
+	LockMode string         `json:"LockMode"`
+	HeldMode string         `json:"HeldMode"`
+	LockType string         `json:"LockType"`
+	Object   string         `json:"Object"`
+	LockData string         `json:"LockData"`
+	WaitTime float64        `json:"WaitTime"`
+	Deadlock bool           `json:"Deadlock"`
+	Children []BlockingNode `json:"Children"`
+}
+
+// BuildBlockingTree 根据锁等待关系生成阻塞树，processes 用于补充连接的用户、SQL 等信息
+func BuildBlockingTree(waits []LockWait, processes []ProcessInfo) []BlockingNode {
+	info := make(map[int64]ProcessInfo, len(processes))
+	for _, p := range processes {
+		info[p.ID] = p
+	}
+	process := func(id int64) ProcessInfo {
+		if p, ok := info[id]; ok {
+			return p
+		}
+		return ProcessInfo{ID: id}
+	}
+
+	blocked := make(map[int64][]LockWait)
+	waiting := make(map[int64]bool)
+	for _, w := range waits {
+		blocked[w.BlockingID] = append(blocked[w.BlockingID], w)
+		waiting[w.WaitingID] = true
+	}
+
+	visited := make(map[int64]bool)
+	var build func(node BlockingNode, path map[int64]bool) BlockingNode
+	build = func(node BlockingNode, path map[int64]bool) BlockingNode {
+		id := node.Process.ID
+		visited[id] = true
+		path[id] = true
+		for _, w := range blocked[id] {
+			child := BlockingNode{
+				Process:  process(w.WaitingID),
+				LockMode: w.LockMode,
+				HeldMode: w.HeldMode,
+				LockType: w.LockType,
+				Object:   w.Object,
+				LockData: w.LockData,
+				WaitTime: w.WaitTime,
+			}
+			// 循环等待时不再展开，标记为死锁
+			if path[w.WaitingID] {
+				child.Deadlock = true
+				node.Deadlock = true
+				node.Children = append(node.Children, child)
+				continue
+			}
+			node.Children = append(node.Children, build(child, path))
+		}
+		delete(path, id)
+		return node
+	}
+
+	var ids []int64
+	for id := range blocked {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, k int) bool { return ids[i] < ids[k] })
+
+	var roots []BlockingNode
+	for _, id := range ids {
+		if !waiting[id] {
+			roots = append(roots, build(BlockingNode{Process: process(id)}, make(map[int64]bool)))
+		}
+	}
+	// 所有阻塞方都在等待时存在循环，从编号最小的连接开始展开
+	for _, id := range ids {
+		if !visited[id] {
+			roots = append(roots, build(BlockingNode{Process: process(id), Deadlock: true}, make(map[int64]bool)))
+		}
+	}
+	if roots == nil {
+		roots = []BlockingNode{}
+	}
+	return roots
+}
+
+// GetLockWaits 获取 InnoDB 的锁等待，MySQL 8.0 使用 performance_schema.data_lock_waits，
+// 5.7 及 MariaDB 使用 information_schema.INNODB_LOCK_WAITS
+func (a *MySQLAdapter) GetLockWaits() ([]LockWait, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	var version string
+	if err := db.Get(&version, "SELECT VERSION()"); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT COALESCE(rt.PROCESSLIST_ID, 0), COALESCE(bt.PROCESSLIST_ID, 0), rl.LOCK_MODE, COALESCE(bl.LOCK_MODE, ''), rl.LOCK_TYPE,
+			CONCAT_WS('.', rl.OBJECT_SCHEMA, rl.OBJECT_NAME, rl.INDEX_NAME), COALESCE(rl.LOCK_DATA, ''),
+			COALESCE(TIMESTAMPDIFF(MICROSECOND, trx.trx_wait_started, NOW()) / 1000000, 0)
+		FROM performance_schema.data_lock_waits w
+		JOIN performance_schema.data_locks rl ON rl.ENGINE_LOCK_ID = w.REQUESTING_ENGINE_LOCK_ID
+		LEFT JOIN performance_schema.data_locks bl ON bl.ENGINE_LOCK_ID = w.BLOCKING_ENGINE_LOCK_ID
+		JOIN performance_schema.threads rt ON rt.THREAD_ID = w.REQUESTING_THREAD_ID
+		JOIN performance_schema.threads bt ON bt.THREAD_ID = w.BLOCKING_THREAD_ID
+		LEFT JOIN information_schema.INNODB_TRX trx ON trx.trx_id = w.REQUESTING_ENGINE_TRANSACTION_ID
+		ORDER BY rt.PROCESSLIST_ID
+	`
+	if strings.HasPrefix(version, "5.") || strings.Contains(version, "MariaDB") {
+		query = `
+			SELECT r.trx_mysql_thread_id, b.trx_mysql_thread_id, rl.lock_mode, bl.lock_mode, rl.lock_type,
+				CONCAT_WS('.', rl.lock_table, rl.lock_index), COALESCE(rl.lock_data, ''),
+				COALESCE(TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()), 0)
+			FROM information_schema.INNODB_LOCK_WAITS w
+			JOIN information_schema.INNODB_TRX r ON r.trx_id = w.requesting_trx_id
+			JOIN information_schema.INNODB_TRX b ON b.trx_id = w.blocking_trx_id
+			JOIN information_schema.INNODB_LOCKS rl ON rl.lock_id = w.requested_lock_id
+			JOIN information_schema.INNODB_LOCKS bl ON bl.lock_id = w.blocking_lock_id
+			ORDER BY r.trx_mysql_thread_id
+		`
+	}
+	rows, err := db.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	waits := []LockWait{}
+	for rows.Next() {
+		var w LockWait
+		if err := rows.Scan(&w.WaitingID, &w.BlockingID, &w.LockMode, &w.HeldMode, &w.LockType, &w.Object, &w.LockData, &w.WaitTime); err != nil {
+			return nil, err
+		}
+		waits = append(waits, w)
+	}
+	return waits, rows.Err()
+}
+
+// GetLockWaits 通过 pg_locks 和 pg_blocking_pids() 获取锁等待。PostgreSQL 14 起按 pg_locks.waitstart 计算等待时间，
+// 旧版本没有该列，使用语句开始时间
+func (a *PostgresAdapter) GetLockWaits() ([]LockWait, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	var version int
+	if err := db.Get(&version, "SELECT current_setting('server_version_num')::int"); err != nil {
+		return nil, err
+	}
+	waitStart := "a.query_start"
+	if version >= 140000 {
+		waitStart = "COALESCE(wl.waitstart, a.query_start)"
+	}
+
+	query := `
+		SELECT wl.pid, b.pid, wl.mode,
+			COALESCE((
+				SELECT string_agg(DISTINCT hl.mode, ', ')
+				FROM pg_locks hl
+				WHERE hl.pid = b.pid AND hl.granted AND hl.locktype = wl.locktype
+					AND hl.database IS NOT DISTINCT FROM wl.database
+					AND hl.relation IS NOT DISTINCT FROM wl.relation
+					AND hl.transactionid IS NOT DISTINCT FROM wl.transactionid
+					AND hl.virtualxid IS NOT DISTINCT FROM wl.virtualxid
+			), ''),
+			wl.locktype,
+			COALESCE(wl.relation::regclass::text, 'transaction ' || wl.transactionid::text, wl.virtualxid, ''),
+			COALESCE('page ' || wl.page || ', tuple ' || wl.tuple, ''),
+			COALESCE(EXTRACT(EPOCH FROM now() - ` + waitStart + `), 0)::float8
+		FROM pg_locks wl
+		JOIN pg_stat_activity a ON a.pid = wl.pid
+		CROSS JOIN LATERAL unnest(pg_blocking_pids(wl.pid)) AS b(pid)
+		WHERE NOT wl.granted
+		ORDER BY wl.pid, b.pid
+	`
+	rows, err := db.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	waits := []LockWait{}
+	for rows.Next() {
+		var w LockWait
+		if err := rows.Scan(&w.WaitingID, &w.BlockingID, &w.LockMode, &w.HeldMode, &w.LockType, &w.Object, &w.LockData, &w.WaitTime); err != nil {
+			return nil, err
+		}
+		waits = append(waits, w)
+	}
+	return waits, rows.Err()
+}
+
+// GetLockWaits SQLite 没有可查询的锁等待信息
+func (a *SQLiteAdapter) GetLockWaits() ([]LockWait, error) {
+	return []LockWait{}, nil
+}
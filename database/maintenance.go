@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 表维护操作
+const (
+	MaintAnalyze        = "analyze"
+	MaintOptimize       = "optimize"
+	MaintCheck          = "check"
+	MaintRepair         = "repair"
+	MaintVacuum         = "vacuum"
+	MaintVacuumFull     = "vacuum_full"
+	MaintVacuumAnalyze  = "vacuum_analyze"
+	MaintReindex        = "reindex"
+	MaintCluster        = "cluster"
+	MaintIntegrityCheck = "integrity_check"
+	MaintQuickCheck     = "quick_check"
+)
+
+// 维护结果的状态，与 MySQL 返回的 Msg_type 一致
+const (
+	MaintStatusOK    = "status"
+	MaintStatusInfo  = "info"
+	MaintStatusError = "error"
+)
+
+// maintenanceOperations 各数据库支持的维护操作
+var maintenanceOperations = map[string][]string{
+	"mysql":    {MaintAnalyze, MaintOptimize, MaintCheck, MaintRepair},
+	"postgres": {MaintVacuum, MaintVacuumFull, MaintVacuumAnalyze, MaintAnalyze, MaintReindex, MaintCluster},
+	"sqlite":   {MaintVacuum, MaintAnalyze, MaintIntegrityCheck, MaintQuickCheck},
+}
+
+// MaintenanceResult 维护操作的一条输出。Table 为空表示作用于整个数据库，Duration 为耗时（毫秒）
+type MaintenanceResult struct {
+	Table     string  `json:"Table"`
+	Operation string  `json:"Operation"`
+	Status    string  `json:"Status"`
+	Message   string  `json:"Message"`
+	Duration  float64 `json:"Duration"`
+}
+
+// MaintenanceProgress 维护进度，Done 为已完成的表数
+type MaintenanceProgress struct {
+	Done    int    `json:"Done"`
+	Total   int    `json:"Total"`
+	Current string `json:"Current"`
+	Errors  int    `json:"Errors"`
+}
+
+// MaintenanceOperations 返回数据库支持的维护操作
+func MaintenanceOperations(dbType string) []string {
+	ops := maintenanceOperations[dbType]
+	if ops == nil {
+		return []string{}
+	}
+	return ops
+}
+
+// checkMaintenanceOperation 检查操作是否受支持
+func checkMaintenanceOperation(dbType, operation string) error {
+	for _, op := range maintenanceOperations[dbType] {
+		if op == operation {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s does not support maintenance operation %q", dbType, operation)
+}
+
+// RunMaintenance 依次对表执行维护操作，tables 为空时作用于整个数据库（MySQL 为库中的所有表）。
+// 单张表失败时记录为 error 状态的结果并继续，只有取消时返回错误
+func RunMaintenance(ctx context.Context, adapter DBAdapter, dbType, dbName, schema string, tables []string, operation string, progress func(MaintenanceProgress)) ([]MaintenanceResult, error) {
+	if err := checkMaintenanceOperation(dbType, operation); err != nil {
+		return nil, err
+	}
+	targets := tables
+	switch {
+	case dbType == "sqlite" && operation == MaintVacuum:
+		// SQLite 只能清理整个数据库
+		targets = []string{""}
+	case len(targets) == 0 && dbType == "mysql":
+		list, err := adapter.GetTables(dbName, dbName)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range list {
+			targets = append(targets, t.Name)
+		}
+	case len(targets) == 0:
+		targets = []string{""}
+	}
+
+	results := []MaintenanceResult{}
+	failed := 0
+	for i, table := range targets {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if progress != nil {
+			progress(MaintenanceProgress{Done: i, Total: len(targets), Current: table, Errors: failed})
+		}
+
+		started := time.Now()
+		list, err := adapter.RunMaintenance(ctx, dbName, schema, table, operation)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			list = []MaintenanceResult{{Table: table, Operation: operation, Status: MaintStatusError, Message: err.Error()}}
+		}
+		elapsed := float64(time.Since(started).Microseconds()) / 1000
+		for k := range list {
+			list[k].Duration = elapsed
+			if list[k].Status == MaintStatusError {
+				failed++
+			}
+		}
+		results = append(results, list...)
+	}
+	if progress != nil {
+		progress(MaintenanceProgress{Done: len(targets), Total: len(targets), Errors: failed})
+	}
+	return results, nil
+}
+
+// RunMaintenance 执行 ANALYZE/OPTIMIZE/CHECK/REPAIR TABLE，返回服务器输出的每一行。
+// MySQL 不支持对整个数据库执行，table 不能为空；取消只会断开连接，服务器上的操作可能继续执行
+func (a *MySQLAdapter) RunMaintenance(ctx context.Context, dbName, schema, table, operation string) ([]MaintenanceResult, error) {
+	if err := checkMaintenanceOperation("mysql", operation); err != nil {
+		return nil, err
+	}
+	if table == "" {
+		return nil, fmt.Errorf("table is required")
+	}
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := strings.ToUpper(operation) + " TABLE " + DialectOf("mysql").TableName(dbName, "", table)
+	rows, err := db.QueryxContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []MaintenanceResult{}
+	for rows.Next() {
+		var name, op, msgType, msgText string
+		if err := rows.Scan(&name, &op, &msgType, &msgText); err != nil {
+			return nil, err
+		}
+		status := strings.ToLower(msgType)
+		if status == "warning" || status == "note" {
+			status = MaintStatusInfo
+		}
+		results = append(results, MaintenanceResult{Table: table, Operation: operation, Status: status, Message: msgText})
+	}
+	return results, rows.Err()
+}
+
+// RunMaintenance 执行 VACUUM、ANALYZE、REINDEX 或 CLUSTER，table 为空时作用于当前数据库。
+// 这些语句不能在事务中执行，结果只有成功或失败
+func (a *PostgresAdapter) RunMaintenance(ctx context.Context, dbName, schema, table, operation string) ([]MaintenanceResult, error) {
+	if err := checkMaintenanceOperation("postgres", operation); err != nil {
+		return nil, err
+	}
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectOf("postgres")
+	target := ""
+	if table != "" {
+		if schema == "" {
+			schema = "public"
+		}
+		target = " " + d.TableName("", schema, table)
+	}
+	var stmt string
+	switch operation {
+	case MaintVacuum:
+		stmt = "VACUUM" + target
+	case MaintVacuumFull:
+		stmt = "VACUUM (FULL)" + target
+	case MaintVacuumAnalyze:
+		stmt = "VACUUM (ANALYZE)" + target
+	case MaintAnalyze:
+		stmt = "ANALYZE" + target
+	case MaintReindex:
+		if target == "" {
+			// REINDEX DATABASE 只能作用于当前连接的数据库
+			var current string
+			if err := db.GetContext(ctx, &current, "SELECT current_database()"); err != nil {
+				return nil, err
+			}
+			stmt = "REINDEX DATABASE " + d.QuoteIdent(current)
+		} else {
+			stmt = "REINDEX TABLE" + target
+		}
+	case MaintCluster:
+		stmt = "CLUSTER" + target
+	}
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return nil, err
+	}
+	return []MaintenanceResult{{Table: table, Operation: operation, Status: MaintStatusOK, Message: "OK"}}, nil
+}
+
+// RunMaintenance 执行 VACUUM、ANALYZE 或 PRAGMA integrity_check/quick_check，schema 为附加数据库名，为空时为 main。
+// VACUUM 总是作用于整个数据库文件；检查结果中的每个问题作为一条 error 结果返回
+func (a *SQLiteAdapter) RunMaintenance(ctx context.Context, dbName, schema, table, operation string) ([]MaintenanceResult, error) {
+	if err := checkMaintenanceOperation("sqlite", operation); err != nil {
+		return nil, err
+	}
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	d := DialectOf("sqlite")
+	prefix := sqliteSchemaPrefix(schema)
+	switch operation {
+	case MaintVacuum:
+		if _, err := db.ExecContext(ctx, "VACUUM "+d.QuoteIdent(sqliteSchemaOrMain(schema))); err != nil {
+			return nil, err
+		}
+		return []MaintenanceResult{{Operation: operation, Status: MaintStatusOK, Message: "OK"}}, nil
+	case MaintAnalyze:
+		stmt := "ANALYZE"
+		if table != "" {
+			stmt += " " + prefix + d.QuoteIdent(table)
+		} else if schema != "" {
+			stmt += " " + d.QuoteIdent(schema)
+		}
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, err
+		}
+		return []MaintenanceResult{{Table: table, Operation: operation, Status: MaintStatusOK, Message: "OK"}}, nil
+	}
+
+	stmt := "PRAGMA " + prefix + operation
+	if table != "" {
+		stmt += "(" + d.QuoteIdent(table) + ")"
+	}
+	var messages []string
+	if err := db.SelectContext(ctx, &messages, stmt); err != nil {
+		return nil, err
+	}
+	results := []MaintenanceResult{}
+	for _, msg := range messages {
+		status := MaintStatusError
+		if msg == "ok" {
+			status = MaintStatusOK
+		}
+		results = append(results, MaintenanceResult{Table: table, Operation: operation, Status: status, Message: msg})
+	}
+	return results, nil
+}
@@ -0,0 +1,342 @@
+package database
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// 元数据加载状态
+const (
+	MetadataEmpty   = "empty"
+	MetadataLoading = "loading"
+	MetadataReady   = "ready"
+	MetadataError   = "error"
+)
+
+// backgroundColumnLimit 后台预加载列信息的表数量上限
+const backgroundColumnLimit = 500
+
+// TableMeta 缓存中的表信息
+type TableMeta struct {
+	Schema  string       `json:"Schema"`
+	Name    string       `json:"Name"`
+	Comment string       `json:"Comment"`
+	Columns []ColumnInfo `json:"Columns"`
+	loaded  bool
+}
+
+// MetadataSnapshot 某个连接下某个数据库的元数据快照
+type MetadataSnapshot struct {
+	Type     string        `json:"Type"`
+	State    string        `json:"State"`
+	Error    string        `json:"Error"`
+	LoadedAt int64         `json:"LoadedAt"`
+	Schemas  []string      `json:"Schemas"`
+	Tables   []TableMeta   `json:"Tables"`
+	Routines []RoutineInfo `json:"Routines"`
+	Keywords []string      `json:"Keywords"`
+}
+
+// metadataEntry 单个连接+数据库的缓存项
+type metadataEntry struct {
+	mu       sync.RWMutex
+	config   DatabaseConfig
+	dbName   string
+	state    string
+	err      string
+	loadedAt time.Time
+	schemas  []string
+	tables   []*TableMeta
+	routines []RoutineInfo
+	// generation 每次刷新递增，用于丢弃过期的后台加载结果
+	generation int
+}
+
+// MetadataCache 按连接缓存数据库元数据，供自动补全使用
+type MetadataCache struct {
+	mu      sync.Mutex
+	factory *DBFactory
+	entries map[string]*metadataEntry
+	// OnUpdate 后台加载完成后回调，参数为连接标识和数据库名
+	OnUpdate func(profile, dbName string)
+}
+
+// NewMetadataCache 创建元数据缓存
+func NewMetadataCache() *MetadataCache {
+	return &MetadataCache{
+		factory: NewDBFactory(),
+		entries: make(map[string]*metadataEntry),
+	}
+}
+
+func metadataKey(config DatabaseConfig, dbName string) string {
+	return config.ProfileKey() + "\x00" + dbName
+}
+
+// entry 获取缓存项，首次访问时在后台开始加载
+func (c *MetadataCache) entry(config DatabaseConfig, dbName string) *metadataEntry {
+	c.mu.Lock()
+	key := metadataKey(config, dbName)
+	e, ok := c.entries[key]
+	if !ok {
+		e = &metadataEntry{config: config, dbName: dbName, state: MetadataEmpty}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	if e.state == MetadataEmpty {
+		c.startLoad(e)
+	}
+	e.mu.Unlock()
+	return e
+}
+
+// startLoad 在后台加载元数据，调用方需持有 e.mu
+func (c *MetadataCache) startLoad(e *metadataEntry) {
+	e.state = MetadataLoading
+	e.generation++
+	go c.load(e, e.generation)
+}
+
+// Refresh 丢弃缓存并在后台重新加载
+func (c *MetadataCache) Refresh(config DatabaseConfig, dbName string) {
+	e := c.entry(config, dbName)
+	e.mu.Lock()
+	if e.state != MetadataLoading {
+		c.startLoad(e)
+	}
+	e.mu.Unlock()
+}
+
+// Invalidate 使某个连接的全部缓存失效，下次访问时重新加载
+func (c *MetadataCache) Invalidate(config DatabaseConfig) {
+	prefix := config.ProfileKey() + "\x00"
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// load 加载 schema、表和函数列表，然后预加载列信息
+func (c *MetadataCache) load(e *metadataEntry, generation int) {
+	schemas, tables, routines, err := c.loadObjects(e.config, e.dbName)
+
+	e.mu.Lock()
+	if e.generation != generation {
+		e.mu.Unlock()
+		return
+	}
+	if err != nil {
+		e.state = MetadataError
+		e.err = err.Error()
+	} else {
+		e.state = MetadataReady
+		e.err = ""
+		e.schemas = schemas
+		e.tables = tables
+		e.routines = routines
+	}
+	e.loadedAt = time.Now()
+	e.mu.Unlock()
+	c.notify(e)
+
+	if err == nil {
+		c.preloadColumns(e, generation)
+	}
+}
+
+func (c *MetadataCache) loadObjects(config DatabaseConfig, dbName string) ([]string, []*TableMeta, []RoutineInfo, error) {
+	adapter, err := c.factory.CreateAdapter(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer adapter.Close()
+	if err := adapter.Connect(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var schemas []string
+	switch config.Type {
+	case "postgres":
+		list, err := adapter.GetSchemas(dbName)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, s := range list {
+			schemas = append(schemas, s.Name)
+		}
+	case "sqlite":
+		schemas = []string{"main"}
+	default:
+		schemas = []string{dbName}
+	}
+
+	var tables []*TableMeta
+	var routines []RoutineInfo
+	for _, schema := range schemas {
+		list, err := adapter.GetTables(dbName, schema)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, t := range list {
+			tables = append(tables, &TableMeta{Schema: schema, Name: t.Name, Comment: t.Comment})
+		}
+		list2, err := adapter.GetRoutines(dbName, schema)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		routines = append(routines, list2...)
+	}
+	return schemas, tables, routines, nil
+}
+
+// preloadColumns 在后台逐表加载列信息
+func (c *MetadataCache) preloadColumns(e *metadataEntry, generation int) {
+	e.mu.RLock()
+	var pending []*TableMeta
+	for _, t := range e.tables {
+		if !t.loaded && len(pending) < backgroundColumnLimit {
+			pending = append(pending, t)
+		}
+	}
+	e.mu.RUnlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := c.loadColumns(e, generation, pending); err == nil {
+		c.notify(e)
+	}
+}
+
+// loadColumns 加载指定表的列信息
+func (c *MetadataCache) loadColumns(e *metadataEntry, generation int, tables []*TableMeta) error {
+	adapter, err := c.factory.CreateAdapter(e.config)
+	if err != nil {
+		return err
+	}
+	defer adapter.Close()
+	if err := adapter.Connect(); err != nil {
+		return err
+	}
+
+	for _, t := range tables {
+		columns, err := SchemaTableColumns(adapter, e.dbName, t.Schema, t.Name)
+		if err != nil {
+			return err
+		}
+		e.mu.Lock()
+		if e.generation != generation {
+			e.mu.Unlock()
+			return nil
+		}
+		t.Columns = columns
+		t.loaded = true
+		e.mu.Unlock()
+	}
+	return nil
+}
+
+func (c *MetadataCache) notify(e *metadataEntry) {
+	if c.OnUpdate != nil {
+		c.OnUpdate(e.config.ProfileKey(), e.dbName)
+	}
+}
+
+// ensureColumns 确保指定表的列信息已加载，未加载的同步加载
+func (c *MetadataCache) ensureColumns(e *metadataEntry, tables []*TableMeta) {
+	e.mu.RLock()
+	generation := e.generation
+	var pending []*TableMeta
+	for _, t := range tables {
+		if !t.loaded {
+			pending = append(pending, t)
+		}
+	}
+	e.mu.RUnlock()
+	if len(pending) > 0 {
+		c.loadColumns(e, generation, pending)
+	}
+}
+
+// Snapshot 获取当前缓存内容，不等待加载完成
+func (c *MetadataCache) Snapshot(config DatabaseConfig, dbName string) MetadataSnapshot {
+	e := c.entry(config, dbName)
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.snapshot()
+}
+
+// snapshot 复制缓存内容，调用方需持有读锁
+func (e *metadataEntry) snapshot() MetadataSnapshot {
+	snap := MetadataSnapshot{
+		Type:     e.config.Type,
+		State:    e.state,
+		Error:    e.err,
+		Schemas:  append([]string{}, e.schemas...),
+		Tables:   make([]TableMeta, 0, len(e.tables)),
+		Routines: append([]RoutineInfo{}, e.routines...),
+		Keywords: Keywords(e.config.Type),
+	}
+	if !e.loadedAt.IsZero() {
+		snap.LoadedAt = e.loadedAt.UnixMilli()
+	}
+	for _, t := range e.tables {
+		snap.Tables = append(snap.Tables, TableMeta{
+			Schema:  t.Schema,
+			Name:    t.Name,
+			Comment: t.Comment,
+			Columns: append([]ColumnInfo{}, t.Columns...),
+			loaded:  t.loaded,
+		})
+	}
+	return snap
+}
+
+// IsDML 判断SQL是否为单条不返回结果集的 INSERT、UPDATE、DELETE 或 REPLACE 语句，这类语句需要通过 Exec 执行才能得到影响的行数
+func IsDML(dialect Dialect, sql string) bool {
+	first, ended, dml := true, false, false
+	for _, tok := range Tokenize(dialect, sql) {
+		switch tok.Type {
+		case TokenWhitespace, TokenComment:
+			continue
+		}
+		if ended {
+			return false
+		}
+		if tok.Type == TokenPunct && tok.Text == ";" {
+			ended = true
+			continue
+		}
+		if first {
+			first = false
+			dml = tok.IsKeyword("INSERT", "UPDATE", "DELETE", "REPLACE")
+			continue
+		}
+		if tok.IsKeyword("RETURNING") {
+			return false
+		}
+	}
+	return dml
+}
+
+// IsDDL 判断SQL中是否包含会改变表结构的语句
+func IsDDL(dialect Dialect, sql string) bool {
+	expectStatement := true
+	for _, tok := range Tokenize(dialect, sql) {
+		switch tok.Type {
+		case TokenWhitespace, TokenComment:
+			continue
+		}
+		if expectStatement && tok.IsKeyword("CREATE", "ALTER", "DROP", "RENAME") {
+			return true
+		}
+		expectStatement = tok.Type == TokenPunct && tok.Text == ";"
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"database/sql"
@@ -115,8 +116,10 @@ func (a *MySQLAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo,
 	query := `
 		SELECT 
 			COLUMN_NAME,
-			DATA_TYPE,
+			COLUMN_TYPE,
 			CHARACTER_MAXIMUM_LENGTH,
+			IF(DATA_TYPE IN ('decimal', 'numeric'), NUMERIC_PRECISION, NULL),
+			IF(DATA_TYPE IN ('decimal', 'numeric'), NUMERIC_SCALE, NULL),
 			IS_NULLABLE,
 			COLUMN_KEY
 		FROM INFORMATION_SCHEMA.COLUMNS 
@@ -133,15 +136,17 @@ func (a *MySQLAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo,
 	var columns []ColumnInfo
 	for rows.Next() {
 		var col ColumnInfo
-		var length sql.NullInt64
+		var length, precision, scale sql.NullInt64
 		var nullable, key string
 
-		err := rows.Scan(&col.Name, &col.Type, &length, &nullable, &key)
+		err := rows.Scan(&col.Name, &col.Type, &length, &precision, &scale, &nullable, &key)
 		if err != nil {
 			return nil, err
 		}
 
 		col.Length = int(length.Int64)
+		col.Precision = int(precision.Int64)
+		col.Scale = int(scale.Int64)
 		col.Nullable = nullable == "YES"
 		col.IsPrimary = key == "PRI"
 
@@ -151,23 +156,73 @@ func (a *MySQLAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo,
 	return columns, nil
 }
 
-// GetTableRowCount 获取表行数
-func (a *MySQLAdapter) GetTableRowCount(dbName, tableName string) (int64, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s`", dbName, tableName)
+// GetRoutines 获取存储过程和函数
+func (a *MySQLAdapter) GetRoutines(dbName, schema string) ([]RoutineInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ROUTINE_NAME, ROUTINE_TYPE
+		FROM INFORMATION_SCHEMA.ROUTINES
+		WHERE ROUTINE_SCHEMA = ?
+		ORDER BY ROUTINE_NAME
+	`
+	rows, err := db.Queryx(query, dbName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routines []RoutineInfo
+	for rows.Next() {
+		var routine RoutineInfo
+		if err := rows.Scan(&routine.Name, &routine.Type); err != nil {
+			return nil, err
+		}
+		routines = append(routines, routine)
+	}
+	return routines, nil
+}
+
+// GetTableDDL 获取建表语句
+func (a *MySQLAdapter) GetTableDDL(dbName, tableName string) (string, error) {
+	db, err := a.DB()
+	if err != nil {
+		return "", err
+	}
+
+	d := DialectOf("mysql")
+	var name, ddl string
+	query := "SHOW CREATE TABLE " + d.TableName(dbName, "", tableName)
+	if err := db.QueryRowx(query).Scan(&name, &ddl); err != nil {
+		return "", err
+	}
+	return ddl + ";", nil
+}
+
+// GetTableRowCount 获取表行数，where 为过滤条件
+func (a *MySQLAdapter) GetTableRowCount(dbName, tableName, where string) (int64, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s`%s", dbName, tableName, WhereClause(where))
 	var count int64
 	err := a.db.Get(&count, query)
 	return count, err
 }
 
-// QueryTableData 查询表数据
-func (a *MySQLAdapter) QueryTableData(dbName, tableName string, offset, limit int) ([]map[string]string, error) {
-	query := fmt.Sprintf("SELECT * FROM `%s`.`%s` LIMIT ? OFFSET ?", dbName, tableName)
+// QueryTableData 查询表数据，where 为过滤条件
+func (a *MySQLAdapter) QueryTableData(dbName, tableName, where string, offset, limit int) ([]map[string]string, error) {
+	query := fmt.Sprintf("SELECT * FROM `%s`.`%s`%s LIMIT ? OFFSET ?", dbName, tableName, WhereClause(where))
 	rows, err := a.db.Queryx(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	types, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
 	var result []map[string]string
 	for rows.Next() {
 		row := make(map[string]interface{})
@@ -184,7 +239,7 @@ func (a *MySQLAdapter) QueryTableData(dbName, tableName string, offset, limit in
 			} else {
 				switch v := v.(type) {
 				case []byte:
-					strRow[k] = string(v)
+					strRow[k] = BytesText(types[k], v)
 				default:
 					strRow[k] = fmt.Sprintf("%v", v)
 				}
@@ -306,6 +361,10 @@ func (a *MySQLAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string, er
 			}
 			defer rows.Close()
 
+			types, err := columnTypeNames(rows)
+			if err != nil {
+				return nil, err
+			}
 			result = []map[string]string{} // 清空之前的结果
 			for rows.Next() {
 				row := make(map[string]interface{})
@@ -321,7 +380,7 @@ func (a *MySQLAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string, er
 					} else {
 						switch v := v.(type) {
 						case []byte:
-							strRow[k] = string(v)
+							strRow[k] = BytesText(types[k], v)
 						default:
 							strRow[k] = fmt.Sprintf("%v", v)
 						}
@@ -340,4 +399,145 @@ func (a *MySQLAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string, er
 	return result, nil
 }
 
+// Conn 获取一个切换到指定数据库的连接，USE 只对当前连接生效
+func (a *MySQLAdapter) Conn(ctx context.Context, dbName string) (*sqlx.Conn, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dbName != "" {
+		if _, err := conn.ExecContext(ctx, "USE "+DialectOf("mysql").QuoteIdent(dbName)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// ExecuteQueryArgs 使用驱动参数执行单条SQL
+func (a *MySQLAdapter) ExecuteQueryArgs(dbName, query string, args []interface{}) ([]map[string]string, error) {
+	ctx := context.Background()
+	conn, err := a.Conn(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStringRows(rows)
+}
+
+// StreamQuery 流式执行查询，逐行写入 sink
+func (a *MySQLAdapter) StreamQuery(ctx context.Context, dbName, query string, args []interface{}, sink RowSink) error {
+	conn, err := a.Conn(ctx, dbName)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return streamRows(rows, sink)
+}
+
+// GetIndexes 获取库中所有表的索引
+func (a *MySQLAdapter) GetIndexes(dbName, schema string) ([]IndexInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COALESCE(COLUMN_NAME, '')
+		FROM INFORMATION_SCHEMA.STATISTICS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
+	`
+	rows, err := db.Queryx(query, dbName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var table, name, column string
+		var nonUnique int
+		if err := rows.Scan(&table, &name, &nonUnique, &column); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Table == table && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		primary := name == "PRIMARY"
+		indexes = append(indexes, IndexInfo{
+			Table:      table,
+			Name:       name,
+			Columns:    []string{column},
+			Unique:     nonUnique == 0,
+			Primary:    primary,
+			Constraint: primary,
+		})
+	}
+	return indexes, nil
+}
+
+// GetForeignKeys 获取库中所有表的外键
+func (a *MySQLAdapter) GetForeignKeys(dbName, schema string) ([]ForeignKeyInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT k.TABLE_NAME, k.CONSTRAINT_NAME, k.COLUMN_NAME,
+			k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME, k.REFERENCED_COLUMN_NAME,
+			r.DELETE_RULE, r.UPDATE_RULE
+		FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE k
+		JOIN INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+			AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.TABLE_SCHEMA = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.TABLE_NAME, k.CONSTRAINT_NAME, k.ORDINAL_POSITION
+	`
+	rows, err := db.Queryx(query, dbName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKeyInfo
+	for rows.Next() {
+		var fk ForeignKeyInfo
+		var column, refColumn string
+		if err := rows.Scan(&fk.Table, &fk.Name, &column, &fk.RefSchema, &fk.RefTable, &refColumn, &fk.OnDelete, &fk.OnUpdate); err != nil {
+			return nil, err
+		}
+		if n := len(keys); n > 0 && keys[n-1].Table == fk.Table && keys[n-1].Name == fk.Name {
+			keys[n-1].Columns = append(keys[n-1].Columns, column)
+			keys[n-1].RefColumns = append(keys[n-1].RefColumns, refColumn)
+			continue
+		}
+		fk.Columns = []string{column}
+		fk.RefColumns = []string{refColumn}
+		keys = append(keys, fk)
+	}
+	return keys, nil
+}
+
 // ... 其他方法类似修改
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// namedParam SQL中的命名参数位置
+type namedParam struct {
+	name       string
+	start, end int
+}
+
+// scanNamedParams 扫描 :name 与 ${name} 形式的参数，跳过字符串、引用标识符和注释。
+// # 只在 MySQL 中是注释，PostgreSQL 还会跳过 $tag$ ... $tag$ 字符串
+func scanNamedParams(dialect Dialect, sql string) []namedParam {
+	var params []namedParam
+	n := len(sql)
+	for i := 0; i < n; i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(dialect, sql, i, c)
+		case c == '-' && i+1 < n && sql[i+1] == '-', c == '#' && dialect.Name == "mysql":
+			for i < n && sql[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < n && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return params
+			}
+			i += end + 3
+		case c == '$' && i+1 < n && sql[i+1] == '{':
+			end := strings.IndexByte(sql[i:], '}')
+			if end < 0 {
+				continue
+			}
+			name := strings.TrimSpace(sql[i+2 : i+end])
+			if isIdent(name) {
+				params = append(params, namedParam{name: name, start: i, end: i + end + 1})
+			}
+			i += end
+		case c == '$' && dialect.Name == "postgres" && (i == 0 || !isIdentChar(sql[i-1])):
+			// $1 这样的参数和标识符中的 $ 不是字符串开头
+			tagEnd := strings.IndexByte(sql[i+1:], '$')
+			if tagEnd < 0 || tagEnd > 0 && !isIdent(sql[i+1:i+1+tagEnd]) {
+				continue
+			}
+			tag := sql[i : i+tagEnd+2]
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				return params
+			}
+			i += len(tag) + end + len(tag) - 1
+		case c == ':':
+			// 跳过 PostgreSQL 的 :: 类型转换和 MySQL 的 := 赋值
+			if i+1 < n && (sql[i+1] == ':' || sql[i+1] == '=') {
+				i++
+				continue
+			}
+			if i > 0 && sql[i-1] == ':' {
+				continue
+			}
+			j := i + 1
+			for j < n && isIdentChar(sql[j]) {
+				j++
+			}
+			if name := sql[i+1 : j]; isIdent(name) {
+				params = append(params, namedParam{name: name, start: i, end: j})
+				i = j - 1
+			}
+		}
+	}
+	return params
+}
+
+// skipQuoted 跳过引号内容，返回结束引号的位置。反斜杠只在 MySQL 的字符串和 PostgreSQL 的 E'...' 字符串中是转义符，
+// 其他字符串及引用标识符中只用重复引号转义
+func skipQuoted(dialect Dialect, sql string, start int, quote byte) int {
+	escape := dialect.Name == "mysql" && quote != '`' ||
+		dialect.Name == "postgres" && quote == '\'' && start > 0 && (sql[start-1] == 'E' || sql[start-1] == 'e') &&
+			(start == 1 || !isIdentChar(sql[start-2]))
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			if escape {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(sql)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isIdent(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParamNames 返回SQL模板中的参数名，按首次出现顺序去重
+func ParamNames(dialect Dialect, sql string) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, p := range scanNamedParams(dialect, sql) {
+		if !seen[p.name] {
+			seen[p.name] = true
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
+
+// BindParams 将命名参数替换为驱动占位符，并按顺序返回参数值
+func BindParams(dialect Dialect, sql string, values map[string]interface{}) (string, []interface{}, error) {
+	params := scanNamedParams(dialect, sql)
+	if len(params) == 0 {
+		return sql, nil, nil
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+	// PostgreSQL 的 $n 可以重复引用同一个参数
+	index := make(map[string]int)
+	last := 0
+	for _, p := range params {
+		value, ok := values[p.name]
+		if !ok {
+			return "", nil, fmt.Errorf("missing value for parameter %q", p.name)
+		}
+		sb.WriteString(sql[last:p.start])
+		if dialect.Name == "postgres" {
+			n, ok := index[p.name]
+			if !ok {
+				args = append(args, value)
+				n = len(args)
+				index[p.name] = n
+			}
+			sb.WriteString(dialect.Placeholder(n))
+		} else {
+			args = append(args, value)
+			sb.WriteString(dialect.Placeholder(len(args)))
+		}
+		last = p.end
+	}
+	sb.WriteString(sql[last:])
+	return sb.String(), args, nil
+}
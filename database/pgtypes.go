@@ -0,0 +1,263 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgreSQL 自定义类型的种类
+const (
+	PGTypeEnum      = "enum"
+	PGTypeDomain    = "domain"
+	PGTypeComposite = "composite"
+)
+
+// PGExtension PostgreSQL 扩展。未安装的扩展 Version 和 Schema 为空，DDL 为安装语句
+type PGExtension struct {
+	Name           string `json:"Name"`
+	Schema         string `json:"Schema"`
+	Version        string `json:"Version"`
+	DefaultVersion string `json:"DefaultVersion"`
+	Installed      bool   `json:"Installed"`
+	Comment        string `json:"Comment"`
+	DDL            string `json:"DDL"`
+}
+
+// PGType PostgreSQL 自定义类型。Labels 只用于枚举；BaseType、NotNull、Default、Constraints 只用于域；
+// Attributes 只用于复合类型，其中只有 Name 和 Type 有值
+type PGType struct {
+	Schema      string       `json:"Schema"`
+	Name        string       `json:"Name"`
+	Kind        string       `json:"Kind"`
+	Labels      []string     `json:"Labels"`
+	BaseType    string       `json:"BaseType"`
+	NotNull     bool         `json:"NotNull"`
+	Default     string       `json:"Default"`
+	Constraints []string     `json:"Constraints"`
+	Attributes  []ColumnInfo `json:"Attributes"`
+	Comment     string       `json:"Comment"`
+	DDL         string       `json:"DDL"`
+}
+
+// pgShortTypes format_type 返回的 SQL 标准类型名对应的常用写法
+var pgShortTypes = map[string]string{
+	"character varying":           "varchar",
+	"character":                   "char",
+	"bit varying":                 "varbit",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+	"time without time zone":      "time",
+	"time with time zone":         "timetz",
+}
+
+// pgTypeName 将 format_type 的结果转换为常用写法，保留长度等参数和数组维度，
+// 如 character varying(255)[] 转换为 varchar(255)[]，timestamp(3) with time zone 转换为 timestamptz(3)
+func pgTypeName(t string) string {
+	array := ""
+	if i := strings.IndexByte(t, '['); i >= 0 && !strings.HasPrefix(t, `"`) {
+		t, array = t[:i], t[i:]
+	}
+	name, mod := t, ""
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		if j := strings.IndexByte(t[i:], ')'); j > 0 {
+			name, mod = strings.TrimSpace(t[:i]+t[i+j+1:]), t[i:i+j+1]
+		}
+	}
+	if short, ok := pgShortTypes[name]; ok {
+		return short + mod + array
+	}
+	return t + array
+}
+
+// GetExtensions 获取可用的扩展及其安装状态，已安装的排在前面
+func (a *PostgresAdapter) GetExtensions() ([]PGExtension, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT av.name, COALESCE(n.nspname, ''), COALESCE(e.extversion, ''), COALESCE(av.default_version, ''),
+			e.oid IS NOT NULL, COALESCE(av.comment, '')
+		FROM pg_available_extensions av
+		LEFT JOIN pg_extension e ON e.extname = av.name
+		LEFT JOIN pg_namespace n ON n.oid = e.extnamespace
+		ORDER BY e.oid IS NULL, av.name
+	`
+	rows, err := db.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	d := DialectOf("postgres")
+	extensions := []PGExtension{}
+	for rows.Next() {
+		var e PGExtension
+		if err := rows.Scan(&e.Name, &e.Schema, &e.Version, &e.DefaultVersion, &e.Installed, &e.Comment); err != nil {
+			return nil, err
+		}
+		e.DDL = "CREATE EXTENSION IF NOT EXISTS " + d.QuoteIdent(e.Name)
+		if e.Installed {
+			e.DDL += " WITH SCHEMA " + d.QuoteIdent(e.Schema) + " VERSION " + d.QuoteString(e.Version)
+		}
+		e.DDL += ";"
+		extensions = append(extensions, e)
+	}
+	return extensions, rows.Err()
+}
+
+// GetTypes 获取 schema 中的枚举、域和复合类型，不包括扩展创建的类型，schema 为空时使用 public。
+// 域的基础类型和复合类型的字段类型与列类型一样转换为常用写法，数组保留元素类型，如 mood[]
+func (a *PostgresAdapter) GetTypes(schema string) ([]PGType, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	if schema == "" {
+		schema = "public"
+	}
+	return pgUserTypes(db, []string{schema})
+}
+
+// pgUserTypes 获取多个 schema 中的自定义类型并生成 DDL，按枚举、域、复合类型的顺序排列，
+// 使域可以使用枚举，复合类型可以使用前两者
+func pgUserTypes(db *sqlx.DB, schemas []string) ([]PGType, error) {
+	rows, err := db.Queryx(`
+		SELECT t.oid, n.nspname, t.typname, t.typtype::text,
+			ARRAY(SELECT enumlabel::text FROM pg_enum WHERE enumtypid = t.oid ORDER BY enumsortorder),
+			COALESCE(format_type(t.typbasetype, t.typtypmod), ''), t.typnotnull, COALESCE(t.typdefault, ''),
+			COALESCE((SELECT quote_ident(co.collname) FROM pg_collation co JOIN pg_type bt ON bt.oid = t.typbasetype
+				WHERE co.oid = t.typcollation AND t.typcollation <> bt.typcollation), ''),
+			ARRAY(SELECT 'CONSTRAINT ' || quote_ident(conname) || ' ' || pg_get_constraintdef(oid)
+				FROM pg_constraint WHERE contypid = t.oid ORDER BY conname),
+			COALESCE(obj_description(t.oid, 'pg_type'), '')
+		FROM pg_type t
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		LEFT JOIN pg_class c ON c.oid = t.typrelid
+		WHERE n.nspname = ANY($1)
+			AND (t.typtype IN ('e', 'd') OR (t.typtype = 'c' AND c.relkind = 'c'))
+			AND NOT EXISTS (SELECT 1 FROM pg_depend dep
+				WHERE dep.classid = 'pg_type'::regclass AND dep.objid = t.oid AND dep.deptype = 'e')
+		ORDER BY CASE t.typtype WHEN 'e' THEN 0 WHEN 'd' THEN 1 ELSE 2 END, n.nspname, t.typname
+	`, pq.Array(schemas))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []PGType{}
+	var oids []int64
+	collations := make(map[int]string)
+	for rows.Next() {
+		var t PGType
+		var oid int64
+		var kind, collation string
+		if err := rows.Scan(&oid, &t.Schema, &t.Name, &kind, pq.Array(&t.Labels), &t.BaseType, &t.NotNull,
+			&t.Default, &collation, pq.Array(&t.Constraints), &t.Comment); err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "e":
+			t.Kind = PGTypeEnum
+		case "d":
+			t.Kind = PGTypeDomain
+			t.BaseType = pgTypeName(t.BaseType)
+			collations[len(types)] = collation
+		default:
+			t.Kind = PGTypeComposite
+		}
+		oids = append(oids, oid)
+		types = append(types, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(types) == 0 {
+		return types, nil
+	}
+
+	attrs, err := db.Queryx(`
+		SELECT t.oid, a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_type t
+		JOIN pg_attribute a ON a.attrelid = t.typrelid
+		WHERE t.oid = ANY($1) AND t.typtype = 'c' AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY t.oid, a.attnum
+	`, pq.Array(oids))
+	if err != nil {
+		return nil, err
+	}
+	defer attrs.Close()
+
+	index := make(map[int64]int, len(oids))
+	for i, oid := range oids {
+		index[oid] = i
+	}
+	for attrs.Next() {
+		var oid int64
+		var col ColumnInfo
+		if err := attrs.Scan(&oid, &col.Name, &col.Type); err != nil {
+			return nil, err
+		}
+		col.Type = pgTypeName(col.Type)
+		col.Nullable = true
+		i := index[oid]
+		types[i].Attributes = append(types[i].Attributes, col)
+	}
+	if err := attrs.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range types {
+		types[i].DDL = pgTypeDDL(types[i], collations[i])
+	}
+	return types, nil
+}
+
+// pgTypeDDL 生成建立类型的语句，有注释时附加 COMMENT ON 语句
+func pgTypeDDL(t PGType, collation string) string {
+	d := DialectOf("postgres")
+	qualified := d.QuoteIdent(t.Schema) + "." + d.QuoteIdent(t.Name)
+
+	var sb strings.Builder
+	switch t.Kind {
+	case PGTypeEnum:
+		labels := make([]string, len(t.Labels))
+		for i, l := range t.Labels {
+			labels[i] = d.QuoteString(l)
+		}
+		sb.WriteString("CREATE TYPE " + qualified + " AS ENUM (" + strings.Join(labels, ", ") + ");")
+	case PGTypeDomain:
+		sb.WriteString("CREATE DOMAIN " + qualified + " AS " + t.BaseType)
+		if collation != "" {
+			sb.WriteString(" COLLATE " + collation)
+		}
+		if t.Default != "" {
+			sb.WriteString(" DEFAULT " + t.Default)
+		}
+		if t.NotNull {
+			sb.WriteString(" NOT NULL")
+		}
+		for _, c := range t.Constraints {
+			sb.WriteString("\n  " + c)
+		}
+		sb.WriteString(";")
+	case PGTypeComposite:
+		fields := make([]string, len(t.Attributes))
+		for i, a := range t.Attributes {
+			fields[i] = "  " + d.QuoteIdent(a.Name) + " " + a.Type
+		}
+		sb.WriteString("CREATE TYPE " + qualified + " AS (\n" + strings.Join(fields, ",\n") + "\n);")
+	}
+	if t.Comment != "" {
+		object := "TYPE"
+		if t.Kind == PGTypeDomain {
+			object = "DOMAIN"
+		}
+		sb.WriteString(fmt.Sprintf("\nCOMMENT ON %s %s IS %s;", object, qualified, d.QuoteString(t.Comment)))
+	}
+	return sb.String()
+}
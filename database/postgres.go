@@ -1,11 +1,12 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"database/sql"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // PostgresAdapter PostgreSQL适配器
@@ -142,27 +143,37 @@ func (a *PostgresAdapter) GetTables(dbName, schema string) ([]TableInfo, error)
 
 // GetTableColumns 获取指定表的所有列
 func (a *PostgresAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo, error) {
+	return a.GetSchemaTableColumns("", tableName)
+}
+
+// GetSchemaTableColumns 获取指定 schema 中表的所有列，schema 为空时不限定 schema
+func (a *PostgresAdapter) GetSchemaTableColumns(schema, tableName string) ([]ColumnInfo, error) {
 	query := `
 		SELECT 
-			column_name,
-			data_type,
+			c.column_name,
+			format_type(a.atttypid, a.atttypmod),
 			character_maximum_length,
+			CASE WHEN data_type = 'numeric' THEN numeric_precision END,
+			CASE WHEN data_type = 'numeric' THEN numeric_scale END,
 			is_nullable,
 			CASE WHEN pk.column_name IS NOT NULL THEN true ELSE false END as is_primary
 		FROM information_schema.columns c
 		LEFT JOIN (
-			SELECT ku.column_name
+			SELECT ku.table_schema, ku.column_name
 			FROM information_schema.table_constraints tc
 			JOIN information_schema.key_column_usage ku
-				ON tc.constraint_name = ku.constraint_name
+				ON tc.constraint_schema = ku.constraint_schema AND tc.constraint_name = ku.constraint_name
 			WHERE tc.constraint_type = 'PRIMARY KEY'
 				AND tc.table_name = $1
-		) pk ON c.column_name = pk.column_name
-		WHERE c.table_name = $1
+		) pk ON c.table_schema = pk.table_schema AND c.column_name = pk.column_name
+		JOIN pg_namespace n ON n.nspname = c.table_schema
+		JOIN pg_class cl ON cl.relnamespace = n.oid AND cl.relname = c.table_name
+		JOIN pg_attribute a ON a.attrelid = cl.oid AND a.attname = c.column_name
+		WHERE c.table_name = $1 AND ($2 = '' OR c.table_schema = $2)
 		ORDER BY ordinal_position;
 	`
 
-	rows, err := a.db.Queryx(query, tableName)
+	rows, err := a.db.Queryx(query, tableName, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -171,15 +182,19 @@ func (a *PostgresAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInf
 	var columns []ColumnInfo
 	for rows.Next() {
 		var col ColumnInfo
-		var length sql.NullInt64
+		var length, precision, scale sql.NullInt64
 		var nullable string
 
-		err := rows.Scan(&col.Name, &col.Type, &length, &nullable, &col.IsPrimary)
+		err := rows.Scan(&col.Name, &col.Type, &length, &precision, &scale, &nullable, &col.IsPrimary)
 		if err != nil {
 			return nil, err
 		}
 
+		// data_type 对枚举等自定义类型只返回 USER-DEFINED，对数组返回 ARRAY，使用 format_type 获取实际类型
+		col.Type = pgTypeName(col.Type)
 		col.Length = int(length.Int64)
+		col.Precision = int(precision.Int64)
+		col.Scale = int(scale.Int64)
 		col.Nullable = nullable == "YES"
 
 		columns = append(columns, col)
@@ -188,9 +203,52 @@ func (a *PostgresAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInf
 	return columns, nil
 }
 
-// GetTableRowCount 获取指定表的行数
-func (a *PostgresAdapter) GetTableRowCount(dbName, tableName string) (int64, error) {
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)
+// GetRoutines 获取指定schema的函数和存储过程
+func (a *PostgresAdapter) GetRoutines(dbName, schema string) ([]RoutineInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT DISTINCT routine_name, COALESCE(routine_type, 'FUNCTION')
+		FROM information_schema.routines
+		WHERE routine_schema = $1
+		ORDER BY routine_name
+	`
+	rows, err := db.Queryx(query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var routines []RoutineInfo
+	for rows.Next() {
+		var routine RoutineInfo
+		if err := rows.Scan(&routine.Name, &routine.Type); err != nil {
+			return nil, err
+		}
+		routines = append(routines, routine)
+	}
+	return routines, nil
+}
+
+// GetTableDDL 根据列信息生成建表语句
+func (a *PostgresAdapter) GetTableDDL(dbName, tableName string) (string, error) {
+	columns, err := a.GetTableColumns(dbName, tableName)
+	if err != nil {
+		return "", err
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table %s not found", tableName)
+	}
+	d := DialectOf("postgres")
+	return d.BuildCreateTable(d.QuoteIdent(tableName), columns, false), nil
+}
+
+// GetTableRowCount 获取指定表的行数，where 为过滤条件
+func (a *PostgresAdapter) GetTableRowCount(dbName, tableName, where string) (int64, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", tableName, WhereClause(where))
 
 	var count int64
 	err := a.db.QueryRowx(query).Scan(&count)
@@ -201,10 +259,10 @@ func (a *PostgresAdapter) GetTableRowCount(dbName, tableName string) (int64, err
 	return count, nil
 }
 
-// QueryTableData 查询指定表的数据
-func (a *PostgresAdapter) QueryTableData(dbName, tableName string, offset, limit int) ([]map[string]string, error) {
+// QueryTableData 查询指定表的数据，where 为过滤条件
+func (a *PostgresAdapter) QueryTableData(dbName, tableName, where string, offset, limit int) ([]map[string]string, error) {
 	// 构建查询语句
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT $1 OFFSET $2", tableName)
+	query := fmt.Sprintf("SELECT * FROM %s%s LIMIT $1 OFFSET $2", tableName, WhereClause(where))
 
 	rows, err := a.db.Queryx(query, limit, offset)
 	if err != nil {
@@ -226,6 +284,10 @@ func (a *PostgresAdapter) QueryTableData(dbName, tableName string, offset, limit
 	}
 
 	// 读取数据
+	types, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
 	var result []map[string]string
 	for rows.Next() {
 		err := rows.Scan(valuePtrs...)
@@ -243,7 +305,7 @@ func (a *PostgresAdapter) QueryTableData(dbName, tableName string, offset, limit
 			} else {
 				switch val := val.(type) {
 				case []byte:
-					v = string(val)
+					v = BytesText(types[col], val)
 				default:
 					v = fmt.Sprintf("%v", val)
 				}
@@ -354,6 +416,10 @@ func (a *PostgresAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string,
 	}
 	defer rows.Close()
 
+	types, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
 	var result []map[string]string
 	for rows.Next() {
 		row := make(map[string]interface{})
@@ -370,7 +436,7 @@ func (a *PostgresAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string,
 			} else {
 				switch v := v.(type) {
 				case []byte:
-					strRow[k] = string(v)
+					strRow[k] = BytesText(types[k], v)
 				default:
 					strRow[k] = fmt.Sprintf("%v", v)
 				}
@@ -381,3 +447,146 @@ func (a *PostgresAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string,
 
 	return result, nil
 }
+
+// ExecuteQueryArgs 使用驱动参数执行单条SQL
+func (a *PostgresAdapter) ExecuteQueryArgs(dbName, query string, args []interface{}) ([]map[string]string, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStringRows(rows)
+}
+
+// StreamQuery 流式执行查询，逐行写入 sink
+func (a *PostgresAdapter) StreamQuery(ctx context.Context, dbName, query string, args []interface{}, sink RowSink) error {
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return streamRows(rows, sink)
+}
+
+// Conn 从连接池中获取一个独占连接
+func (a *PostgresAdapter) Conn(ctx context.Context, dbName string) (*sqlx.Conn, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	return db.Connx(ctx)
+}
+
+// GetIndexes 获取指定schema中所有表的索引
+func (a *PostgresAdapter) GetIndexes(dbName, schema string) ([]IndexInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT t.relname, i.relname, ix.indisunique, ix.indisprimary,
+			EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = ix.indexrelid),
+			ARRAY(
+				SELECT pg_get_indexdef(ix.indexrelid, k + 1, true)
+				FROM generate_subscripts(ix.indkey, 1) k
+				WHERE k < ix.indnkeyatts
+				ORDER BY k
+			)::text[]
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1
+		ORDER BY t.relname, i.relname
+	`
+	rows, err := db.Queryx(query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var idx IndexInfo
+		var columns pq.StringArray
+		if err := rows.Scan(&idx.Table, &idx.Name, &idx.Unique, &idx.Primary, &idx.Constraint, &columns); err != nil {
+			return nil, err
+		}
+		idx.Columns = columns
+		indexes = append(indexes, idx)
+	}
+	return indexes, nil
+}
+
+// pgForeignKeyActions pg_constraint 中外键动作代码对应的关键字
+var pgForeignKeyActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// GetForeignKeys 获取指定schema中所有表的外键
+func (a *PostgresAdapter) GetForeignKeys(dbName, schema string) ([]ForeignKeyInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c.relname, con.conname, rn.nspname, rc.relname,
+			ARRAY(
+				SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(num, ord)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.num
+				ORDER BY k.ord
+			)::text[],
+			ARRAY(
+				SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(num, ord)
+				JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.num
+				ORDER BY k.ord
+			)::text[],
+			con.confdeltype::text, con.confupdtype::text
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_class rc ON rc.oid = con.confrelid
+		JOIN pg_namespace rn ON rn.oid = rc.relnamespace
+		WHERE con.contype = 'f' AND n.nspname = $1
+		ORDER BY c.relname, con.conname
+	`
+	rows, err := db.Queryx(query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKeyInfo
+	for rows.Next() {
+		var fk ForeignKeyInfo
+		var columns, refColumns pq.StringArray
+		var onDelete, onUpdate string
+		if err := rows.Scan(&fk.Table, &fk.Name, &fk.RefSchema, &fk.RefTable, &columns, &refColumns, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		fk.Columns = columns
+		fk.RefColumns = refColumns
+		fk.OnDelete = pgForeignKeyActions[onDelete]
+		fk.OnUpdate = pgForeignKeyActions[onUpdate]
+		keys = append(keys, fk)
+	}
+	return keys, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ProcessInfo 服务器上的一个连接（会话）。Time 为当前状态持续的秒数，
+// Current 表示查询进程列表时使用的连接
+type ProcessInfo struct {
+	ID       int64   `json:"ID"`
+	User     string  `json:"User"`
+	Host     string  `json:"Host"`
+	Database string  `json:"Database"`
+	Command  string  `json:"Command"`
+	State    string  `json:"State"`
+	Time     float64 `json:"Time"`
+	SQL      string  `json:"SQL"`
+	Current  bool    `json:"Current"`
+}
+
+// GetServerProcesses 获取服务器上的连接，优先使用 performance_schema.processlist，
+// 旧版本服务器回退到 information_schema.PROCESSLIST
+func (a *MySQLAdapter) GetServerProcesses() ([]ProcessInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ID, USER, COALESCE(HOST, ''), COALESCE(DB, ''), COMMAND, COALESCE(TIME, 0), COALESCE(STATE, ''), COALESCE(INFO, ''),
+			ID = CONNECTION_ID()
+		FROM %s
+		ORDER BY TIME DESC, ID
+	`
+	rows, err := db.Queryx(fmt.Sprintf(query, "performance_schema.processlist"))
+	if err != nil {
+		rows, err = db.Queryx(fmt.Sprintf(query, "information_schema.PROCESSLIST"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	processes := []ProcessInfo{}
+	for rows.Next() {
+		var p ProcessInfo
+		var user sql.NullString
+		if err := rows.Scan(&p.ID, &user, &p.Host, &p.Database, &p.Command, &p.Time, &p.State, &p.SQL, &p.Current); err != nil {
+			return nil, err
+		}
+		p.User = user.String
+		processes = append(processes, p)
+	}
+	return processes, rows.Err()
+}
+
+// KillProcess 结束连接，queryOnly 为 true 时只中止正在执行的语句
+func (a *MySQLAdapter) KillProcess(id int64, queryOnly bool) error {
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+	stmt := "KILL %d"
+	if queryOnly {
+		stmt = "KILL QUERY %d"
+	}
+	_, err = db.Exec(fmt.Sprintf(stmt, id))
+	return err
+}
+
+// GetServerProcesses 从 pg_stat_activity 获取服务器上的连接，包括后台进程
+func (a *PostgresAdapter) GetServerProcesses() ([]ProcessInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT pid, COALESCE(usename, ''),
+			COALESCE(host(client_addr) || ':' || client_port, ''),
+			COALESCE(datname, ''), COALESCE(backend_type, ''),
+			COALESCE(state, '') || COALESCE(' (' || wait_event_type || ': ' || wait_event || ')', ''),
+			COALESCE(EXTRACT(EPOCH FROM now() - CASE WHEN state = 'active' THEN query_start ELSE COALESCE(state_change, backend_start) END), 0)::float8,
+			COALESCE(query, ''), pid = pg_backend_pid()
+		FROM pg_stat_activity
+		ORDER BY state = 'active' DESC, query_start, pid
+	`
+	rows, err := db.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	processes := []ProcessInfo{}
+	for rows.Next() {
+		var p ProcessInfo
+		if err := rows.Scan(&p.ID, &p.User, &p.Host, &p.Database, &p.Command, &p.State, &p.Time, &p.SQL, &p.Current); err != nil {
+			return nil, err
+		}
+		processes = append(processes, p)
+	}
+	return processes, rows.Err()
+}
+
+// KillProcess 结束后端进程，queryOnly 为 true 时使用 pg_cancel_backend 只取消当前查询
+func (a *PostgresAdapter) KillProcess(id int64, queryOnly bool) error {
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+	fn := "pg_terminate_backend"
+	if queryOnly {
+		fn = "pg_cancel_backend"
+	}
+	var ok bool
+	if err := db.Get(&ok, "SELECT "+fn+"($1)", id); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("process %d not found or could not be signalled", id)
+	}
+	return nil
+}
+
+// GetServerProcesses SQLite 没有服务器进程
+func (a *SQLiteAdapter) GetServerProcesses() ([]ProcessInfo, error) {
+	return []ProcessInfo{}, nil
+}
+
+// KillProcess SQLite 不支持结束连接
+func (a *SQLiteAdapter) KillProcess(id int64, queryOnly bool) error {
+	return fmt.Errorf("sqlite does not support killing processes")
+}
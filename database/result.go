@@ -0,0 +1,56 @@
+package database
+
+// ResultSet 带列信息的查询结果。JSON 列的值为 JSON 本身（对象、数组、数值等），
+// 其他值与 ExecuteQuery 的结果一样为显示的文本，NULL 为 null。Affected 为修改语句影响的行数
+type ResultSet struct {
+	Columns  []ResultColumn  `json:"Columns"`
+	Rows     [][]interface{} `json:"Rows"`
+	Affected int64           `json:"Affected"`
+}
+
+// ResultValue 将驱动返回的值转换为 ResultSet 中的值
+func ResultValue(col ResultColumn, v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if col.Kind == KindJSON {
+			if raw, ok := JSONRaw(v); ok {
+				return raw
+			}
+		}
+		return BytesText(col.DatabaseType, v)
+	case string:
+		if col.Kind == KindJSON {
+			if raw, ok := JSONRaw(v); ok {
+				return raw
+			}
+		}
+	}
+	return FormatValue(col.DatabaseType, v)
+}
+
+// resultSink 收集流式查询结果
+type resultSink struct {
+	result *ResultSet
+}
+
+// NewResultSink 创建收集结果到 result 的 RowSink
+func NewResultSink(result *ResultSet) RowSink {
+	return &resultSink{result: result}
+}
+
+func (s *resultSink) Begin(columns []ResultColumn) error {
+	s.result.Columns = columns
+	s.result.Rows = [][]interface{}{}
+	return nil
+}
+
+func (s *resultSink) Row(values []interface{}) error {
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = ResultValue(s.result.Columns[i], v)
+	}
+	s.result.Rows = append(s.result.Rows, row)
+	return nil
+}
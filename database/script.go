@@ -0,0 +1,386 @@
+package database
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxScriptErrors 脚本执行结果中保留的错误条数
+const maxScriptErrors = 100
+
+// ScriptStatement 脚本中的一条语句
+type ScriptStatement struct {
+	SQL string
+	// Line 语句第一个有效字符所在的行，从 1 开始
+	Line int
+	// Offset 语句结束处的字节偏移
+	Offset int64
+}
+
+// ScriptScanner 从输入流中逐条读取 SQL 语句，不会把整个脚本读入内存。
+// 能识别引号、注释、PostgreSQL 的 $tag$ 字符串、SQLite 触发器以及 MySQL 客户端的 DELIMITER 命令
+type ScriptScanner struct {
+	r       *bufio.Reader
+	dialect Dialect
+	delim   string
+	line    int
+	offset  int64
+	buf     strings.Builder
+}
+
+// NewScriptScanner 创建语句扫描器
+func NewScriptScanner(r io.Reader, dbType string) *ScriptScanner {
+	return &ScriptScanner{r: bufio.NewReaderSize(r, 64*1024), dialect: DialectOf(dbType), delim: ";", line: 1}
+}
+
+// Offset 已读取的字节数
+func (s *ScriptScanner) Offset() int64 {
+	return s.offset
+}
+
+// Next 返回下一条语句，脚本结束时返回 io.EOF
+func (s *ScriptScanner) Next() (ScriptStatement, error) {
+	s.buf.Reset()
+	start := 0
+	for {
+		c, err := s.read()
+		if err == io.EOF {
+			if start == 0 {
+				return ScriptStatement{}, io.EOF
+			}
+			return s.statement(start), nil
+		}
+		if err != nil {
+			return ScriptStatement{}, err
+		}
+
+		if unicode.IsSpace(c) {
+			s.buf.WriteRune(c)
+			continue
+		}
+		if start == 0 && s.dialect.Name == "mysql" && (c == 'd' || c == 'D') && s.readDelimiter() {
+			s.buf.Reset()
+			continue
+		}
+
+		switch {
+		case c == '-' && s.peekIs("-"), c == '#' && s.dialect.Name == "mysql":
+			s.buf.WriteRune(c)
+			if err := s.readLine(); err != nil {
+				return ScriptStatement{}, err
+			}
+			continue
+		case c == '/' && s.peekIs("*"):
+			// MySQL 的 /*! ... */ 条件注释需要执行，视为有效内容
+			if start == 0 && s.peekIs("*!") {
+				start = s.line
+			}
+			s.buf.WriteString("/*")
+			s.discard(1)
+			if err := s.readUntil("*/"); err != nil {
+				return ScriptStatement{}, err
+			}
+			continue
+		}
+
+		if strings.HasPrefix(s.delim, string(c)) && s.peekIs(s.delim[len(string(c)):]) {
+			s.discard(len(s.delim) - len(string(c)))
+			if start == 0 {
+				// 空语句或只有注释
+				s.buf.Reset()
+				continue
+			}
+			if s.inTriggerBody() {
+				s.buf.WriteString(s.delim)
+				continue
+			}
+			return s.statement(start), nil
+		}
+		if start == 0 {
+			start = s.line
+		}
+
+		s.buf.WriteRune(c)
+		switch {
+		case c == '\'' || c == '"' || (c == '`' && s.dialect.Name == "mysql"):
+			err = s.readQuoted(c)
+		case c == '$' && s.dialect.Name == "postgres":
+			err = s.readDollarQuoted()
+		}
+		if err != nil {
+			return ScriptStatement{}, err
+		}
+	}
+}
+
+// sqliteTriggerRe 匹配 SQLite 的 CREATE TRIGGER 语句开头
+var sqliteTriggerRe = regexp.MustCompile(`(?is)^(\s*(--[^\n]*\n|/\*.*?\*/))*\s*CREATE\s+(TEMP\s+|TEMPORARY\s+)?TRIGGER\b`)
+
+// inTriggerBody 判断分号是否位于 SQLite 触发器的 BEGIN ... END 之间
+func (s *ScriptScanner) inTriggerBody() bool {
+	if s.dialect.Name != "sqlite" || !sqliteTriggerRe.MatchString(s.buf.String()) {
+		return false
+	}
+	text := strings.ToUpper(strings.TrimSpace(s.buf.String()))
+	return !strings.HasSuffix(text, "END") || (len(text) > 3 && isIdentChar(text[len(text)-4]))
+}
+
+// statement 结束当前语句
+func (s *ScriptScanner) statement(start int) ScriptStatement {
+	return ScriptStatement{SQL: strings.TrimSpace(s.buf.String()), Line: start, Offset: s.offset}
+}
+
+// read 读取一个字符并更新偏移和行号
+func (s *ScriptScanner) read() (rune, error) {
+	c, size, err := s.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	s.offset += int64(size)
+	if c == '\n' {
+		s.line++
+	}
+	return c, nil
+}
+
+// peekIs 判断后续内容是否以 prefix 开头（不区分大小写），不消耗输入
+func (s *ScriptScanner) peekIs(prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	p, _ := s.r.Peek(len(prefix))
+	return strings.EqualFold(string(p), prefix)
+}
+
+// discard 跳过 n 个字节（调用方保证其中不含换行）
+func (s *ScriptScanner) discard(n int) {
+	d, _ := s.r.Discard(n)
+	s.offset += int64(d)
+}
+
+// readLine 读取到行尾（包含换行符）
+func (s *ScriptScanner) readLine() error {
+	text, err := s.r.ReadString('\n')
+	s.offset += int64(len(text))
+	s.buf.WriteString(text)
+	if strings.HasSuffix(text, "\n") {
+		s.line++
+	}
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// readUntil 读取到 end 为止（包含 end），用于块注释和 $tag$ 字符串
+func (s *ScriptScanner) readUntil(end string) error {
+	from := s.buf.Len()
+	for {
+		c, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.buf.WriteRune(c)
+		if s.buf.Len()-from >= len(end) && strings.HasSuffix(s.buf.String(), end) {
+			return nil
+		}
+	}
+}
+
+// readQuoted 读取引号内的内容，支持重复引号转义，MySQL 的字符串还支持反斜杠转义
+func (s *ScriptScanner) readQuoted(quote rune) error {
+	backslash := s.dialect.Name == "mysql" && quote != '`'
+	for {
+		c, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.buf.WriteRune(c)
+		switch {
+		case c == '\\' && backslash:
+			next, err := s.read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			s.buf.WriteRune(next)
+		case c == quote:
+			if !s.peekIs(string(quote)) {
+				return nil
+			}
+			s.discard(1)
+			s.buf.WriteRune(quote)
+		}
+	}
+}
+
+// readDollarQuoted 读取 $tag$ ... $tag$ 字符串；$1 这样的参数不作处理
+func (s *ScriptScanner) readDollarQuoted() error {
+	p, _ := s.r.Peek(64)
+	end := -1
+	for i, b := range p {
+		if b == '$' {
+			end = i
+			break
+		}
+		if !(b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' && i > 0) {
+			return nil
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+	tag := "$" + string(p[:end+1])
+	s.buf.WriteString(tag[1:])
+	s.discard(end + 1)
+	return s.readUntil(tag)
+}
+
+// readDelimiter 识别 MySQL 客户端的 DELIMITER 命令，c 已读取
+func (s *ScriptScanner) readDelimiter() bool {
+	p, _ := s.r.Peek(len("ELIMITER") + 1)
+	if len(p) < len("ELIMITER")+1 || !strings.EqualFold(string(p[:8]), "ELIMITER") || (p[8] != ' ' && p[8] != '\t') {
+		return false
+	}
+	text, _ := s.r.ReadString('\n')
+	s.offset += int64(len(text))
+	if strings.HasSuffix(text, "\n") {
+		s.line++
+	}
+	if delim := strings.TrimSpace(text[8:]); delim != "" {
+		s.delim = delim
+	}
+	return true
+}
+
+// ScriptOptions 脚本文件执行选项
+type ScriptOptions struct {
+	Database string `json:"Database"`
+	Encoding string `json:"Encoding"`
+	// ContinueOnError 为 true 时出错后继续执行后续语句
+	ContinueOnError bool `json:"ContinueOnError"`
+	// StartLine 跳过起始行在此之前的语句，用于从上次失败处继续执行
+	StartLine int `json:"StartLine"`
+}
+
+// ScriptError 执行失败的语句
+type ScriptError struct {
+	Line      int    `json:"Line"`
+	Statement string `json:"Statement"`
+	Error     string `json:"Error"`
+}
+
+// ScriptResult 脚本执行结果
+type ScriptResult struct {
+	Executed int64 `json:"Executed"`
+	Skipped  int64 `json:"Skipped"`
+	Failed   int64 `json:"Failed"`
+	Offset   int64 `json:"Offset"`
+	Size     int64 `json:"Size"`
+	// StopLine 执行中止时所在语句的行号，未中止时为 0
+	StopLine int           `json:"StopLine"`
+	Errors   []ScriptError `json:"Errors"`
+}
+
+// ScriptProgressFunc 脚本执行进度回调
+type ScriptProgressFunc func(result ScriptResult)
+
+// RunScriptFile 逐条执行脚本文件中的语句，所有语句在同一个连接上执行
+func RunScriptFile(ctx context.Context, adapter DBAdapter, dbType, path string, opts ScriptOptions, progress ScriptProgressFunc) (ScriptResult, error) {
+	var result ScriptResult
+	f, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		result.Size = info.Size()
+	}
+
+	// gzip/zstd 压缩的脚本（如备份文件）自动解压，此时进度按压缩文件的读取量计算
+	counter := &countingReader{r: f}
+	plain, compressed, closeReader, err := decompressReader(counter)
+	if err != nil {
+		return result, err
+	}
+	defer closeReader()
+	r, err := decodingReader(plain, opts.Encoding)
+	if err != nil {
+		return result, err
+	}
+	conn, err := adapter.Conn(ctx, opts.Database)
+	if err != nil {
+		return result, err
+	}
+	defer conn.Close()
+
+	scanner := NewScriptScanner(r, dbType)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		stmt, err := scanner.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Offset = scanner.Offset()
+		if compressed {
+			result.Offset = counter.n
+		}
+
+		if stmt.Line < opts.StartLine {
+			result.Skipped++
+		} else if _, err := conn.ExecContext(ctx, stmt.SQL); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Failed++
+			if len(result.Errors) < maxScriptErrors {
+				result.Errors = append(result.Errors, ScriptError{Line: stmt.Line, Statement: truncateStatement(stmt.SQL), Error: err.Error()})
+			}
+			if !opts.ContinueOnError {
+				result.StopLine = stmt.Line
+				if progress != nil {
+					progress(result)
+				}
+				return result, fmt.Errorf("line %d: %v", stmt.Line, err)
+			}
+		} else {
+			result.Executed++
+		}
+		if progress != nil {
+			progress(result)
+		}
+	}
+}
+
+// truncateStatement 截断过长的语句，用于错误信息展示
+func truncateStatement(sql string) string {
+	const max = 200
+	if len(sql) <= max {
+		return sql
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(sql[cut]) {
+		cut--
+	}
+	return sql[:cut] + "..."
+}
@@ -0,0 +1,503 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 搜索的匹配方式
+const (
+	MatchExact    = "exact"
+	MatchContains = "contains"
+	MatchRegex    = "regex"
+)
+
+// 搜索范围，也用作 SearchMatch.Kind
+const (
+	SearchTables   = "table"
+	SearchColumns  = "column"
+	SearchRoutines = "routine"
+	SearchData     = "data"
+)
+
+// 搜索的默认限制
+const (
+	defaultSearchRowLimit    = 100
+	defaultSearchConcurrency = 4
+	maxSearchConcurrency     = 16
+	searchValueLimit         = 256
+)
+
+// SearchOptions 全库搜索选项。Scopes 为空时只搜索对象名（表、列、存储过程）；
+// Schema 为空时搜索所有 schema；Tables 非空时只在这些表中搜索数据。
+// RowLimit 为每张表最多返回的匹配行数，Concurrency 为同时搜索的表数
+type SearchOptions struct {
+	Scopes        []string `json:"Scopes"`
+	Schema        string   `json:"Schema"`
+	Tables        []string `json:"Tables"`
+	MatchMode     string   `json:"MatchMode"`
+	CaseSensitive bool     `json:"CaseSensitive"`
+	RowLimit      int      `json:"RowLimit"`
+	Concurrency   int      `json:"Concurrency"`
+}
+
+// SearchMatch 一条搜索结果。Kind 为 table、column、routine 或 data；
+// 数据匹配时 Value 为匹配到的值（过长时截断），PrimaryKey 为该行的主键，表没有主键时为空。
+// 数据库的排序规则与本地比较结果不一致时 Column 可能为空
+type SearchMatch struct {
+	Kind       string            `json:"Kind"`
+	Schema     string            `json:"Schema"`
+	Table      string            `json:"Table"`
+	Column     string            `json:"Column"`
+	Name       string            `json:"Name"`
+	Value      string            `json:"Value"`
+	PrimaryKey map[string]string `json:"PrimaryKey"`
+}
+
+// SearchProgress 搜索进度
+type SearchProgress struct {
+	Tables      int    `json:"Tables"`
+	TotalTables int    `json:"TotalTables"`
+	Matches     int    `json:"Matches"`
+	Current     string `json:"Current"`
+}
+
+// SearchSummary 搜索结束时的统计。Truncated 为达到行数上限的表，Errors 为搜索失败的表及原因
+type SearchSummary struct {
+	Tables    int      `json:"Tables"`
+	Matches   int      `json:"Matches"`
+	Truncated []string `json:"Truncated"`
+	Errors    []string `json:"Errors"`
+}
+
+// searchMatcher 在本地判断名称或值是否匹配
+type searchMatcher struct {
+	mode string
+	term string
+	fold bool
+	re   *regexp.Regexp
+}
+
+func newSearchMatcher(term string, opts SearchOptions) (*searchMatcher, error) {
+	m := &searchMatcher{mode: opts.MatchMode, term: term, fold: !opts.CaseSensitive}
+	switch m.mode {
+	case "":
+		m.mode = MatchContains
+	case MatchExact, MatchContains:
+	case MatchRegex:
+		expr := term
+		if m.fold {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		m.re = re
+	default:
+		return nil, fmt.Errorf("unsupported match mode: %s", opts.MatchMode)
+	}
+	if m.fold {
+		m.term = strings.ToLower(term)
+	}
+	return m, nil
+}
+
+func (m *searchMatcher) match(s string) bool {
+	if m.re != nil {
+		return m.re.MatchString(s)
+	}
+	if m.fold {
+		s = strings.ToLower(s)
+	}
+	if m.mode == MatchExact {
+		return s == m.term
+	}
+	return strings.Contains(s, m.term)
+}
+
+// searchTable 需要搜索数据的表
+type searchTable struct {
+	schema  string
+	name    string
+	columns []ColumnInfo
+}
+
+// spatialTypes 不参与文本搜索的空间类型
+var spatialTypes = map[string]bool{
+	"geometry": true, "point": true, "linestring": true, "polygon": true, "multipoint": true,
+	"multilinestring": true, "multipolygon": true, "geometrycollection": true, "geography": true,
+}
+
+// searchColumnKind 返回列在数据搜索中的用法：text 按文本匹配，number 在关键字为数值时按相等匹配，其他列不搜索
+func searchColumnKind(col ColumnInfo) string {
+	if t, _ := baseType(col.Type); spatialTypes[t] {
+		return ""
+	}
+	switch ColumnKind(col.Type) {
+	case KindText, KindJSON:
+		return KindText
+	case KindInteger, KindNumber:
+		return KindNumber
+	}
+	return ""
+}
+
+// SearchDatabase 在对象名和数据中搜索关键字，每找到一条结果调用一次 emit。
+// 单张表搜索失败不会中止整个搜索，错误记录在 SearchSummary.Errors 中
+func SearchDatabase(ctx context.Context, adapter DBAdapter, dbType, dbName, term string, opts SearchOptions, emit func(SearchMatch), progress func(SearchProgress)) (*SearchSummary, error) {
+	if term == "" {
+		return nil, fmt.Errorf("search term is empty")
+	}
+	matcher, err := newSearchMatcher(term, opts)
+	if err != nil {
+		return nil, err
+	}
+	scopes := make(map[string]bool)
+	for _, s := range opts.Scopes {
+		scopes[s] = true
+	}
+	if len(scopes) == 0 {
+		scopes[SearchTables], scopes[SearchColumns], scopes[SearchRoutines] = true, true, true
+	}
+	if scopes[SearchData] && dbType == "sqlite" && matcher.mode == MatchRegex {
+		return nil, fmt.Errorf("sqlite does not support regex search in data")
+	}
+
+	var schemas []string
+	switch {
+	case opts.Schema != "":
+		schemas = []string{opts.Schema}
+	case dbType == "postgres":
+		list, err := adapter.GetSchemas(dbName)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range list {
+			schemas = append(schemas, s.Name)
+		}
+	case dbType == "sqlite":
+		schemas = []string{"main"}
+	default:
+		schemas = []string{dbName}
+	}
+	only := make(map[string]bool)
+	for _, t := range opts.Tables {
+		only[t] = true
+	}
+
+	summary := &SearchSummary{Truncated: []string{}, Errors: []string{}}
+	var mu sync.Mutex
+	report := func(m SearchMatch) {
+		mu.Lock()
+		defer mu.Unlock()
+		summary.Matches++
+		emit(m)
+	}
+
+	// 对象名
+	var tables []searchTable
+	for _, schema := range schemas {
+		list, err := adapter.GetTables(dbName, schema)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range list {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if scopes[SearchTables] && matcher.match(t.Name) {
+				report(SearchMatch{Kind: SearchTables, Schema: schema, Table: t.Name, Name: t.Name})
+			}
+			if !scopes[SearchColumns] && !scopes[SearchData] {
+				continue
+			}
+			columns, err := SchemaTableColumns(adapter, dbName, schema, t.Name)
+			if err != nil {
+				summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", t.Name, err))
+				continue
+			}
+			if scopes[SearchColumns] {
+				for _, col := range columns {
+					if matcher.match(col.Name) {
+						report(SearchMatch{Kind: SearchColumns, Schema: schema, Table: t.Name, Column: col.Name, Name: col.Name})
+					}
+				}
+			}
+			if scopes[SearchData] && (len(only) == 0 || only[t.Name]) {
+				tables = append(tables, searchTable{schema: schema, name: t.Name, columns: columns})
+			}
+		}
+		if scopes[SearchRoutines] {
+			routines, err := adapter.GetRoutines(dbName, schema)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range routines {
+				if matcher.match(r.Name) {
+					report(SearchMatch{Kind: SearchRoutines, Schema: schema, Name: r.Name, Value: r.Type})
+				}
+			}
+		}
+	}
+	if len(tables) == 0 {
+		return summary, ctx.Err()
+	}
+
+	// 数据
+	s := &dataSearcher{
+		ctx:      ctx,
+		adapter:  adapter,
+		d:        DialectOf(dbType),
+		dbName:   dbName,
+		term:     term,
+		matcher:  matcher,
+		rowLimit: opts.RowLimit,
+		report:   report,
+	}
+	if s.rowLimit <= 0 {
+		s.rowLimit = defaultSearchRowLimit
+	}
+	if _, ok := numberText(term); ok && matcher.mode != MatchRegex {
+		s.numeric = true
+	}
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = defaultSearchConcurrency
+	}
+	if workers > maxSearchConcurrency {
+		workers = maxSearchConcurrency
+	}
+	if workers > len(tables) {
+		workers = len(tables)
+	}
+
+	queue := make(chan searchTable)
+	var wg sync.WaitGroup
+	done := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				truncated, err := s.search(t)
+				mu.Lock()
+				done++
+				switch {
+				case err != nil && ctx.Err() == nil:
+					summary.Errors = append(summary.Errors, fmt.Sprintf("%s: %v", t.name, err))
+				case truncated:
+					summary.Truncated = append(summary.Truncated, t.name)
+				}
+				p := SearchProgress{Tables: done, TotalTables: len(tables), Matches: summary.Matches, Current: t.name}
+				mu.Unlock()
+				if progress != nil {
+					progress(p)
+				}
+			}
+		}()
+	}
+	for _, t := range tables {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- t
+	}
+	close(queue)
+	wg.Wait()
+
+	summary.Tables = done
+	return summary, ctx.Err()
+}
+
+// dataSearcher 在表数据中搜索关键字
+type dataSearcher struct {
+	ctx      context.Context
+	adapter  DBAdapter
+	d        Dialect
+	dbName   string
+	term     string
+	numeric  bool
+	matcher  *searchMatcher
+	rowLimit int
+	report   func(SearchMatch)
+}
+
+// likePattern 转义 LIKE 通配符，contains 时两侧加 %
+func likePattern(term string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(term) + "%"
+}
+
+// condition 返回单列的匹配条件和参数，n 为参数序号
+func (s *dataSearcher) condition(col ColumnInfo, n int) (string, interface{}, bool) {
+	kind := searchColumnKind(col)
+	name := s.d.QuoteIdent(col.Name)
+	ph := s.d.Placeholder(n)
+	if kind == KindNumber {
+		if !s.numeric {
+			return "", nil, false
+		}
+		// PostgreSQL 按列类型解析参数，小数或超出范围的值会使整个查询出错
+		if s.d.Name == "postgres" {
+			return name + "::numeric = " + ph + "::numeric", s.term, true
+		}
+		return name + " = " + ph, s.term, true
+	}
+	if kind != KindText {
+		return "", nil, false
+	}
+
+	fold := s.matcher.fold
+	switch s.d.Name {
+	case "mysql":
+		if !fold {
+			name = "BINARY " + name
+		}
+		switch s.matcher.mode {
+		case MatchExact:
+			return name + " = " + ph, s.term, true
+		case MatchRegex:
+			return name + " REGEXP " + ph, s.term, true
+		}
+		return name + " LIKE " + ph, likePattern(s.term), true
+	case "postgres":
+		name += "::text"
+		switch s.matcher.mode {
+		case MatchExact:
+			if fold {
+				return "lower(" + name + ") = lower(" + ph + ")", s.term, true
+			}
+			return name + " = " + ph, s.term, true
+		case MatchRegex:
+			if fold {
+				return name + " ~* " + ph, s.term, true
+			}
+			return name + " ~ " + ph, s.term, true
+		}
+		if fold {
+			return name + " ILIKE " + ph, likePattern(s.term), true
+		}
+		return name + " LIKE " + ph, likePattern(s.term), true
+	}
+	// SQLite 的 LIKE 不区分 ASCII 大小写
+	if s.matcher.mode == MatchExact {
+		if fold {
+			return name + " = " + ph + " COLLATE NOCASE", s.term, true
+		}
+		return name + " = " + ph, s.term, true
+	}
+	if fold {
+		return name + ` LIKE ` + ph + ` ESCAPE '\'`, likePattern(s.term), true
+	}
+	return "instr(" + name + ", " + ph + ") > 0", s.term, true
+}
+
+// search 搜索一张表，返回是否达到行数上限
+func (s *dataSearcher) search(t searchTable) (bool, error) {
+	var conds, selected, keys []string
+	var args []interface{}
+	var searched []ColumnInfo
+	for _, col := range t.columns {
+		if col.IsPrimary {
+			keys = append(keys, col.Name)
+		}
+		cond, arg, ok := s.condition(col, len(args)+1)
+		if !ok {
+			continue
+		}
+		conds = append(conds, cond)
+		args = append(args, arg)
+		searched = append(searched, col)
+	}
+	if len(conds) == 0 {
+		return false, nil
+	}
+	for _, k := range keys {
+		selected = append(selected, s.d.QuoteIdent(k))
+	}
+	for _, col := range searched {
+		selected = append(selected, s.d.QuoteIdent(col.Name))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s LIMIT %d",
+		strings.Join(selected, ", "), s.d.TableName(s.dbName, t.schema, t.name), strings.Join(conds, " OR "), s.rowLimit+1)
+
+	sink := &searchSink{s: s, table: t, keys: keys, searched: searched}
+	if err := s.adapter.StreamQuery(s.ctx, s.dbName, query, args, sink); err != nil {
+		return false, err
+	}
+	return sink.rows > s.rowLimit, nil
+}
+
+// searchSink 将匹配的行转换为搜索结果
+type searchSink struct {
+	s        *dataSearcher
+	table    searchTable
+	keys     []string
+	searched []ColumnInfo
+	columns  []ResultColumn
+	rows     int
+}
+
+func (k *searchSink) Begin(columns []ResultColumn) error {
+	k.columns = columns
+	return nil
+}
+
+func (k *searchSink) Row(values []interface{}) error {
+	if err := k.s.ctx.Err(); err != nil {
+		return err
+	}
+	// 多查询的一行只用于判断是否达到上限
+	k.rows++
+	if k.rows > k.s.rowLimit {
+		return nil
+	}
+	pk := make(map[string]string, len(k.keys))
+	for i, key := range k.keys {
+		pk[key] = FormatValue(k.columns[i].DatabaseType, values[i])
+	}
+
+	matched := false
+	for i, col := range k.searched {
+		v := values[len(k.keys)+i]
+		if v == nil {
+			continue
+		}
+		text := FormatValue(k.columns[len(k.keys)+i].DatabaseType, v)
+		if searchColumnKind(col) == KindNumber {
+			if n, ok := NumberText(v); !ok || !numberEqual(n, k.s.term) {
+				continue
+			}
+		} else if !k.s.matcher.match(text) {
+			continue
+		}
+		matched = true
+		k.s.report(SearchMatch{Kind: SearchData, Schema: k.table.schema, Table: k.table.name, Column: col.Name,
+			Value: truncateValue(text), PrimaryKey: pk})
+	}
+	if !matched {
+		k.s.report(SearchMatch{Kind: SearchData, Schema: k.table.schema, Table: k.table.name, PrimaryKey: pk})
+	}
+	return nil
+}
+
+// numberEqual 按数值比较两个数字文本
+func numberEqual(a, b string) bool {
+	x, err1 := strconv.ParseFloat(a, 64)
+	y, err2 := strconv.ParseFloat(b, 64)
+	return err1 == nil && err2 == nil && x == y
+}
+
+// truncateValue 截断过长的值
+func truncateValue(s string) string {
+	r := []rune(s)
+	if len(r) <= searchValueLimit {
+		return s
+	}
+	return string(r[:searchValueLimit]) + "…"
+}
@@ -0,0 +1,464 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ServerVariable 服务器配置项或状态值。Kind 为值分类（integer、number、bool、text），
+// 数值类的 Number 为按 Unit 换算后的值，内存类单位统一换算为 bytes
+type ServerVariable struct {
+	Name        string  `json:"Name"`
+	Value       string  `json:"Value"`
+	Kind        string  `json:"Kind"`
+	Number      float64 `json:"Number"`
+	Unit        string  `json:"Unit"`
+	Category    string  `json:"Category"`
+	Description string  `json:"Description"`
+	// Options 枚举类配置的可选值
+	Options []string `json:"Options"`
+}
+
+// ServerMetrics 两次采样之间计算出的速率和比例，Interval 为采样间隔秒数。
+// 第一次采样没有上一次的数据，速率为 0；BufferHitRatio 无法计算时为 -1
+type ServerMetrics struct {
+	Interval         float64            `json:"Interval"`
+	QPS              float64            `json:"QPS"`
+	TPS              float64            `json:"TPS"`
+	Connections      float64            `json:"Connections"`
+	BufferHitRatio   float64            `json:"BufferHitRatio"`
+	BytesReceivedSec float64            `json:"BytesReceivedSec"`
+	BytesSentSec     float64            `json:"BytesSentSec"`
+	Rates            map[string]float64 `json:"Rates"`
+}
+
+// newServerVariable 根据文本值推断类型
+func newServerVariable(name, value, unit string) ServerVariable {
+	v := ServerVariable{Name: name, Value: value, Kind: KindText, Unit: unit}
+	switch strings.ToUpper(value) {
+	case "ON", "OFF", "TRUE", "FALSE":
+		v.Kind = KindBool
+		if strings.EqualFold(value, "ON") || strings.EqualFold(value, "TRUE") {
+			v.Number = 1
+		}
+		return v
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		v.Kind, v.Number = KindInteger, float64(n)
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+		v.Kind, v.Number = KindNumber, f
+	}
+	return v
+}
+
+// memoryUnits PostgreSQL 内存单位对应的字节数
+var memoryUnits = map[string]float64{
+	"B": 1, "kB": 1024, "8kB": 8192, "16kB": 16384, "32kB": 32768, "64kB": 65536,
+	"MB": 1 << 20, "16MB": 16 << 20, "GB": 1 << 30, "TB": 1 << 40,
+}
+
+// mysqlVariables 常用 MySQL 配置项的单位和说明，未列出的配置项没有单位和说明
+var mysqlVariables = map[string]struct{ unit, desc string }{
+	"max_connections":                  {"", "最大并发连接数"},
+	"max_user_connections":             {"", "单个账号的最大并发连接数，0 为不限制"},
+	"max_connect_errors":               {"", "主机连续连接失败达到该次数后被阻止"},
+	"back_log":                         {"", "等待处理的连接请求队列长度"},
+	"thread_cache_size":                {"", "缓存的空闲线程数"},
+	"thread_stack":                     {"bytes", "每个线程的栈大小"},
+	"host_cache_size":                  {"", "主机名缓存的条目数"},
+	"table_open_cache":                 {"", "所有线程可打开的表数量"},
+	"table_open_cache_instances":       {"", "打开表缓存的分区数"},
+	"table_definition_cache":           {"", "可缓存的表定义数量"},
+	"open_files_limit":                 {"", "进程可打开的文件数"},
+	"max_prepared_stmt_count":          {"", "预处理语句的最大数量"},
+	"max_allowed_packet":               {"bytes", "单个数据包或生成的字符串的最大长度"},
+	"net_buffer_length":                {"bytes", "连接缓冲区的初始大小"},
+	"max_heap_table_size":              {"bytes", "用户创建的 MEMORY 表的最大大小"},
+	"tmp_table_size":                   {"bytes", "内部临时表在内存中的最大大小"},
+	"sort_buffer_size":                 {"bytes", "每次排序分配的缓冲区大小"},
+	"join_buffer_size":                 {"bytes", "无索引连接使用的缓冲区大小"},
+	"read_buffer_size":                 {"bytes", "顺序扫描使用的缓冲区大小"},
+	"read_rnd_buffer_size":             {"bytes", "按排序结果读取行时使用的缓冲区大小"},
+	"bulk_insert_buffer_size":          {"bytes", "MyISAM 批量插入的缓冲区大小"},
+	"key_buffer_size":                  {"bytes", "MyISAM 索引缓冲区大小"},
+	"myisam_sort_buffer_size":          {"bytes", "MyISAM 重建索引时的排序缓冲区大小"},
+	"preload_buffer_size":              {"bytes", "预加载索引时的缓冲区大小"},
+	"query_cache_size":                 {"bytes", "查询缓存大小（MySQL 8.0 已移除）"},
+	"group_concat_max_len":             {"bytes", "GROUP_CONCAT 结果的最大长度"},
+	"max_sort_length":                  {"bytes", "排序时使用的值的最大字节数"},
+	"range_optimizer_max_mem_size":     {"bytes", "范围优化器可使用的最大内存"},
+	"binlog_cache_size":                {"bytes", "事务中二进制日志的缓存大小"},
+	"max_binlog_cache_size":            {"bytes", "事务的二进制日志缓存的最大大小"},
+	"max_binlog_size":                  {"bytes", "单个二进制日志文件的最大大小"},
+	"binlog_expire_logs_seconds":       {"s", "二进制日志的保留时间"},
+	"expire_logs_days":                 {"d", "二进制日志的保留天数（MySQL 8.0 已弃用）"},
+	"sync_binlog":                      {"", "每提交多少次事务将二进制日志同步到磁盘，0 由操作系统决定"},
+	"binlog_format":                    {"", "二进制日志格式"},
+	"log_bin":                          {"", "是否开启二进制日志"},
+	"server_id":                        {"", "复制拓扑中的服务器 ID"},
+	"gtid_mode":                        {"", "是否使用 GTID 复制"},
+	"read_only":                        {"", "是否只允许有 SUPER 权限的用户写入"},
+	"super_read_only":                  {"", "是否禁止所有用户写入"},
+	"wait_timeout":                     {"s", "非交互连接的空闲超时"},
+	"interactive_timeout":              {"s", "交互连接的空闲超时"},
+	"connect_timeout":                  {"s", "连接握手的超时"},
+	"net_read_timeout":                 {"s", "从连接读取数据的超时"},
+	"net_write_timeout":                {"s", "向连接写入数据的超时"},
+	"lock_wait_timeout":                {"s", "元数据锁的等待超时"},
+	"innodb_lock_wait_timeout":         {"s", "InnoDB 行锁的等待超时"},
+	"max_execution_time":               {"ms", "SELECT 语句的执行超时，0 为不限制"},
+	"long_query_time":                  {"s", "执行时间超过该值的语句记入慢查询日志"},
+	"slow_launch_time":                 {"s", "创建线程超过该时间时计入 Slow_launch_threads"},
+	"slow_query_log":                   {"", "是否开启慢查询日志"},
+	"general_log":                      {"", "是否记录所有语句"},
+	"replica_net_timeout":              {"s", "副本等待源库数据的超时"},
+	"slave_net_timeout":                {"s", "副本等待源库数据的超时"},
+	"innodb_buffer_pool_size":          {"bytes", "InnoDB 缓冲池大小"},
+	"innodb_buffer_pool_instances":     {"", "InnoDB 缓冲池的实例数"},
+	"innodb_buffer_pool_chunk_size":    {"bytes", "调整缓冲池大小时的块大小"},
+	"innodb_log_file_size":             {"bytes", "每个重做日志文件的大小"},
+	"innodb_log_files_in_group":        {"", "重做日志文件数"},
+	"innodb_redo_log_capacity":         {"bytes", "重做日志占用的磁盘空间"},
+	"innodb_log_buffer_size":           {"bytes", "重做日志缓冲区大小"},
+	"innodb_flush_log_at_trx_commit":   {"", "提交时重做日志的刷盘策略（1=每次提交刷盘，2=写入操作系统缓存，0=每秒刷盘）"},
+	"innodb_flush_log_at_timeout":      {"s", "重做日志的刷盘间隔"},
+	"innodb_flush_method":              {"", "数据和日志文件的刷盘方式"},
+	"innodb_io_capacity":               {"", "后台任务每秒可执行的 I/O 次数"},
+	"innodb_io_capacity_max":           {"", "后台任务每秒可执行的最大 I/O 次数"},
+	"innodb_read_io_threads":           {"", "读 I/O 线程数"},
+	"innodb_write_io_threads":          {"", "写 I/O 线程数"},
+	"innodb_purge_threads":             {"", "清理 undo 日志的线程数"},
+	"innodb_purge_batch_size":          {"", "每批清理的 undo 日志页数"},
+	"innodb_thread_concurrency":        {"", "InnoDB 内部的并发线程数，0 为不限制"},
+	"innodb_page_size":                 {"bytes", "InnoDB 页大小"},
+	"innodb_open_files":                {"", "InnoDB 可同时打开的文件数"},
+	"innodb_sort_buffer_size":          {"bytes", "创建索引时的排序缓冲区大小"},
+	"innodb_online_alter_log_max_size": {"bytes", "在线 DDL 期间记录修改的日志的最大大小"},
+	"innodb_max_undo_log_size":         {"bytes", "undo 表空间超过该大小时被截断"},
+	"innodb_max_dirty_pages_pct":       {"%", "缓冲池中脏页的目标最大比例"},
+	"innodb_old_blocks_time":           {"ms", "新读入的页在缓冲池旧区停留多久后才能移入新区"},
+	"innodb_file_per_table":            {"", "是否每个表使用独立的表空间文件"},
+	"innodb_autoinc_lock_mode":         {"", "自增锁模式（0=传统，1=连续，2=交错）"},
+	"innodb_deadlock_detect":           {"", "是否开启死锁检测"},
+	"innodb_print_all_deadlocks":       {"", "是否将所有死锁记入错误日志"},
+	"innodb_rollback_on_timeout":       {"", "锁等待超时时是否回滚整个事务"},
+	"innodb_adaptive_hash_index":       {"", "是否开启自适应哈希索引"},
+	"autocommit":                       {"", "是否自动提交"},
+	"transaction_isolation":            {"", "默认事务隔离级别"},
+	"sql_mode":                         {"", "SQL 模式"},
+	"character_set_server":             {"", "服务器默认字符集"},
+	"collation_server":                 {"", "服务器默认排序规则"},
+	"time_zone":                        {"", "当前时区"},
+	"default_storage_engine":           {"", "默认存储引擎"},
+	"lower_case_table_names":           {"", "表名大小写的处理方式"},
+	"explicit_defaults_for_timestamp":  {"", "TIMESTAMP 列是否使用标准的默认值和 NULL 处理"},
+	"performance_schema":               {"", "是否开启性能模式"},
+}
+
+// mysqlStatusDescriptions 常用 MySQL 状态值的说明
+var mysqlStatusDescriptions = map[string]string{
+	"Questions":                        "客户端发送的语句数",
+	"Queries":                          "执行的语句数（包括存储过程中的语句）",
+	"Com_commit":                       "COMMIT 次数",
+	"Com_rollback":                     "ROLLBACK 次数",
+	"Threads_connected":                "当前连接数",
+	"Threads_running":                  "正在执行的线程数",
+	"Connections":                      "连接尝试次数",
+	"Aborted_connects":                 "连接失败次数",
+	"Slow_queries":                     "慢查询次数",
+	"Bytes_received":                   "接收的字节数",
+	"Bytes_sent":                       "发送的字节数",
+	"Innodb_buffer_pool_reads":         "缓冲池未命中、从磁盘读取的次数",
+	"Innodb_buffer_pool_read_requests": "缓冲池逻辑读次数",
+	"Innodb_row_lock_waits":            "行锁等待次数",
+	"Innodb_deadlocks":                 "死锁次数",
+	"Uptime":                           "服务器运行秒数",
+}
+
+// showGlobal 执行 SHOW GLOBAL VARIABLES/STATUS
+func (a *MySQLAdapter) showGlobal(what string) ([][2]string, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Queryx("SHOW GLOBAL " + what)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result [][2]string
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		result = append(result, [2]string{name, value})
+	}
+	return result, rows.Err()
+}
+
+// GetServerVariables 读取 SHOW GLOBAL VARIABLES，常用配置项附带单位和说明
+func (a *MySQLAdapter) GetServerVariables() ([]ServerVariable, error) {
+	rows, err := a.showGlobal("VARIABLES")
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]ServerVariable, 0, len(rows))
+	for _, r := range rows {
+		v := newServerVariable(r[0], r[1], "")
+		info := mysqlVariables[r[0]]
+		if v.Kind == KindInteger || v.Kind == KindNumber {
+			v.Unit = info.unit
+		}
+		v.Description = info.desc
+		if i := strings.IndexByte(r[0], '_'); i > 0 {
+			v.Category = r[0][:i]
+		}
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// GetServerStatus 读取 SHOW GLOBAL STATUS
+func (a *MySQLAdapter) GetServerStatus() ([]ServerVariable, error) {
+	rows, err := a.showGlobal("STATUS")
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]ServerVariable, 0, len(rows))
+	for _, r := range rows {
+		v := newServerVariable(r[0], r[1], "")
+		if strings.HasPrefix(r[0], "Bytes_") {
+			v.Unit = "bytes"
+		} else if r[0] == "Uptime" {
+			v.Unit = "s"
+		}
+		if i := strings.IndexByte(r[0], '_'); i > 0 {
+			v.Category = r[0][:i]
+		}
+		v.Description = mysqlStatusDescriptions[r[0]]
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// GetServerVariables 读取 pg_settings，内存类配置换算为字节
+func (a *PostgresAdapter) GetServerVariables() ([]ServerVariable, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Queryx(`
+		SELECT name, COALESCE(setting, ''), COALESCE(unit, ''), vartype, COALESCE(category, ''),
+			COALESCE(short_desc, ''), COALESCE(array_to_string(enumvals, ','), '')
+		FROM pg_settings
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vars []ServerVariable
+	for rows.Next() {
+		var name, setting, unit, vartype, category, desc, enumvals string
+		if err := rows.Scan(&name, &setting, &unit, &vartype, &category, &desc, &enumvals); err != nil {
+			return nil, err
+		}
+		v := newServerVariable(name, setting, unit)
+		switch vartype {
+		case "bool":
+			v.Kind = KindBool
+		case "enum":
+			v.Kind = KindText
+			v.Options = strings.Split(enumvals, ",")
+		case "string":
+			v.Kind, v.Number = KindText, 0
+		}
+		if factor, ok := memoryUnits[unit]; ok && (v.Kind == KindInteger || v.Kind == KindNumber) {
+			v.Number *= factor
+			v.Unit = "bytes"
+		}
+		v.Category = category
+		v.Description = desc
+		vars = append(vars, v)
+	}
+	return vars, rows.Err()
+}
+
+// GetServerStatus 汇总 pg_stat_database 中所有库的计数，并读取 pg_stat_bgwriter
+func (a *PostgresAdapter) GetServerStatus() ([]ServerVariable, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	var vars []ServerVariable
+	for _, src := range []struct{ category, query string }{
+		{"database", `
+			SELECT sum(numbackends) AS numbackends, sum(xact_commit) AS xact_commit, sum(xact_rollback) AS xact_rollback,
+				sum(blks_read) AS blks_read, sum(blks_hit) AS blks_hit, sum(tup_returned) AS tup_returned,
+				sum(tup_fetched) AS tup_fetched, sum(tup_inserted) AS tup_inserted, sum(tup_updated) AS tup_updated,
+				sum(tup_deleted) AS tup_deleted, sum(conflicts) AS conflicts, sum(temp_files) AS temp_files,
+				sum(temp_bytes) AS temp_bytes, sum(deadlocks) AS deadlocks
+			FROM pg_stat_database`},
+		{"bgwriter", `SELECT * FROM pg_stat_bgwriter`},
+	} {
+		rows, err := db.Queryx(src.query)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			row := make(map[string]interface{})
+			if err := rows.MapScan(row); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			columns, _ := rows.Columns()
+			for _, col := range columns {
+				text, ok := NumberText(row[col])
+				if !ok {
+					continue
+				}
+				v := newServerVariable(src.category+"."+col, text, "")
+				v.Category = src.category
+				if strings.HasSuffix(col, "_bytes") {
+					v.Unit = "bytes"
+				}
+				vars = append(vars, v)
+			}
+		}
+		rows.Close()
+	}
+	return vars, nil
+}
+
+// sqlitePragmas 作为配置项读取的 PRAGMA 及说明
+var sqlitePragmas = []struct{ name, unit, desc string }{
+	{"journal_mode", "", "日志模式"},
+	{"synchronous", "", "同步级别（0=OFF，1=NORMAL，2=FULL，3=EXTRA）"},
+	{"foreign_keys", "", "是否检查外键约束"},
+	{"auto_vacuum", "", "自动清理模式（0=NONE，1=FULL，2=INCREMENTAL）"},
+	{"encoding", "", "文本编码"},
+	{"page_size", "bytes", "页大小"},
+	{"cache_size", "", "页缓存大小，负数表示 KiB"},
+	{"temp_store", "", "临时表存储位置（0=DEFAULT，1=FILE，2=MEMORY）"},
+	{"mmap_size", "bytes", "内存映射大小"},
+	{"busy_timeout", "ms", "等待锁的超时时间"},
+	{"locking_mode", "", "锁模式"},
+	{"wal_autocheckpoint", "", "WAL 自动检查点的页数"},
+	{"user_version", "", "用户定义的版本号"},
+	{"application_id", "", "应用程序 ID"},
+}
+
+// sqliteStatusPragmas 作为状态值读取的 PRAGMA
+var sqliteStatusPragmas = []struct{ name, unit, desc string }{
+	{"page_count", "", "数据库页数"},
+	{"freelist_count", "", "空闲页数"},
+	{"schema_version", "", "结构版本号"},
+	{"data_version", "", "数据版本号"},
+}
+
+// readPragmas 逐个读取 PRAGMA
+func (a *SQLiteAdapter) readPragmas(list []struct{ name, unit, desc string }, category string) ([]ServerVariable, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	var vars []ServerVariable
+	for _, p := range list {
+		var value string
+		if err := db.Get(&value, "PRAGMA "+p.name); err != nil {
+			return nil, fmt.Errorf("pragma %s: %v", p.name, err)
+		}
+		v := newServerVariable(p.name, value, p.unit)
+		v.Category = category
+		v.Description = p.desc
+		v.Options = sqliteEditablePragmas[p.name]
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// GetServerVariables 读取常用的 PRAGMA 配置
+func (a *SQLiteAdapter) GetServerVariables() ([]ServerVariable, error) {
+	return a.readPragmas(sqlitePragmas, "pragma")
+}
+
+// GetServerStatus 读取页数等状态 PRAGMA
+func (a *SQLiteAdapter) GetServerStatus() ([]ServerVariable, error) {
+	return a.readPragmas(sqliteStatusPragmas, "status")
+}
+
+// ComputeServerMetrics 根据两次状态采样计算速率，prev 为 nil 时只计算当前值类指标，
+// seconds 为两次采样的间隔秒数
+func ComputeServerMetrics(dbType string, prev, cur []ServerVariable, seconds float64) ServerMetrics {
+	m := ServerMetrics{Interval: seconds, BufferHitRatio: -1, Rates: make(map[string]float64)}
+	now := make(map[string]float64, len(cur))
+	for _, v := range cur {
+		if v.Kind == KindInteger || v.Kind == KindNumber {
+			now[v.Name] = v.Number
+		}
+	}
+	before := make(map[string]float64, len(prev))
+	for _, v := range prev {
+		before[v.Name] = v.Number
+	}
+	delta := func(names ...string) float64 {
+		var d float64
+		for _, name := range names {
+			d += now[name] - before[name]
+		}
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+	rate := func(names ...string) float64 {
+		if prev == nil || seconds <= 0 {
+			return 0
+		}
+		return delta(names...) / seconds
+	}
+	ratio := func(hit, miss float64) float64 {
+		if hit+miss <= 0 {
+			return -1
+		}
+		return hit / (hit + miss)
+	}
+
+	switch dbType {
+	case "mysql":
+		m.QPS = rate("Questions")
+		m.TPS = rate("Com_commit", "Com_rollback")
+		m.Connections = now["Threads_connected"]
+		m.BytesReceivedSec = rate("Bytes_received")
+		m.BytesSentSec = rate("Bytes_sent")
+		if prev != nil {
+			requests, reads := delta("Innodb_buffer_pool_read_requests"), delta("Innodb_buffer_pool_reads")
+			m.BufferHitRatio = ratio(requests-reads, reads)
+		} else {
+			m.BufferHitRatio = ratio(now["Innodb_buffer_pool_read_requests"]-now["Innodb_buffer_pool_reads"], now["Innodb_buffer_pool_reads"])
+		}
+		for _, name := range []string{"Slow_queries", "Innodb_rows_read", "Innodb_rows_inserted", "Innodb_rows_updated",
+			"Innodb_rows_deleted", "Innodb_row_lock_waits", "Created_tmp_disk_tables"} {
+			m.Rates[name] = rate(name)
+		}
+	case "postgres":
+		// PostgreSQL 没有语句计数，QPS 按事务数计算
+		m.TPS = rate("database.xact_commit", "database.xact_rollback")
+		m.QPS = m.TPS
+		m.Connections = now["database.numbackends"]
+		if prev != nil {
+			m.BufferHitRatio = ratio(delta("database.blks_hit"), delta("database.blks_read"))
+		} else {
+			m.BufferHitRatio = ratio(now["database.blks_hit"], now["database.blks_read"])
+		}
+		for _, name := range []string{"database.tup_returned", "database.tup_fetched", "database.tup_inserted",
+			"database.tup_updated", "database.tup_deleted", "database.temp_bytes", "database.deadlocks"} {
+			m.Rates[strings.TrimPrefix(name, "database.")] = rate(name)
+		}
+	}
+	return m
+}
@@ -0,0 +1,732 @@
+package database
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WKB 几何类型编号，数组下标对应类型编号
+var geometryTypeNames = []string{"", "Point", "LineString", "Polygon", "MultiPoint", "MultiLineString", "MultiPolygon", "GeometryCollection"}
+
+const (
+	geomPoint = iota + 1
+	geomLineString
+	geomPolygon
+	geomMultiPoint
+	geomMultiLineString
+	geomMultiPolygon
+	geomCollection
+)
+
+// EWKB 类型中的标志位
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// geometryMaxDepth GeometryCollection 的最大嵌套层数
+const geometryMaxDepth = 32
+
+// geometry 解析后的几何对象。Point 和 LineString 使用 coords（空点没有坐标），
+// Polygon 使用 rings，Multi* 和 GeometryCollection 使用 parts
+type geometry struct {
+	typ    int
+	hasZ   bool
+	hasM   bool
+	coords [][]float64
+	rings  [][][]float64
+	parts  []*geometry
+	// fixed 解析 WKT 时维数是否已确定
+	fixed bool
+}
+
+// dims 每个坐标的维数
+func (g *geometry) dims() int {
+	n := 2
+	if g.hasZ {
+		n++
+	}
+	if g.hasM {
+		n++
+	}
+	return n
+}
+
+// empty 判断是否为空几何
+func (g *geometry) empty() bool {
+	return len(g.coords) == 0 && len(g.rings) == 0 && len(g.parts) == 0
+}
+
+// Geometry 解码后的空间值。WKT 不包含 SRID，GeoJSON 为 geometry 对象（不包含 M 坐标，坐标不做投影转换）
+type Geometry struct {
+	SRID    int             `json:"SRID"`
+	Type    string          `json:"Type"`
+	WKT     string          `json:"WKT"`
+	GeoJSON json.RawMessage `json:"GeoJSON"`
+	geom    *geometry
+}
+
+// EWKT 返回带 SRID 前缀的 WKT（PostGIS 的 EWKT 格式），SRID 为 0 时与 WKT 相同
+func (g *Geometry) EWKT() string {
+	if g.SRID == 0 {
+		return g.WKT
+	}
+	return "SRID=" + strconv.Itoa(g.SRID) + ";" + g.WKT
+}
+
+func newGeometry(g *geometry, srid int) *Geometry {
+	data, _ := json.Marshal(geoJSONObject(g))
+	return &Geometry{SRID: srid, Type: geometryTypeNames[g.typ], WKT: geometryWKT(g), GeoJSON: data, geom: g}
+}
+
+// DecodeGeometry 将驱动返回的空间值解码，支持 MySQL 的内部格式（4 字节 SRID + WKB）、
+// PostGIS 返回的十六进制 EWKB、WKB 以及空间列中以文本保存的 WKT（SQLite）。dbType 为结果集中的列类型，lib/pq 对 PostGIS 类型返回空字符串，
+// 此时只尝试十六进制 EWKB；不是空间值时返回 false
+func DecodeGeometry(dbType string, v interface{}) (*Geometry, bool) {
+	var b []byte
+	switch v := v.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, false
+	}
+	spatial := ColumnKind(dbType) == KindGeometry
+	if !spatial && dbType != "" {
+		return nil, false
+	}
+
+	if isHexWKB(b) {
+		raw := make([]byte, len(b)/2)
+		if _, err := hex.Decode(raw, b); err == nil {
+			if g, srid, ok := parseWKB(raw); ok {
+				return newGeometry(g, srid), true
+			}
+		}
+	}
+	if !spatial {
+		return nil, false
+	}
+	head := b
+	if len(head) > 64 {
+		head = head[:64]
+	}
+	if LooksLikeWKT(string(head)) {
+		g, err := ParseWKT(string(b))
+		return g, err == nil
+	}
+	if len(b) > 4 {
+		if g, _, ok := parseWKB(b[4:]); ok {
+			return newGeometry(g, int(binary.LittleEndian.Uint32(b))), true
+		}
+	}
+	if g, srid, ok := parseWKB(b); ok {
+		return newGeometry(g, srid), true
+	}
+	return nil, false
+}
+
+// isHexWKB 判断是否为 PostGIS 输出的十六进制 EWKB：大写十六进制，以字节序标记开头
+func isHexWKB(b []byte) bool {
+	if len(b) < 18 || len(b)%2 != 0 || b[0] != '0' || (b[1] != '0' && b[1] != '1') {
+		return false
+	}
+	for _, c := range b {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// wkbReader 读取 WKB/EWKB
+type wkbReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *wkbReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (r *wkbReader) uint32(order binary.ByteOrder) uint32 {
+	if r.err != nil || r.pos+4 > len(r.b) {
+		r.fail("unexpected end of WKB")
+		return 0
+	}
+	v := order.Uint32(r.b[r.pos:])
+	r.pos += 4
+	return v
+}
+
+// count 读取元素个数，并按每个元素的最小字节数检查剩余长度，避免错误数据导致大量分配
+func (r *wkbReader) count(order binary.ByteOrder, minSize int) int {
+	n := int(r.uint32(order))
+	if r.err == nil && n*minSize > len(r.b)-r.pos {
+		r.fail("invalid element count %d", n)
+		return 0
+	}
+	return n
+}
+
+func (r *wkbReader) coords(order binary.ByteOrder, n, dims int) [][]float64 {
+	if r.err != nil || r.pos+n*dims*8 > len(r.b) {
+		r.fail("unexpected end of WKB")
+		return nil
+	}
+	list := make([][]float64, n)
+	for i := range list {
+		c := make([]float64, dims)
+		for k := range c {
+			c[k] = math.Float64frombits(order.Uint64(r.b[r.pos:]))
+			r.pos += 8
+		}
+		list[i] = c
+	}
+	return list
+}
+
+// geometry 读取一个几何对象，返回其中的 SRID（没有时为 0）
+func (r *wkbReader) geometry(depth int) (*geometry, int) {
+	if depth > geometryMaxDepth {
+		r.fail("geometry nested too deeply")
+		return nil, 0
+	}
+	if r.pos >= len(r.b) {
+		r.fail("unexpected end of WKB")
+		return nil, 0
+	}
+	var order binary.ByteOrder
+	switch r.b[r.pos] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		r.fail("invalid byte order")
+		return nil, 0
+	}
+	r.pos++
+
+	t := r.uint32(order)
+	g := &geometry{hasZ: t&ewkbZ != 0, hasM: t&ewkbM != 0}
+	srid := 0
+	if t&ewkbSRID != 0 {
+		srid = int(r.uint32(order))
+	}
+	base := int(t & 0x0FFFFFFF)
+	// ISO WKB 使用 1000/2000/3000 表示 Z、M、ZM
+	switch base / 1000 {
+	case 1:
+		g.hasZ = true
+	case 2:
+		g.hasM = true
+	case 3:
+		g.hasZ, g.hasM = true, true
+	}
+	g.typ = base % 1000
+	if r.err != nil || g.typ < geomPoint || g.typ > geomCollection || base >= 4000 {
+		r.fail("invalid geometry type %d", t)
+		return nil, 0
+	}
+
+	dims := g.dims()
+	switch g.typ {
+	case geomPoint:
+		c := r.coords(order, 1, dims)
+		// 空点的坐标为 NaN
+		if r.err == nil && !(math.IsNaN(c[0][0]) && math.IsNaN(c[0][1])) {
+			g.coords = c
+		}
+	case geomLineString:
+		g.coords = r.coords(order, r.count(order, dims*8), dims)
+	case geomPolygon:
+		n := r.count(order, 4)
+		for i := 0; i < n && r.err == nil; i++ {
+			g.rings = append(g.rings, r.coords(order, r.count(order, dims*8), dims))
+		}
+	default:
+		n := r.count(order, 9)
+		for i := 0; i < n && r.err == nil; i++ {
+			part, _ := r.geometry(depth + 1)
+			if r.err != nil {
+				break
+			}
+			if g.typ != geomCollection && part.typ != g.typ-3 {
+				r.fail("invalid %s element", geometryTypeNames[g.typ])
+				break
+			}
+			g.parts = append(g.parts, part)
+		}
+	}
+	return g, srid
+}
+
+// parseWKB 解析 WKB/EWKB，必须恰好读完全部字节
+func parseWKB(b []byte) (*geometry, int, bool) {
+	r := &wkbReader{b: b}
+	g, srid := r.geometry(0)
+	if r.err != nil || r.pos != len(b) {
+		return nil, 0, false
+	}
+	return g, srid, true
+}
+
+// writeWKB 以小端 ISO WKB 写出几何对象，dims2 为 true 时只写 X、Y
+func writeWKB(buf []byte, g *geometry, dims2 bool) []byte {
+	t := uint32(g.typ)
+	if !dims2 {
+		switch {
+		case g.hasZ && g.hasM:
+			t += 3000
+		case g.hasZ:
+			t += 1000
+		case g.hasM:
+			t += 2000
+		}
+	}
+	buf = append(buf, 1)
+	buf = binary.LittleEndian.AppendUint32(buf, t)
+
+	dims := g.dims()
+	if dims2 {
+		dims = 2
+	}
+	writeCoords := func(coords [][]float64) {
+		for _, c := range coords {
+			for k := 0; k < dims; k++ {
+				buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(c[k]))
+			}
+		}
+	}
+	switch g.typ {
+	case geomPoint:
+		if len(g.coords) == 0 {
+			for k := 0; k < dims; k++ {
+				buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(math.NaN()))
+			}
+		} else {
+			writeCoords(g.coords)
+		}
+	case geomLineString:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g.coords)))
+		writeCoords(g.coords)
+	case geomPolygon:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g.rings)))
+		for _, ring := range g.rings {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(ring)))
+			writeCoords(ring)
+		}
+	default:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g.parts)))
+		for _, part := range g.parts {
+			buf = writeWKB(buf, part, dims2)
+		}
+	}
+	return buf
+}
+
+// MySQLGeometry 返回 MySQL 内部格式的空间值（4 字节小端 SRID + WKB）。MySQL 只保存 X、Y，Z 和 M 坐标会被丢弃
+func (g *Geometry) MySQLGeometry() []byte {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(g.SRID))
+	return writeWKB(buf, g.geom, true)
+}
+
+// geometryWKT 生成 WKT，格式与 PostGIS 的 ST_AsText 一致
+func geometryWKT(g *geometry) string {
+	var sb strings.Builder
+	writeWKT(&sb, g, true)
+	return sb.String()
+}
+
+func writeWKT(sb *strings.Builder, g *geometry, tagged bool) {
+	if tagged {
+		sb.WriteString(strings.ToUpper(geometryTypeNames[g.typ]))
+		switch {
+		case g.hasZ && g.hasM:
+			sb.WriteString(" ZM")
+		case g.hasZ:
+			sb.WriteString(" Z")
+		case g.hasM:
+			sb.WriteString(" M")
+		}
+		if g.empty() {
+			sb.WriteString(" EMPTY")
+			return
+		}
+		if g.hasZ || g.hasM {
+			sb.WriteByte(' ')
+		}
+	} else if g.empty() {
+		sb.WriteString("EMPTY")
+		return
+	}
+
+	coords := func(list [][]float64) {
+		sb.WriteByte('(')
+		for i, c := range list {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			for k, v := range c {
+				if k > 0 {
+					sb.WriteByte(' ')
+				}
+				sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+			}
+		}
+		sb.WriteByte(')')
+	}
+	switch g.typ {
+	case geomPoint, geomLineString:
+		coords(g.coords)
+	case geomPolygon:
+		sb.WriteByte('(')
+		for i, ring := range g.rings {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			coords(ring)
+		}
+		sb.WriteByte(')')
+	case geomMultiPoint:
+		// 与 ST_AsText 相同，点不加括号
+		points := make([][]float64, 0, len(g.parts))
+		for _, p := range g.parts {
+			if len(p.coords) > 0 {
+				points = append(points, p.coords[0])
+			}
+		}
+		coords(points)
+	default:
+		sb.WriteByte('(')
+		for i, part := range g.parts {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeWKT(sb, part, g.typ == geomCollection)
+		}
+		sb.WriteByte(')')
+	}
+}
+
+// geoJSON GeoJSON geometry 对象，使 type 写在最前面
+type geoJSON struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates,omitempty"`
+	Geometries  interface{} `json:"geometries,omitempty"`
+}
+
+// geoJSONObject 返回 GeoJSON geometry 对象，坐标只保留 X、Y、Z
+func geoJSONObject(g *geometry) geoJSON {
+	position := func(c []float64) []float64 {
+		if g.hasZ {
+			return c[:3]
+		}
+		return c[:2]
+	}
+	positions := func(list [][]float64) [][]float64 {
+		out := make([][]float64, len(list))
+		for i, c := range list {
+			out[i] = position(c)
+		}
+		return out
+	}
+
+	obj := geoJSON{Type: geometryTypeNames[g.typ]}
+	switch g.typ {
+	case geomPoint:
+		if len(g.coords) == 0 {
+			obj.Coordinates = []float64{}
+		} else {
+			obj.Coordinates = position(g.coords[0])
+		}
+	case geomLineString:
+		obj.Coordinates = positions(g.coords)
+	case geomPolygon:
+		rings := make([][][]float64, len(g.rings))
+		for i, ring := range g.rings {
+			rings[i] = positions(ring)
+		}
+		obj.Coordinates = rings
+	case geomCollection:
+		list := make([]geoJSON, len(g.parts))
+		for i, part := range g.parts {
+			list[i] = geoJSONObject(part)
+		}
+		obj.Geometries = list
+	default:
+		list := make([]interface{}, len(g.parts))
+		for i, part := range g.parts {
+			list[i] = geoJSONObject(part).Coordinates
+		}
+		obj.Coordinates = list
+	}
+	return obj
+}
+
+// wktPrefix 匹配 WKT 或 EWKT 开头的几何类型
+var wktPrefix = regexp.MustCompile(`(?i)^\s*(SRID=\d+\s*;\s*)?(POINT|LINESTRING|POLYGON|MULTIPOINT|MULTILINESTRING|MULTIPOLYGON|GEOMETRYCOLLECTION)\b`)
+
+// LooksLikeWKT 判断文本是否以 WKT 几何类型开头
+func LooksLikeWKT(text string) bool {
+	return wktPrefix.MatchString(text)
+}
+
+// ParseWKT 解析 WKT 或带 SRID=n; 前缀的 EWKT
+func ParseWKT(text string) (*Geometry, error) {
+	p := &wktParser{s: text}
+	srid := 0
+	p.space()
+	if len(p.s)-p.pos >= 5 && strings.EqualFold(p.s[p.pos:p.pos+5], "SRID=") {
+		p.pos += 5
+		end := strings.IndexByte(p.s[p.pos:], ';')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid SRID prefix")
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(p.s[p.pos : p.pos+end]))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid SRID prefix")
+		}
+		srid = n
+		p.pos += end + 1
+	}
+	g := p.geometry(0)
+	p.space()
+	if p.err == nil && p.pos < len(p.s) {
+		p.fail("unexpected %q", p.s[p.pos:])
+	}
+	if p.err != nil {
+		return nil, fmt.Errorf("invalid WKT: %v", p.err)
+	}
+	return newGeometry(g, srid), nil
+}
+
+// wktParser WKT 解析器
+type wktParser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *wktParser) fail(format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (p *wktParser) space() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// peek 跳过空白后返回下一个字符，结束时为 0
+func (p *wktParser) peek() byte {
+	p.space()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *wktParser) expect(c byte) {
+	if p.peek() != c {
+		p.fail("expected %q at position %d", c, p.pos)
+		return
+	}
+	p.pos++
+}
+
+// word 读取一个由字母组成的单词并转换为大写
+func (p *wktParser) word() string {
+	p.space()
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z' || p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') {
+		p.pos++
+	}
+	return strings.ToUpper(p.s[start:p.pos])
+}
+
+// emptyOrOpen 读取 EMPTY 或左括号，为 EMPTY 时返回 true
+func (p *wktParser) emptyOrOpen() bool {
+	if c := p.peek(); c == 'E' || c == 'e' {
+		if p.word() != "EMPTY" {
+			p.fail("expected EMPTY or '(' at position %d", p.pos)
+		}
+		return true
+	}
+	p.expect('(')
+	return false
+}
+
+func (p *wktParser) number() float64 {
+	p.space()
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("0123456789+-.eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		p.fail("invalid number at position %d", start)
+	}
+	return v
+}
+
+// coord 读取一个坐标，第一个坐标决定没有声明 Z/M 时的维数
+func (p *wktParser) coord(g *geometry) []float64 {
+	var c []float64
+	for p.err == nil && len(c) < 4 {
+		c = append(c, p.number())
+		if ch := p.peek(); ch == ',' || ch == ')' || ch == 0 {
+			break
+		}
+	}
+	if p.err != nil {
+		return nil
+	}
+	if len(c) < 2 {
+		p.fail("coordinate needs at least 2 values at position %d", p.pos)
+		return nil
+	}
+	if !g.fixed {
+		// 未声明维数时按第一个坐标的个数推断，3 个值为 Z
+		g.hasZ, g.hasM, g.fixed = len(c) >= 3, len(c) == 4, true
+	}
+	if len(c) != g.dims() {
+		p.fail("inconsistent coordinate dimension at position %d", p.pos)
+		return nil
+	}
+	return c
+}
+
+// coordList 读取括号中的坐标列表
+func (p *wktParser) coordList(g *geometry) [][]float64 {
+	if p.emptyOrOpen() {
+		return nil
+	}
+	var list [][]float64
+	for p.err == nil {
+		list = append(list, p.coord(g))
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	p.expect(')')
+	return list
+}
+
+// rings 读取多边形的环
+func (p *wktParser) rings(g *geometry) [][][]float64 {
+	if p.emptyOrOpen() {
+		return nil
+	}
+	var rings [][][]float64
+	for p.err == nil {
+		ring := p.coordList(g)
+		if len(ring) > 0 && len(ring) < 4 {
+			p.fail("polygon ring needs at least 4 points")
+		}
+		rings = append(rings, ring)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	p.expect(')')
+	return rings
+}
+
+// geometry 读取带类型名的几何对象
+func (p *wktParser) geometry(depth int) *geometry {
+	if depth > geometryMaxDepth {
+		p.fail("geometry nested too deeply")
+		return nil
+	}
+	name := p.word()
+	g := &geometry{}
+	for i, n := range geometryTypeNames {
+		if i > 0 && strings.ToUpper(n) == name {
+			g.typ = i
+		}
+	}
+	if g.typ == 0 {
+		p.fail("unknown geometry type %q", name)
+		return nil
+	}
+	// 可选的维数声明 Z、M、ZM
+	if c := p.peek(); c == 'Z' || c == 'z' || c == 'M' || c == 'm' {
+		switch p.word() {
+		case "Z":
+			g.hasZ = true
+		case "M":
+			g.hasM = true
+		case "ZM":
+			g.hasZ, g.hasM = true, true
+		default:
+			p.fail("invalid dimension at position %d", p.pos)
+		}
+		g.fixed = true
+	}
+	p.body(g, depth)
+	return g
+}
+
+// body 按类型读取几何对象的内容
+func (p *wktParser) body(g *geometry, depth int) {
+	switch g.typ {
+	case geomPoint:
+		list := p.coordList(g)
+		if len(list) > 1 {
+			p.fail("point has more than one coordinate")
+		}
+		g.coords = list
+	case geomLineString:
+		g.coords = p.coordList(g)
+		if len(g.coords) == 1 {
+			p.fail("linestring needs at least 2 points")
+		}
+	case geomPolygon:
+		g.rings = p.rings(g)
+	default:
+		if p.emptyOrOpen() {
+			return
+		}
+		for p.err == nil {
+			var part *geometry
+			if g.typ == geomCollection {
+				part = p.geometry(depth + 1)
+			} else {
+				part = &geometry{typ: g.typ - 3, hasZ: g.hasZ, hasM: g.hasM, fixed: g.fixed}
+				if g.typ == geomMultiPoint && p.peek() != '(' && p.peek() != 'E' && p.peek() != 'e' {
+					// MULTIPOINT(1 2, 3 4) 的点可以不加括号
+					part.coords = [][]float64{p.coord(part)}
+				} else {
+					p.body(part, depth+1)
+				}
+				g.hasZ, g.hasM, g.fixed = part.hasZ, part.hasM, part.fixed
+			}
+			if p.err != nil {
+				return
+			}
+			g.parts = append(g.parts, part)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		p.expect(')')
+	}
+}
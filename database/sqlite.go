@@ -1,9 +1,14 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -26,11 +31,16 @@ func NewSQLiteAdapter(config DatabaseConfig) *SQLiteAdapter {
 
 // Connect 连接数据库
 func (a *SQLiteAdapter) Connect() error {
-	// SQLite 直接使用文件路径
-	db, err := sqlx.Connect("sqlite3", a.config.Database)
+	// SQLite 直接使用文件路径，每个新连接都会附加数据库并执行连接级 PRAGMA
+	connector, err := newSQLiteConnector(a.config)
 	if err != nil {
 		return err
 	}
+	db := sqlx.NewDb(sql.OpenDB(connector), "sqlite3")
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return err
+	}
 
 	a.db = db
 	// SQLite 特殊设置：只允许一个连接
@@ -52,9 +62,17 @@ func (a *SQLiteAdapter) GetDatabases() ([]DatabaseInfo, error) {
 	return []DatabaseInfo{{Name: "main"}}, nil
 }
 
-// GetSchemas 获取指定数据库的所有schema（SQLite没有schema概念）
+// GetSchemas 通过 PRAGMA database_list 获取 main 及附加的数据库，不包括 temp
 func (a *SQLiteAdapter) GetSchemas(dbName string) ([]SchemaInfo, error) {
-	return []SchemaInfo{{Name: "main"}}, nil
+	files, err := a.GetDatabaseFiles()
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]SchemaInfo, 0, len(files))
+	for _, f := range files {
+		schemas = append(schemas, SchemaInfo{Name: f.Name})
+	}
+	return schemas, nil
 }
 
 // GetTables 获取指定schema的所有表
@@ -64,12 +82,16 @@ func (a *SQLiteAdapter) GetTables(dbName, schema string) ([]TableInfo, error) {
 		return nil, err
 	}
 
+	master := "sqlite_master"
+	if schema != "" {
+		master = DialectOf("sqlite").QuoteIdent(schema) + ".sqlite_master"
+	}
 	query := `
 		SELECT 
 			name,
 			'' as comment
 		FROM 
-			sqlite_master 
+			` + master + ` 
 		WHERE 
 			type='table' 
 		AND 
@@ -94,12 +116,17 @@ func (a *SQLiteAdapter) GetTables(dbName, schema string) ([]TableInfo, error) {
 
 // GetTableColumns 获取表结构
 func (a *SQLiteAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo, error) {
+	return a.GetSchemaTableColumns("", tableName)
+}
+
+// GetSchemaTableColumns 获取附加数据库 schema 中表的结构，schema 为空时按搜索顺序查找表
+func (a *SQLiteAdapter) GetSchemaTableColumns(schema, tableName string) ([]ColumnInfo, error) {
 	db, err := a.DB()
 	if err != nil {
 		return nil, err
 	}
 
-	query := fmt.Sprintf("PRAGMA table_info(%s)", tableName)
+	query := "PRAGMA " + sqliteSchemaPrefix(schema) + "table_info(" + DialectOf("sqlite").QuoteString(tableName) + ")"
 	rows, err := db.Queryx(query)
 	if err != nil {
 		return nil, err
@@ -122,7 +149,7 @@ func (a *SQLiteAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo,
 			Name:      name,
 			Type:      type_,
 			Nullable:  notnull == 0,
-			IsPrimary: pk == 1,
+			IsPrimary: pk > 0,
 		}
 
 		if matches := regexp.MustCompile(`(\w+)\((\d+)\)`).FindStringSubmatch(type_); len(matches) == 3 {
@@ -138,14 +165,34 @@ func (a *SQLiteAdapter) GetTableColumns(dbName, tableName string) ([]ColumnInfo,
 	return columns, nil
 }
 
-// GetTableRowCount 获取表行数
-func (a *SQLiteAdapter) GetTableRowCount(dbName, tableName string) (int64, error) {
+// GetRoutines SQLite不支持存储过程
+func (a *SQLiteAdapter) GetRoutines(dbName, schema string) ([]RoutineInfo, error) {
+	return nil, nil
+}
+
+// GetTableDDL 获取建表语句
+func (a *SQLiteAdapter) GetTableDDL(dbName, tableName string) (string, error) {
+	db, err := a.DB()
+	if err != nil {
+		return "", err
+	}
+
+	var ddl string
+	query := "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if err := db.Get(&ddl, query, tableName); err != nil {
+		return "", err
+	}
+	return ddl + ";", nil
+}
+
+// GetTableRowCount 获取表行数，where 为过滤条件
+func (a *SQLiteAdapter) GetTableRowCount(dbName, tableName, where string) (int64, error) {
 	db, err := a.DB()
 	if err != nil {
 		return 0, err
 	}
 
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", tableName, WhereClause(where))
 	var count int64
 	err = db.QueryRowx(query).Scan(&count)
 	if err != nil {
@@ -155,20 +202,24 @@ func (a *SQLiteAdapter) GetTableRowCount(dbName, tableName string) (int64, error
 	return count, nil
 }
 
-// QueryTableData 查询表数据
-func (a *SQLiteAdapter) QueryTableData(dbName, tableName string, offset, limit int) ([]map[string]string, error) {
+// QueryTableData 查询表数据，where 为过滤条件
+func (a *SQLiteAdapter) QueryTableData(dbName, tableName, where string, offset, limit int) ([]map[string]string, error) {
 	db, err := a.DB()
 	if err != nil {
 		return nil, err
 	}
 
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT ? OFFSET ?", tableName)
+	query := fmt.Sprintf("SELECT * FROM %s%s LIMIT ? OFFSET ?", tableName, WhereClause(where))
 	rows, err := db.Queryx(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	types, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
 	var result []map[string]string
 	for rows.Next() {
 		row := make(map[string]interface{})
@@ -184,7 +235,7 @@ func (a *SQLiteAdapter) QueryTableData(dbName, tableName string, offset, limit i
 			} else {
 				switch v := v.(type) {
 				case []byte:
-					strRow[k] = string(v)
+					strRow[k] = BytesText(types[k], v)
 				default:
 					strRow[k] = fmt.Sprintf("%v", v)
 				}
@@ -204,9 +255,34 @@ func (a *SQLiteAdapter) Ping() error {
 	return a.db.Ping()
 }
 
-// CreateDatabase SQLite不需要创建数据库
+// CreateDatabase 创建新的数据库文件，name 为文件路径，相对路径相对于当前数据库文件所在目录。
+// charset 为文本编码（UTF-8、UTF-16le、UTF-16be），文件已存在时返回错误
 func (a *SQLiteAdapter) CreateDatabase(name string, charset string, collation string) error {
-	return nil
+	path := name
+	if !filepath.IsAbs(path) && a.config.Database != "" {
+		path = filepath.Join(filepath.Dir(a.config.Database), path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("file already exists: %s", path)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if charset != "" {
+		if !sqliteEncodings[strings.ToUpper(charset)] {
+			return fmt.Errorf("unsupported encoding: %s", charset)
+		}
+		if _, err := db.Exec("PRAGMA encoding = " + DialectOf("sqlite").QuoteString(charset)); err != nil {
+			return err
+		}
+	}
+	// 空数据库在第一次写入时才会创建文件
+	_, err = db.Exec("PRAGMA user_version = 0")
+	return err
 }
 
 // GetCharsets SQLite只支持UTF-8
@@ -234,6 +310,10 @@ func (a *SQLiteAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string, e
 	}
 	defer rows.Close()
 
+	types, err := columnTypeNames(rows)
+	if err != nil {
+		return nil, err
+	}
 	var result []map[string]string
 	for rows.Next() {
 		row := make(map[string]interface{})
@@ -250,7 +330,7 @@ func (a *SQLiteAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string, e
 			} else {
 				switch v := v.(type) {
 				case []byte:
-					strRow[k] = string(v)
+					strRow[k] = BytesText(types[k], v)
 				default:
 					strRow[k] = fmt.Sprintf("%v", v)
 				}
@@ -261,3 +341,154 @@ func (a *SQLiteAdapter) ExecuteQuery(dbName, sql string) ([]map[string]string, e
 
 	return result, nil
 }
+
+// ExecuteQueryArgs 使用驱动参数执行单条SQL
+func (a *SQLiteAdapter) ExecuteQueryArgs(dbName, query string, args []interface{}) ([]map[string]string, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStringRows(rows)
+}
+
+// StreamQuery 流式执行查询，逐行写入 sink
+func (a *SQLiteAdapter) StreamQuery(ctx context.Context, dbName, query string, args []interface{}, sink RowSink) error {
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return streamRows(rows, sink)
+}
+
+// Conn 从连接池中获取一个独占连接
+func (a *SQLiteAdapter) Conn(ctx context.Context, dbName string) (*sqlx.Conn, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	return db.Connx(ctx)
+}
+
+// sqliteTableNames 获取 schema 中的用户表名，schema 为空时为 main
+func (a *SQLiteAdapter) sqliteTableNames(schema string) ([]string, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = db.Select(&names, "SELECT name FROM "+sqliteSchemaPrefix(schema)+"sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	return names, err
+}
+
+// GetIndexes 获取所有表的索引
+func (a *SQLiteAdapter) GetIndexes(dbName, schema string) ([]IndexInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	tables, err := a.sqliteTableNames(schema)
+	if err != nil {
+		return nil, err
+	}
+	schema = sqliteSchemaOrMain(schema)
+
+	var indexes []IndexInfo
+	for _, table := range tables {
+		rows, err := db.Queryx("SELECT name, \"unique\", origin FROM pragma_index_list(?, ?) ORDER BY name", table, schema)
+		if err != nil {
+			return nil, err
+		}
+		var list []IndexInfo
+		for rows.Next() {
+			var idx IndexInfo
+			var unique int
+			var origin string
+			if err := rows.Scan(&idx.Name, &unique, &origin); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			idx.Table = table
+			idx.Unique = unique == 1
+			idx.Primary = origin == "pk"
+			idx.Constraint = origin != "c"
+			list = append(list, idx)
+		}
+		rows.Close()
+
+		for _, idx := range list {
+			// 表达式索引的列名为空
+			var columns []sql.NullString
+			if err := db.Select(&columns, "SELECT name FROM pragma_index_info(?, ?) ORDER BY seqno", idx.Name, schema); err != nil {
+				return nil, err
+			}
+			for _, c := range columns {
+				idx.Columns = append(idx.Columns, c.String)
+			}
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes, nil
+}
+
+// GetForeignKeys 获取所有表的外键，SQLite 的外键没有名称
+func (a *SQLiteAdapter) GetForeignKeys(dbName, schema string) ([]ForeignKeyInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	tables, err := a.sqliteTableNames(schema)
+	if err != nil {
+		return nil, err
+	}
+	schema = sqliteSchemaOrMain(schema)
+
+	var keys []ForeignKeyInfo
+	for _, table := range tables {
+		rows, err := db.Queryx(`SELECT id, "table", "from", "to", on_update, on_delete FROM pragma_foreign_key_list(?, ?) ORDER BY id, seq`, table, schema)
+		if err != nil {
+			return nil, err
+		}
+		lastID := -1
+		for rows.Next() {
+			var id int
+			var refTable, from string
+			var to sql.NullString
+			var onUpdate, onDelete string
+			if err := rows.Scan(&id, &refTable, &from, &to, &onUpdate, &onDelete); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if id == lastID {
+				fk := &keys[len(keys)-1]
+				fk.Columns = append(fk.Columns, from)
+				fk.RefColumns = append(fk.RefColumns, to.String)
+				continue
+			}
+			lastID = id
+			keys = append(keys, ForeignKeyInfo{
+				Table:      table,
+				Columns:    []string{from},
+				RefTable:   refTable,
+				RefColumns: []string{to.String},
+				OnDelete:   onDelete,
+				OnUpdate:   onUpdate,
+			})
+		}
+		rows.Close()
+	}
+	return keys, nil
+}
@@ -0,0 +1,403 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// AttachedDatabase SQLite 附加的数据库文件，Name 为 ATTACH 时使用的 schema 名
+type AttachedDatabase struct {
+	Name     string `json:"Name"`
+	Path     string `json:"Path"`
+	ReadOnly bool   `json:"ReadOnly"`
+}
+
+// sqliteEncodings CREATE 时可选的文本编码
+var sqliteEncodings = map[string]bool{"UTF-8": true, "UTF-16": true, "UTF-16LE": true, "UTF-16BE": true}
+
+// sqliteEditablePragmas 可在编辑器中修改的 PRAGMA 及可选值，nil 表示取值为整数
+var sqliteEditablePragmas = map[string][]string{
+	"journal_mode": {"delete", "truncate", "persist", "memory", "wal", "off"},
+	"foreign_keys": {"off", "on"},
+	"synchronous":  {"off", "normal", "full", "extra"},
+	"auto_vacuum":  {"none", "full", "incremental"},
+	"temp_store":   {"default", "file", "memory"},
+	"page_size":    nil,
+	"cache_size":   nil,
+	"busy_timeout": nil,
+}
+
+// sqliteConnectionPragmas 只对当前连接生效的 PRAGMA，需要保存在连接配置中并在每个新连接上执行
+var sqliteConnectionPragmas = map[string]bool{
+	"foreign_keys": true, "synchronous": true, "temp_store": true, "cache_size": true, "busy_timeout": true,
+}
+
+// sqliteSchemaName 附加数据库的名称
+var sqliteSchemaName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// 在线备份每步复制的页数及步间隔，间隔让出锁以便其他连接写入
+const (
+	sqliteBackupStepPages = 1024
+	sqliteBackupStepPause = 10 * time.Millisecond
+)
+
+// SQLiteConnectionPragma 判断将 PRAGMA 设为 value 是否只对当前连接生效。journal_mode 只有 WAL 保存在数据库文件中，
+// 其他模式只作用于执行它的连接
+func SQLiteConnectionPragma(name, value string) bool {
+	return sqliteConnectionPragmas[name] || name == "journal_mode" && value != "wal"
+}
+
+// sqliteSchemaPrefix 返回 schema 限定前缀，schema 为空时为空，按 SQLite 的搜索顺序查找
+func sqliteSchemaPrefix(schema string) string {
+	if schema == "" {
+		return ""
+	}
+	return DialectOf("sqlite").QuoteIdent(schema) + "."
+}
+
+// sqliteSchemaOrMain 返回 schema，为空时返回 main，用于 pragma_index_list 等表值函数的 schema 参数
+func sqliteSchemaOrMain(schema string) string {
+	if schema == "" {
+		return "main"
+	}
+	return schema
+}
+
+// sqliteDSN 返回打开文件使用的 DSN，只读时使用 URI 形式的 mode=ro
+func sqliteDSN(path string, readOnly bool) string {
+	if !readOnly {
+		return path
+	}
+	return "file:" + (&url.URL{Path: filepath.ToSlash(path)}).EscapedPath() + "?mode=ro"
+}
+
+// normalizeSQLitePragma 校验 PRAGMA 名称和取值，返回可直接拼接到语句中的值
+func normalizeSQLitePragma(name, value string) (string, error) {
+	options, ok := sqliteEditablePragmas[name]
+	if !ok {
+		return "", fmt.Errorf("pragma %s is not editable", name)
+	}
+	value = strings.ToLower(strings.TrimSpace(value))
+	n, err := strconv.Atoi(value)
+	if options == nil {
+		if err != nil {
+			return "", fmt.Errorf("pragma %s requires an integer value", name)
+		}
+		if name == "page_size" && (n < 512 || n > 65536 || n&(n-1) != 0) {
+			return "", fmt.Errorf("page_size must be a power of two between 512 and 65536")
+		}
+		return value, nil
+	}
+	// 枚举类 PRAGMA 也接受序号，journal_mode 除外
+	if err == nil && name != "journal_mode" && n >= 0 && n < len(options) {
+		return value, nil
+	}
+	for _, opt := range options {
+		if value == opt {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value for pragma %s: %s", name, value)
+}
+
+// sqliteConnector 为每个新连接附加数据库并执行连接级 PRAGMA
+type sqliteConnector struct {
+	driver *sqlite3.SQLiteDriver
+	dsn    string
+}
+
+func newSQLiteConnector(config DatabaseConfig) (*sqliteConnector, error) {
+	var setup []string
+	for _, att := range config.Attached {
+		if !sqliteSchemaName.MatchString(att.Name) {
+			return nil, fmt.Errorf("invalid attached database name: %s", att.Name)
+		}
+		setup = append(setup, "ATTACH DATABASE "+DialectOf("sqlite").QuoteString(sqliteDSN(att.Path, att.ReadOnly))+" AS "+att.Name)
+	}
+	for name, value := range config.Pragmas {
+		v, err := normalizeSQLitePragma(name, value)
+		if err != nil {
+			return nil, err
+		}
+		setup = append(setup, "PRAGMA "+name+" = "+v)
+	}
+
+	return &sqliteConnector{
+		dsn: sqliteDSN(config.Database, config.ReadOnly),
+		driver: &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				for _, stmt := range setup {
+					if _, err := conn.Exec(stmt, nil); err != nil {
+						return fmt.Errorf("%s: %v", stmt, err)
+					}
+				}
+				return nil
+			},
+		},
+	}, nil
+}
+
+func (c *sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *sqliteConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Config 返回适配器当前使用的连接配置，附加数据库后用于保存
+func (a *SQLiteAdapter) Config() DatabaseConfig {
+	return a.config
+}
+
+// GetDatabaseFiles 通过 PRAGMA database_list 获取 main 及附加的数据库文件，不包括 temp
+func (a *SQLiteAdapter) GetDatabaseFiles() ([]AttachedDatabase, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	var list []struct {
+		Seq  int    `db:"seq"`
+		Name string `db:"name"`
+		File string `db:"file"`
+	}
+	if err := db.Select(&list, "PRAGMA database_list"); err != nil {
+		return nil, err
+	}
+
+	readOnly := map[string]bool{"main": a.config.ReadOnly}
+	for _, att := range a.config.Attached {
+		readOnly[att.Name] = att.ReadOnly
+	}
+	files := []AttachedDatabase{}
+	for _, d := range list {
+		if d.Name == "temp" {
+			continue
+		}
+		files = append(files, AttachedDatabase{Name: d.Name, Path: d.File, ReadOnly: readOnly[d.Name]})
+	}
+	return files, nil
+}
+
+// Attach 附加数据库文件并重新建立连接，之后的每个连接都会附加该文件。
+// 文件不存在时返回错误，可先用 CreateDatabase 创建
+func (a *SQLiteAdapter) Attach(name, path string, readOnly bool) error {
+	if !sqliteSchemaName.MatchString(name) {
+		return fmt.Errorf("invalid attached database name: %s", name)
+	}
+	if strings.EqualFold(name, "main") || strings.EqualFold(name, "temp") {
+		return fmt.Errorf("%s is reserved", name)
+	}
+	for _, att := range a.config.Attached {
+		if strings.EqualFold(att.Name, name) {
+			return fmt.Errorf("database %s is already attached", name)
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	config := a.config
+	config.Attached = append(append([]AttachedDatabase{}, a.config.Attached...), AttachedDatabase{Name: name, Path: path, ReadOnly: readOnly})
+	return a.reconnect(config)
+}
+
+// Detach 取消附加数据库并重新建立连接
+func (a *SQLiteAdapter) Detach(name string) error {
+	config := a.config
+	config.Attached = nil
+	found := false
+	for _, att := range a.config.Attached {
+		if strings.EqualFold(att.Name, name) {
+			found = true
+			continue
+		}
+		config.Attached = append(config.Attached, att)
+	}
+	if !found {
+		return fmt.Errorf("database %s is not attached", name)
+	}
+	return a.reconnect(config)
+}
+
+// reconnect 使用新的配置重新建立连接，失败时保留原连接
+func (a *SQLiteAdapter) reconnect(config DatabaseConfig) error {
+	old, oldDB := a.config, a.db
+	a.config, a.db = config, nil
+	if err := a.Connect(); err != nil {
+		a.config, a.db = old, oldDB
+		return err
+	}
+	if oldDB != nil {
+		oldDB.Close()
+	}
+	return nil
+}
+
+// SetPragma 修改 PRAGMA 并返回修改后的值。数据库级 PRAGMA 只作用于 main，修改 page_size 或 auto_vacuum 后执行 VACUUM
+// 使其生效（WAL 模式下二者均无法修改）；连接级 PRAGMA（包括 WAL 以外的 journal_mode）同时记录在配置中，通过 Config 获取
+func (a *SQLiteAdapter) SetPragma(name, value string) (string, error) {
+	v, err := normalizeSQLitePragma(name, value)
+	if err != nil {
+		return "", err
+	}
+	if _, err := a.DB(); err != nil {
+		return "", err
+	}
+
+	// 连接级 PRAGMA 在每个新连接上执行；改为数据库级取值时从配置中移除，避免新连接改回原来的值
+	connLevel := SQLiteConnectionPragma(name, v)
+	if _, saved := a.config.Pragmas[name]; connLevel || saved {
+		pragmas := make(map[string]string, len(a.config.Pragmas)+1)
+		for k, val := range a.config.Pragmas {
+			pragmas[k] = val
+		}
+		if connLevel {
+			pragmas[name] = v
+		} else {
+			delete(pragmas, name)
+		}
+		config := a.config
+		config.Pragmas = pragmas
+		if err := a.reconnect(config); err != nil {
+			return "", err
+		}
+	}
+	db := a.db
+
+	stmt := "PRAGMA " + name
+	if !connLevel {
+		// 同一连接上修改并 VACUUM
+		stmt = "PRAGMA main." + name
+		conn, err := db.Connx(context.Background())
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		if _, err := conn.ExecContext(context.Background(), stmt+" = "+v); err != nil {
+			return "", err
+		}
+		if name == "page_size" || name == "auto_vacuum" {
+			if _, err := conn.ExecContext(context.Background(), "VACUUM"); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var current string
+	if err := db.Get(&current, stmt); err != nil {
+		return "", err
+	}
+	return current, nil
+}
+
+// Backup 使用 SQLite 在线备份 API 将 schema（默认 main）复制到 dest，复制期间其他连接仍可读写。
+// dest 已存在时会被覆盖，progress 的参数为已复制页数和总页数
+func (a *SQLiteAdapter) Backup(ctx context.Context, schema, dest string, progress func(done, total int)) error {
+	if schema == "" {
+		schema = "main"
+	}
+	if err := a.checkCopyTarget(schema, dest); err != nil {
+		return err
+	}
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+	src, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	target, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+	dst, err := target.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	return dst.Raw(func(dc interface{}) error {
+		return src.Raw(func(sc interface{}) error {
+			b, err := dc.(*sqlite3.SQLiteConn).Backup("main", sc.(*sqlite3.SQLiteConn), schema)
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := b.Step(sqliteBackupStepPages)
+				if err != nil {
+					b.Close()
+					return err
+				}
+				if progress != nil {
+					progress(b.PageCount()-b.Remaining(), b.PageCount())
+				}
+				if done {
+					return b.Finish()
+				}
+				select {
+				case <-ctx.Done():
+					b.Close()
+					return ctx.Err()
+				case <-time.After(sqliteBackupStepPause):
+				}
+			}
+		})
+	})
+}
+
+// VacuumInto 使用 VACUUM INTO 将 schema（默认 main）压缩复制到新文件，dest 不能已存在
+func (a *SQLiteAdapter) VacuumInto(ctx context.Context, schema, dest string) error {
+	if schema == "" {
+		schema = "main"
+	}
+	if err := a.checkCopyTarget(schema, dest); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("file already exists: %s", dest)
+	}
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+	d := DialectOf("sqlite")
+	_, err = db.ExecContext(ctx, "VACUUM "+d.QuoteIdent(schema)+" INTO "+d.QuoteString(dest))
+	return err
+}
+
+// checkCopyTarget 防止把数据库复制到自身
+func (a *SQLiteAdapter) checkCopyTarget(schema, dest string) error {
+	if dest == "" {
+		return fmt.Errorf("destination file is required")
+	}
+	files, err := a.GetDatabaseFiles()
+	if err != nil {
+		return err
+	}
+	target, _ := filepath.Abs(dest)
+	for _, f := range files {
+		if f.Path == "" {
+			continue
+		}
+		if path, _ := filepath.Abs(f.Path); path == target {
+			return fmt.Errorf("destination is the same file as database %s", f.Name)
+		}
+	}
+	return nil
+}
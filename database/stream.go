@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ResultColumn 结果集列信息
+type ResultColumn struct {
+	Name         string `json:"Name"`
+	DatabaseType string `json:"DatabaseType"`
+	// Nullable 驱动无法判断时为 true
+	Nullable bool `json:"Nullable"`
+	// Kind 值分类，见 ColumnKind
+	Kind string `json:"Kind"`
+}
+
+// RowSink 接收流式查询结果，Begin 在第一行之前调用一次
+type RowSink interface {
+	Begin(columns []ResultColumn) error
+	Row(values []interface{}) error
+}
+
+// ProgressFunc 进度回调，参数为已处理的行数
+type ProgressFunc func(rows int64)
+
+// progressSink 统计行数并检查取消的 RowSink 包装
+type progressSink struct {
+	ctx      context.Context
+	sink     RowSink
+	rows     int64
+	progress ProgressFunc
+}
+
+// WithProgress 包装 RowSink，每行回调一次进度并在 ctx 取消时中止
+func WithProgress(ctx context.Context, sink RowSink, progress ProgressFunc) RowSink {
+	return &progressSink{ctx: ctx, sink: sink, progress: progress}
+}
+
+func (s *progressSink) Begin(columns []ResultColumn) error {
+	return s.sink.Begin(columns)
+}
+
+func (s *progressSink) Row(values []interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.sink.Row(values); err != nil {
+		return err
+	}
+	s.rows++
+	if s.progress != nil {
+		s.progress(s.rows)
+	}
+	return nil
+}
+
+// streamRows 逐行读取结果集并写入 sink
+func streamRows(rows *sql.Rows, sink RowSink) error {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	columns := make([]ResultColumn, len(types))
+	for i, t := range types {
+		// 驱动无法判断时按可空处理
+		nullable, ok := t.Nullable()
+		if !ok {
+			nullable = true
+		}
+		columns[i] = ResultColumn{Name: t.Name(), DatabaseType: t.DatabaseTypeName(), Nullable: nullable, Kind: ColumnKind(t.DatabaseTypeName())}
+	}
+	if err := sink.Begin(columns); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if err := sink.Row(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FormatValue 将驱动返回的值转换为文本，dbType 为结果集中的列类型
+func FormatValue(dbType string, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		if g, ok := DecodeGeometry(dbType, v); ok {
+			return g.EWKT()
+		}
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		if ColumnKind(dbType) == KindDate {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05.999999")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"sort"
+)
+
+// TableStats 表的存储统计，大小单位为字节。Rows 为估算行数；
+// DeadTuples 及 Last* 字段只用于 PostgreSQL，FreeSize 为 MySQL 的 DATA_FREE；
+// SQLite 没有 dbstat 虚拟表时 DataSize、IndexSize、TotalSize 为 -1
+type TableStats struct {
+	Name           string `json:"Name"`
+	Engine         string `json:"Engine"`
+	Collation      string `json:"Collation"`
+	Rows           int64  `json:"Rows"`
+	DataSize       int64  `json:"DataSize"`
+	IndexSize      int64  `json:"IndexSize"`
+	TotalSize      int64  `json:"TotalSize"`
+	FreeSize       int64  `json:"FreeSize"`
+	AutoIncrement  int64  `json:"AutoIncrement"`
+	CreateTime     string `json:"CreateTime"`
+	UpdateTime     string `json:"UpdateTime"`
+	DeadTuples     int64  `json:"DeadTuples"`
+	LastVacuum     string `json:"LastVacuum"`
+	LastAutovacuum string `json:"LastAutovacuum"`
+	LastAnalyze    string `json:"LastAnalyze"`
+}
+
+// GetTableStats 从 information_schema.TABLES 获取表的大小、引擎等信息，按总大小倒序。
+// InnoDB 的行数为估算值，MySQL 8.0 的统计信息有缓存（information_schema_stats_expiry）
+func (a *MySQLAdapter) GetTableStats(dbName, schema string) ([]TableStats, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT TABLE_NAME, COALESCE(ENGINE, ''), COALESCE(TABLE_COLLATION, ''), COALESCE(TABLE_ROWS, 0),
+			COALESCE(DATA_LENGTH, 0), COALESCE(INDEX_LENGTH, 0), COALESCE(DATA_FREE, 0), COALESCE(AUTO_INCREMENT, 0),
+			COALESCE(CAST(CREATE_TIME AS CHAR), ''), COALESCE(CAST(UPDATE_TIME AS CHAR), '')
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0) DESC, TABLE_NAME
+	`
+	rows, err := db.Queryx(query, dbName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []TableStats{}
+	for rows.Next() {
+		var s TableStats
+		if err := rows.Scan(&s.Name, &s.Engine, &s.Collation, &s.Rows, &s.DataSize, &s.IndexSize, &s.FreeSize,
+			&s.AutoIncrement, &s.CreateTime, &s.UpdateTime); err != nil {
+			return nil, err
+		}
+		s.TotalSize = s.DataSize + s.IndexSize
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// GetTableStats 通过 pg_table_size、pg_indexes_size 和 pg_stat_user_tables 获取表的统计，按总大小倒序，
+// schema 为空时使用 public。DataSize 包含 TOAST 数据
+func (a *PostgresAdapter) GetTableStats(dbName, schema string) ([]TableStats, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	if schema == "" {
+		schema = "public"
+	}
+
+	query := `
+		SELECT c.relname, COALESCE(am.amname, ''), GREATEST(c.reltuples, 0)::bigint,
+			pg_table_size(c.oid), pg_indexes_size(c.oid), pg_total_relation_size(c.oid),
+			COALESCE(s.n_dead_tup, 0), COALESCE(s.last_vacuum::text, ''), COALESCE(s.last_autovacuum::text, ''),
+			COALESCE(GREATEST(s.last_analyze, s.last_autoanalyze)::text, '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_am am ON am.oid = c.relam
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p', 'm')
+		ORDER BY pg_total_relation_size(c.oid) DESC, c.relname
+	`
+	rows, err := db.Queryx(query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []TableStats{}
+	for rows.Next() {
+		var s TableStats
+		if err := rows.Scan(&s.Name, &s.Engine, &s.Rows, &s.DataSize, &s.IndexSize, &s.TotalSize,
+			&s.DeadTuples, &s.LastVacuum, &s.LastAutovacuum, &s.LastAnalyze); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// GetTableStats 通过 dbstat 虚拟表统计表和索引占用的页，驱动未启用 dbstat 时大小为 -1。
+// 行数优先使用 sqlite_stat1，没有统计信息时使用 COUNT(*)
+func (a *SQLiteAdapter) GetTableStats(dbName, schema string) ([]TableStats, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := sqliteSchemaPrefix(schema)
+	var tables []struct {
+		Name string `db:"name"`
+	}
+	err = db.Select(&tables, "SELECT name FROM "+prefix+"sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个 B 树（表或索引）所属的表
+	var owners []struct {
+		Name  string `db:"name"`
+		Table string `db:"tbl_name"`
+		Type  string `db:"type"`
+	}
+	if err := db.Select(&owners, "SELECT name, tbl_name, type FROM "+prefix+"sqlite_master WHERE type IN ('table', 'index')"); err != nil {
+		return nil, err
+	}
+	owner := make(map[string]struct{ table, typ string })
+	for _, o := range owners {
+		owner[o.Name] = struct{ table, typ string }{o.Table, o.Type}
+	}
+
+	dataSize := make(map[string]int64)
+	indexSize := make(map[string]int64)
+	var pages []struct {
+		Name string `db:"name"`
+		Size int64  `db:"size"`
+	}
+	hasDBStat := db.Select(&pages, "SELECT name, SUM(pgsize) AS size FROM dbstat(?) GROUP BY name", sqliteSchemaOrMain(schema)) == nil
+	for _, p := range pages {
+		o, ok := owner[p.Name]
+		if !ok {
+			continue
+		}
+		if o.typ == "index" {
+			indexSize[o.table] += p.Size
+		} else {
+			dataSize[o.table] += p.Size
+		}
+	}
+
+	estimates := make(map[string]int64)
+	// stat 的第一个数为表（或索引）的行数
+	var stat1 []struct {
+		Table string `db:"tbl"`
+		Rows  int64  `db:"n"`
+	}
+	if err := db.Select(&stat1, "SELECT tbl, MAX(CAST(stat AS INTEGER)) AS n FROM "+prefix+"sqlite_stat1 GROUP BY tbl"); err == nil {
+		for _, s := range stat1 {
+			estimates[s.Table] = s.Rows
+		}
+	}
+
+	sequences := make(map[string]int64)
+	var seqs []struct {
+		Name string `db:"name"`
+		Seq  int64  `db:"seq"`
+	}
+	if err := db.Select(&seqs, "SELECT name, seq FROM "+prefix+"sqlite_sequence"); err == nil {
+		for _, s := range seqs {
+			sequences[s.Name] = s.Seq
+		}
+	}
+
+	stats := make([]TableStats, 0, len(tables))
+	for _, t := range tables {
+		s := TableStats{Name: t.Name, DataSize: -1, IndexSize: -1, TotalSize: -1, AutoIncrement: sequences[t.Name]}
+		if hasDBStat {
+			s.DataSize, s.IndexSize = dataSize[t.Name], indexSize[t.Name]
+			s.TotalSize = s.DataSize + s.IndexSize
+		}
+		if n, ok := estimates[t.Name]; ok {
+			s.Rows = n
+		} else if err := db.Get(&s.Rows, "SELECT COUNT(*) FROM "+prefix+DialectOf("sqlite").QuoteIdent(t.Name)); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	sort.SliceStable(stats, func(i, k int) bool {
+		return stats[i].TotalSize > stats[k].TotalSize
+	})
+	return stats, nil
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"strings"
+)
+
+// TokenType 词法单元类型
+type TokenType int
+
+const (
+	TokenWhitespace TokenType = iota
+	TokenComment
+	TokenKeyword
+	TokenIdent
+	TokenQuotedIdent
+	TokenString
+	TokenNumber
+	TokenParam
+	TokenOperator
+	TokenPunct
+)
+
+// Token SQL词法单元，Start/End 为字节偏移
+type Token struct {
+	Type  TokenType
+	Text  string
+	Start int
+	End   int
+}
+
+// Value 返回标识符去掉引号后的名称
+func (t Token) Value() string {
+	if t.Type == TokenQuotedIdent && len(t.Text) >= 1 {
+		q := t.Text[:1]
+		inner := t.Text[1:]
+		if strings.HasSuffix(inner, q) {
+			inner = inner[:len(inner)-1]
+		}
+		return strings.ReplaceAll(inner, q+q, q)
+	}
+	return t.Text
+}
+
+// IsKeyword 判断是否为指定关键字（不区分大小写）
+func (t Token) IsKeyword(words ...string) bool {
+	if t.Type != TokenKeyword {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.Text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tokenize 将SQL切分为词法单元，未闭合的字符串或注释延续到文本末尾。
+// # 只在 MySQL 中是注释，PostgreSQL 中是 #>、#>> 等运算符
+func Tokenize(dialect Dialect, sql string) []Token {
+	var tokens []Token
+	n := len(sql)
+	i := 0
+	for i < n {
+		start := i
+		c := sql[i]
+		var typ TokenType
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			for i < n && strings.IndexByte(" \t\n\r\f", sql[i]) >= 0 {
+				i++
+			}
+			typ = TokenWhitespace
+		case c == '-' && i+1 < n && sql[i+1] == '-', c == '#' && dialect.Name == "mysql":
+			for i < n && sql[i] != '\n' {
+				i++
+			}
+			typ = TokenComment
+		case c == '/' && i+1 < n && sql[i+1] == '*':
+			if end := strings.Index(sql[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = n
+			}
+			typ = TokenComment
+		case c == '\'':
+			i = skipQuoted(dialect, sql, i, c) + 1
+			typ = TokenString
+		case c == '"' || c == '`':
+			i = skipQuoted(dialect, sql, i, c) + 1
+			typ = TokenQuotedIdent
+		case c == '$' && i+1 < n && (sql[i+1] == '$' || sql[i+1] == '_' || isLetter(sql[i+1])):
+			// PostgreSQL 的 $tag$...$tag$ 字符串
+			tagEnd := strings.IndexByte(sql[i+1:], '$')
+			if tagEnd == 0 || tagEnd > 0 && isIdent(sql[i+1:i+1+tagEnd]) {
+				tag := sql[i : i+tagEnd+2]
+				if end := strings.Index(sql[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag)
+				} else {
+					i = n
+				}
+				typ = TokenString
+			} else {
+				i++
+				typ = TokenOperator
+			}
+		case c == '$' && i+1 < n && sql[i+1] >= '0' && sql[i+1] <= '9', c == '?':
+			i++
+			for i < n && sql[i] >= '0' && sql[i] <= '9' {
+				i++
+			}
+			typ = TokenParam
+		case c == ':' && i+1 < n && (isLetter(sql[i+1]) || sql[i+1] == '_') && (i == 0 || sql[i-1] != ':'):
+			i++
+			for i < n && isIdentChar(sql[i]) {
+				i++
+			}
+			typ = TokenParam
+		case c >= '0' && c <= '9', c == '.' && i+1 < n && sql[i+1] >= '0' && sql[i+1] <= '9':
+			for i < n && (isIdentChar(sql[i]) || sql[i] == '.') {
+				i++
+			}
+			typ = TokenNumber
+		case isLetter(c) || c == '_' || c >= 0x80:
+			for i < n && (isIdentChar(sql[i]) || sql[i] == '$' || sql[i] >= 0x80) {
+				i++
+			}
+			typ = TokenIdent
+			if IsKeyword(sql[start:i]) {
+				typ = TokenKeyword
+			}
+		case strings.IndexByte(".,;()[]", c) >= 0:
+			i++
+			typ = TokenPunct
+		default:
+			i++
+			for i < n && strings.IndexByte("<>=!|&+-*/%^~:@", sql[i]) >= 0 && strings.IndexByte("<>=!|&:#", c) >= 0 {
+				i++
+			}
+			typ = TokenOperator
+		}
+
+		if i > n {
+			i = n
+		}
+		tokens = append(tokens, Token{Type: typ, Text: sql[start:i], Start: start, End: i})
+	}
+	return tokens
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxTransferParams 单条 INSERT 允许的最大参数个数，取三种数据库中较小的限制
+const maxTransferParams = 30000
+
+// TransferTable 需要传输的表
+type TransferTable struct {
+	Name string `json:"Name"`
+	// Target 目标表名，为空时与源表相同
+	Target string `json:"Target"`
+	// Filter 读取源数据的过滤条件，不含 WHERE 关键字
+	Filter string `json:"Filter"`
+}
+
+// TransferOptions 跨连接传输数据的选项
+type TransferOptions struct {
+	SourceDatabase string          `json:"SourceDatabase"`
+	SourceSchema   string          `json:"SourceSchema"`
+	TargetDatabase string          `json:"TargetDatabase"`
+	TargetSchema   string          `json:"TargetSchema"`
+	Tables         []TransferTable `json:"Tables"`
+	// CreateTable 为 true 时按映射后的类型创建目标表（已存在则跳过）
+	CreateTable bool `json:"CreateTable"`
+	// Truncate 为 true 时写入前清空目标表
+	Truncate bool `json:"Truncate"`
+	// Upsert 为 true 时按主键覆盖目标表中已存在的行
+	Upsert    bool `json:"Upsert"`
+	BatchSize int  `json:"BatchSize"`
+}
+
+// TransferProgress 传输进度
+type TransferProgress struct {
+	Table         string  `json:"Table"`
+	TablesDone    int     `json:"TablesDone"`
+	TablesTotal   int     `json:"TablesTotal"`
+	Rows          int64   `json:"Rows"`
+	TotalRows     int64   `json:"TotalRows"`
+	RowsPerSecond float64 `json:"RowsPerSecond"`
+	// ETASeconds 预计剩余秒数，无法估计时为 -1
+	ETASeconds int64 `json:"ETASeconds"`
+}
+
+// TransferProgressFunc 传输进度回调
+type TransferProgressFunc func(p TransferProgress)
+
+// transferPlan 一张表的传输计划
+type transferPlan struct {
+	table   TransferTable
+	columns []ColumnInfo
+}
+
+// RunTransfer 将源连接中的表按批次复制到目标连接，支持不同类型的数据库之间传输
+func RunTransfer(ctx context.Context, source DBAdapter, sourceType string, target DBAdapter, targetType string, opts TransferOptions, progress TransferProgressFunc) error {
+	if len(opts.Tables) == 0 {
+		return fmt.Errorf("no tables to transfer")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatch
+	}
+	src := DialectOf(sourceType)
+
+	// 先读取所有表的列和行数，用于计算总进度
+	report := TransferProgress{TablesTotal: len(opts.Tables), ETASeconds: -1}
+	plans := make([]transferPlan, len(opts.Tables))
+	for i, t := range opts.Tables {
+		columns, err := SchemaTableColumns(source, opts.SourceDatabase, opts.SourceSchema, t.Name)
+		if err != nil {
+			return fmt.Errorf("read columns of %s: %v", t.Name, err)
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("table %s not found", t.Name)
+		}
+		if t.Target == "" {
+			t.Target = t.Name
+		}
+		plans[i] = transferPlan{table: t, columns: columns}
+
+		countSQL := "SELECT COUNT(*) AS n FROM " + src.TableName(opts.SourceDatabase, opts.SourceSchema, t.Name) + transferWhere(t.Filter)
+		if rows, err := source.ExecuteQueryArgs(opts.SourceDatabase, countSQL, nil); err == nil && len(rows) == 1 {
+			n, _ := strconv.ParseInt(rows[0]["n"], 10, 64)
+			report.TotalRows += n
+		}
+	}
+
+	started := time.Now()
+	update := func(rows int64) {
+		if progress == nil {
+			return
+		}
+		p := report
+		p.Rows += rows
+		if elapsed := time.Since(started).Seconds(); elapsed > 0 && p.Rows > 0 {
+			p.RowsPerSecond = float64(p.Rows) / elapsed
+			if remaining := p.TotalRows - p.Rows; remaining >= 0 {
+				p.ETASeconds = int64(float64(remaining) / p.RowsPerSecond)
+			}
+		}
+		progress(p)
+	}
+
+	for _, plan := range plans {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report.Table = plan.table.Name
+		update(0)
+		rows, err := transferTable(ctx, source, src, target, DialectOf(targetType), opts, plan, update)
+		if err != nil {
+			return fmt.Errorf("transfer %s: %v", plan.table.Name, err)
+		}
+		report.Rows += rows
+		report.TablesDone++
+	}
+	report.Table = ""
+	update(0)
+	return nil
+}
+
+// transferWhere 返回过滤条件对应的 WHERE 子句
+func transferWhere(filter string) string {
+	if filter = strings.TrimSpace(filter); filter != "" {
+		return " WHERE " + filter
+	}
+	return ""
+}
+
+// transferTable 传输一张表，返回写入的行数
+func transferTable(ctx context.Context, source DBAdapter, src Dialect, target DBAdapter, dst Dialect, opts TransferOptions, plan transferPlan, update func(rows int64)) (int64, error) {
+	conn, err := target.Conn(ctx, opts.TargetDatabase)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	table := dst.TableName(opts.TargetDatabase, opts.TargetSchema, plan.table.Target)
+	var names, quoted, keys []string
+	for _, col := range plan.columns {
+		names = append(names, src.QuoteIdent(col.Name))
+		quoted = append(quoted, dst.QuoteIdent(col.Name))
+		if col.IsPrimary {
+			keys = append(keys, col.Name)
+		}
+	}
+
+	if opts.CreateTable {
+		columns := make([]ColumnInfo, len(plan.columns))
+		for i, col := range plan.columns {
+			typ := MapColumnType(dst, src.Name, col)
+			if col.IsPrimary {
+				typ = keyColumnType(dst, typ)
+			}
+			columns[i] = ColumnInfo{Name: col.Name, Type: typ, Nullable: col.Nullable, IsPrimary: col.IsPrimary}
+		}
+		if _, err := conn.ExecContext(ctx, dst.BuildCreateTable(table, columns, true)); err != nil {
+			return 0, fmt.Errorf("create table: %v", err)
+		}
+	}
+	if opts.Truncate {
+		stmt := "TRUNCATE TABLE " + table
+		if dst.Name == "sqlite" {
+			stmt = "DELETE FROM " + table
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("truncate: %v", err)
+		}
+	}
+
+	mode := InsertPlain
+	if opts.Upsert {
+		if len(keys) == 0 {
+			return 0, fmt.Errorf("upsert requires a primary key")
+		}
+		mode = InsertReplace
+	}
+	verb, suffix, err := dst.InsertClause(mode, quoted, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := opts.BatchSize
+	if batch*len(quoted) > maxTransferParams {
+		batch = maxTransferParams / len(quoted)
+	}
+	sink := &batchInserter{
+		ctx:     ctx,
+		conn:    conn,
+		dialect: dst,
+		prefix:  fmt.Sprintf("%s %s (%s) VALUES ", verb, table, strings.Join(quoted, ", ")),
+		suffix:  suffix,
+		batch:   batch,
+		update:  update,
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s%s", strings.Join(names, ", "), src.TableName(opts.SourceDatabase, opts.SourceSchema, plan.table.Name), transferWhere(plan.table.Filter))
+	if err := source.StreamQuery(ctx, opts.SourceDatabase, query, nil, sink); err != nil {
+		return sink.written, err
+	}
+	if err := sink.flush(); err != nil {
+		return sink.written, err
+	}
+	return sink.written, nil
+}
+
+// batchInserter 将源结果集按批次写入目标表的 RowSink
+type batchInserter struct {
+	ctx     context.Context
+	conn    *sqlx.Conn
+	dialect Dialect
+	prefix  string
+	suffix  string
+	batch   int
+	update  func(rows int64)
+
+	columns []ResultColumn
+	pending [][]interface{}
+	written int64
+}
+
+func (b *batchInserter) Begin(columns []ResultColumn) error {
+	b.columns = columns
+	return nil
+}
+
+func (b *batchInserter) Row(values []interface{}) error {
+	row := make([]interface{}, len(values))
+	for i, v := range values {
+		row[i] = b.convert(b.columns[i], v)
+	}
+	b.pending = append(b.pending, row)
+	if len(b.pending) >= b.batch {
+		return b.flush()
+	}
+	return nil
+}
+
+// convert 将驱动返回的值转换为适合目标库的参数
+func (b *batchInserter) convert(col ResultColumn, v interface{}) interface{} {
+	raw, ok := v.([]byte)
+	if !ok {
+		return v
+	}
+	if ColumnKind(col.DatabaseType) == KindBinary {
+		return raw
+	}
+	text := string(raw)
+	// MySQL 的零日期在其他数据库中无效
+	if b.dialect.Name != "mysql" && strings.HasPrefix(text, "0000-00-00") {
+		return nil
+	}
+	return text
+}
+
+// flush 以一条多行 INSERT 写入缓冲中的行
+func (b *batchInserter) flush() error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString(b.prefix)
+	args := make([]interface{}, 0, len(b.pending)*len(b.columns))
+	for i, row := range b.pending {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for k, v := range row {
+			if k > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, v)
+			sb.WriteString(b.dialect.Placeholder(len(args)))
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteString(b.suffix)
+	if _, err := b.conn.ExecContext(b.ctx, sb.String(), args...); err != nil {
+		return err
+	}
+	b.written += int64(len(b.pending))
+	b.pending = b.pending[:0]
+	b.update(b.written)
+	return nil
+}
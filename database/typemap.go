@@ -0,0 +1,153 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// baseType 拆分类型名和括号中的参数，并统一 PostgreSQL 的长类型名
+func baseType(t string) (string, []int) {
+	t = strings.ToLower(strings.TrimSpace(t))
+	var params []int
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		if j := strings.IndexByte(t[i:], ')'); j > 0 {
+			for _, p := range strings.Split(t[i+1:i+j], ",") {
+				if n, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+					params = append(params, n)
+				}
+			}
+			t = strings.TrimSpace(t[:i] + t[i+j+1:])
+		}
+	}
+	t = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(t, " zerofill"), " unsigned"))
+	// MySQL 驱动返回的结果集类型名形如 UNSIGNED INT
+	t = strings.TrimPrefix(t, "unsigned ")
+	switch t {
+	case "character varying":
+		t = "varchar"
+	case "character":
+		t = "char"
+	case "timestamp without time zone":
+		t = "timestamp"
+	case "timestamp with time zone":
+		t = "timestamptz"
+	case "time without time zone":
+		t = "time"
+	case "time with time zone":
+		t = "timetz"
+	case "double precision":
+		t = "double"
+	case "integer":
+		t = "int"
+	}
+	return t, params
+}
+
+// isUnsigned 判断是否为 MySQL 的无符号类型
+func isUnsigned(t string) bool {
+	return strings.Contains(strings.ToLower(t), "unsigned")
+}
+
+// keyColumnType 返回可作为主键的列类型，MySQL 的主键不能是不限长度的文本，改用 VARCHAR(255)
+func keyColumnType(target Dialect, typ string) string {
+	if target.Name == "mysql" && strings.HasSuffix(typ, "TEXT") {
+		return "VARCHAR(255)"
+	}
+	return typ
+}
+
+// MapColumnType 将源库的列类型转换为目标方言中的列类型，源和目标相同时保持原类型（MySQL 为包含无符号、
+// 枚举值等的完整列类型）。无符号整数在没有无符号类型的数据库中使用更大的类型
+func MapColumnType(target Dialect, sourceType string, col ColumnInfo) string {
+	if target.Name == sourceType {
+		return columnTypeSQL(col)
+	}
+
+	t, params := baseType(col.Type)
+	if isUnsigned(col.Type) {
+		switch t {
+		case "tinyint":
+			return target.pick("TINYINT UNSIGNED", "SMALLINT", "INTEGER")
+		case "smallint":
+			return target.pick("SMALLINT UNSIGNED", "INTEGER", "INTEGER")
+		case "mediumint", "int":
+			return target.pick("INT UNSIGNED", "BIGINT", "INTEGER")
+		case "bigint":
+			// SQLite 的整数为 64 位有符号整数，使用文本保存
+			return target.pick("BIGINT UNSIGNED", "NUMERIC(20)", "TEXT")
+		}
+	}
+	length := col.Length
+	if length == 0 && len(params) > 0 {
+		length = params[0]
+	}
+	precision, scale := col.Precision, col.Scale
+	if precision == 0 && len(params) > 0 {
+		precision = params[0]
+		if len(params) > 1 {
+			scale = params[1]
+		}
+	}
+
+	switch t {
+	case "tinyint", "smallint", "int2", "year":
+		return target.pick("SMALLINT", "SMALLINT", "INTEGER")
+	case "mediumint", "int", "int4", "serial":
+		return target.pick("INT", "INTEGER", "INTEGER")
+	case "bigint", "int8", "bigserial":
+		return target.pick("BIGINT", "BIGINT", "INTEGER")
+	case "decimal", "numeric":
+		if target.Name == "sqlite" {
+			return "NUMERIC"
+		}
+		if precision > 0 {
+			return fmt.Sprintf("%s(%d,%d)", target.pick("DECIMAL", "NUMERIC", ""), precision, scale)
+		}
+		// MySQL 的 DECIMAL 不带精度时为 DECIMAL(10,0)，使用最大精度避免丢失
+		return target.pick("DECIMAL(65,30)", "NUMERIC", "")
+	case "float", "real", "float4":
+		return target.pick("FLOAT", "REAL", "REAL")
+	case "double", "float8":
+		return target.pick("DOUBLE", "DOUBLE PRECISION", "REAL")
+	case "bool", "boolean":
+		return target.TypeForKind(KindBool, 0)
+	case "char", "nchar", "bpchar":
+		if length > 0 && length <= 255 && target.Name != "sqlite" {
+			return fmt.Sprintf("CHAR(%d)", length)
+		}
+	case "varchar", "nvarchar":
+		if length > 0 && target.Name != "sqlite" && (target.Name != "mysql" || length <= 16383) {
+			return fmt.Sprintf("VARCHAR(%d)", length)
+		}
+	case "uuid":
+		return target.pick("CHAR(36)", "UUID", "TEXT")
+	case "date":
+		return "DATE"
+	case "time", "timetz":
+		return target.pick("TIME", "TIME", "TEXT")
+	case "datetime", "timestamp":
+		return target.TypeForKind(KindDateTime, 0)
+	case "timestamptz":
+		return target.pick("DATETIME", "TIMESTAMPTZ", "TEXT")
+	case "json", "jsonb":
+		return target.TypeForKind(KindJSON, 0)
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return target.TypeForKind(KindBinary, 0)
+	}
+	return target.pick("LONGTEXT", "TEXT", "TEXT")
+}
+
+// pick 按方言选择类型名，sqlite 为空时使用 NUMERIC
+func (d Dialect) pick(mysql, postgres, sqlite string) string {
+	switch d.Name {
+	case "mysql":
+		return mysql
+	case "postgres":
+		return postgres
+	}
+	if sqlite == "" {
+		return "NUMERIC"
+	}
+	return sqlite
+}
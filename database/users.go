@@ -0,0 +1,836 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// UserInfo 数据库用户或角色。MySQL 的账号由 Name 和 Host 共同确定，PostgreSQL 的 Host 为空。
+// ConnectionLimit 为 0 表示不限制，MemberOf 为所属角色（MySQL 为 name@host 形式）
+type UserInfo struct {
+	Name            string   `json:"Name"`
+	Host            string   `json:"Host"`
+	IsRole          bool     `json:"IsRole"`
+	CanLogin        bool     `json:"CanLogin"`
+	Superuser       bool     `json:"Superuser"`
+	CreateDB        bool     `json:"CreateDB"`
+	CreateRole      bool     `json:"CreateRole"`
+	Locked          bool     `json:"Locked"`
+	ConnectionLimit int      `json:"ConnectionLimit"`
+	ValidUntil      string   `json:"ValidUntil"`
+	Plugin          string   `json:"Plugin"`
+	MemberOf        []string `json:"MemberOf"`
+}
+
+// 授权级别
+const (
+	GrantGlobal   = "global"
+	GrantDatabase = "database"
+	GrantSchema   = "schema"
+	GrantTable    = "table"
+	GrantRole     = "role"
+)
+
+// GrantInfo 一条授权。Level 为 GrantRole 时 Object 为授予的角色；
+// 无法识别的授权 Level 为空，只保留 SQL
+type GrantInfo struct {
+	Level      string   `json:"Level"`
+	Privileges []string `json:"Privileges"`
+	Database   string   `json:"Database"`
+	Schema     string   `json:"Schema"`
+	Object     string   `json:"Object"`
+	Grantable  bool     `json:"Grantable"`
+	SQL        string   `json:"SQL"`
+}
+
+// 用户变更操作
+const (
+	UserCreate = "create"
+	UserAlter  = "alter"
+	UserDrop   = "drop"
+	UserGrant  = "grant"
+	UserRevoke = "revoke"
+)
+
+// UserSpec 创建或修改用户时的属性。Password 为空时不设置密码；
+// NewName 非空时重命名；Superuser、CreateDB、CreateRole、ValidUntil 只用于 PostgreSQL，Locked 只用于 MySQL
+type UserSpec struct {
+	NewName         string   `json:"NewName"`
+	Password        string   `json:"Password"`
+	IsRole          bool     `json:"IsRole"`
+	Superuser       bool     `json:"Superuser"`
+	CreateDB        bool     `json:"CreateDB"`
+	CreateRole      bool     `json:"CreateRole"`
+	Locked          bool     `json:"Locked"`
+	ConnectionLimit int      `json:"ConnectionLimit"`
+	ValidUntil      string   `json:"ValidUntil"`
+	MemberOf        []string `json:"MemberOf"`
+}
+
+// GrantSpec 授予或撤销的权限。Level 为 GrantTable 且 Object 为 * 时表示库（schema）下的所有表；
+// Level 为 GrantRole 时 Object 为角色名，WithGrantOption 对应 WITH ADMIN OPTION
+type GrantSpec struct {
+	Level           string   `json:"Level"`
+	Privileges      []string `json:"Privileges"`
+	Database        string   `json:"Database"`
+	Schema          string   `json:"Schema"`
+	Object          string   `json:"Object"`
+	WithGrantOption bool     `json:"WithGrantOption"`
+}
+
+// UserChange 对用户 Name（MySQL 为 Name@Host）执行的一次变更。
+// Current 为修改前的属性（GetUsers 的结果），修改时只生成与之不同的属性，为空时用 FillCurrentUsers 从数据库读取
+type UserChange struct {
+	Action  string    `json:"Action"`
+	Name    string    `json:"Name"`
+	Host    string    `json:"Host"`
+	User    UserSpec  `json:"User"`
+	Grant   GrantSpec `json:"Grant"`
+	Current *UserInfo `json:"Current"`
+}
+
+// privilegePattern 权限名只允许字母、空格和下划线，防止拼接出其他语句
+var privilegePattern = regexp.MustCompile(`^[A-Z][A-Z _]*$`)
+
+// BuildUserChangeSQL 生成用户变更的 SQL 语句，用于预览和执行
+func BuildUserChangeSQL(dbType string, change UserChange) ([]string, error) {
+	if change.Name == "" {
+		return nil, fmt.Errorf("user name is required")
+	}
+	if change.Action == UserAlter && change.Current == nil {
+		return nil, fmt.Errorf("current attributes of %s are required to alter it", change.Name)
+	}
+	switch dbType {
+	case "mysql":
+		return mysqlUserSQL(change)
+	case "postgres":
+		return postgresUserSQL(change)
+	}
+	return nil, fmt.Errorf("%s does not support user management", dbType)
+}
+
+// FillCurrentUsers 为缺少 Current 的修改读取用户修改前的属性
+func FillCurrentUsers(adapter DBAdapter, changes []UserChange) error {
+	var users []UserInfo
+	for i := range changes {
+		c := &changes[i]
+		if c.Action != UserAlter || c.Current != nil {
+			continue
+		}
+		if users == nil {
+			var err error
+			if users, err = adapter.GetUsers(); err != nil {
+				return err
+			}
+		}
+		for j := range users {
+			u := users[j]
+			if u.Name == c.Name && (u.Host == c.Host || c.Host == "" && u.Host == "%") {
+				c.Current = &u
+				break
+			}
+		}
+		if c.Current == nil {
+			return fmt.Errorf("user %s not found", c.Name)
+		}
+	}
+	return nil
+}
+
+// FormatStatements 将语句拼接为脚本文本
+func FormatStatements(stmts []string) string {
+	if len(stmts) == 0 {
+		return ""
+	}
+	return strings.Join(stmts, ";\n") + ";"
+}
+
+// normalizePrivileges 校验并规范化权限名，ALL 统一为 ALL PRIVILEGES
+func normalizePrivileges(privileges []string) ([]string, error) {
+	if len(privileges) == 0 {
+		return nil, fmt.Errorf("no privileges specified")
+	}
+	result := make([]string, 0, len(privileges))
+	for _, p := range privileges {
+		p = strings.Join(strings.Fields(strings.ToUpper(p)), " ")
+		if !privilegePattern.MatchString(p) {
+			return nil, fmt.Errorf("invalid privilege: %q", p)
+		}
+		if p == "ALL" {
+			p = "ALL PRIVILEGES"
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// mysqlAccount 返回 'name'@'host'，host 为空时使用 %
+func mysqlAccount(name, host string) string {
+	d := DialectOf("mysql")
+	if host == "" {
+		host = "%"
+	}
+	return d.QuoteString(name) + "@" + d.QuoteString(host)
+}
+
+// mysqlRoleAccount 将 name@host 形式的角色名转换为账号
+func mysqlRoleAccount(role string) string {
+	if i := strings.LastIndexByte(role, '@'); i > 0 {
+		return mysqlAccount(role[:i], role[i+1:])
+	}
+	return mysqlAccount(role, "")
+}
+
+func mysqlUserSQL(change UserChange) ([]string, error) {
+	d := DialectOf("mysql")
+	account := mysqlAccount(change.Name, change.Host)
+	spec := change.User
+
+	switch change.Action {
+	case UserCreate:
+		var stmts []string
+		if spec.IsRole {
+			stmts = append(stmts, "CREATE ROLE "+account)
+		} else {
+			stmt := "CREATE USER " + account
+			if spec.Password != "" {
+				stmt += " IDENTIFIED BY " + d.QuoteString(spec.Password)
+			}
+			if spec.ConnectionLimit > 0 {
+				stmt += " WITH MAX_USER_CONNECTIONS " + strconv.Itoa(spec.ConnectionLimit)
+			}
+			if spec.Locked {
+				stmt += " ACCOUNT LOCK"
+			}
+			stmts = append(stmts, stmt)
+		}
+		for _, role := range spec.MemberOf {
+			stmts = append(stmts, "GRANT "+mysqlRoleAccount(role)+" TO "+account)
+		}
+		return stmts, nil
+
+	case UserAlter:
+		// 只修改变化的属性，避免解锁被锁定的账号或重置连接数限制
+		cur := change.Current
+		var clauses []string
+		if spec.Password != "" {
+			clauses = append(clauses, "IDENTIFIED BY "+d.QuoteString(spec.Password))
+		}
+		if spec.ConnectionLimit != cur.ConnectionLimit {
+			clauses = append(clauses, "WITH MAX_USER_CONNECTIONS "+strconv.Itoa(spec.ConnectionLimit))
+		}
+		if spec.Locked != cur.Locked {
+			if spec.Locked {
+				clauses = append(clauses, "ACCOUNT LOCK")
+			} else {
+				clauses = append(clauses, "ACCOUNT UNLOCK")
+			}
+		}
+		stmts := []string{}
+		if len(clauses) > 0 {
+			stmts = append(stmts, "ALTER USER "+account+" "+strings.Join(clauses, " "))
+		}
+		if spec.NewName != "" && spec.NewName != change.Name {
+			stmts = append(stmts, "RENAME USER "+account+" TO "+mysqlAccount(spec.NewName, change.Host))
+		}
+		return stmts, nil
+
+	case UserDrop:
+		return []string{"DROP USER " + account}, nil
+
+	case UserGrant, UserRevoke:
+		g := change.Grant
+		grant := change.Action == UserGrant
+		if g.Level == GrantRole {
+			if g.Object == "" {
+				return nil, fmt.Errorf("role name is required")
+			}
+			if grant {
+				stmt := "GRANT " + mysqlRoleAccount(g.Object) + " TO " + account
+				if g.WithGrantOption {
+					stmt += " WITH ADMIN OPTION"
+				}
+				return []string{stmt}, nil
+			}
+			return []string{"REVOKE " + mysqlRoleAccount(g.Object) + " FROM " + account}, nil
+		}
+
+		privileges, err := normalizePrivileges(g.Privileges)
+		if err != nil {
+			return nil, err
+		}
+		var target string
+		switch g.Level {
+		case GrantGlobal:
+			target = "*.*"
+		case GrantDatabase, GrantSchema:
+			if g.Database == "" {
+				return nil, fmt.Errorf("database is required")
+			}
+			target = d.QuoteIdent(g.Database) + ".*"
+		case GrantTable:
+			if g.Database == "" || g.Object == "" {
+				return nil, fmt.Errorf("database and table are required")
+			}
+			target = d.QuoteIdent(g.Database) + ".*"
+			if g.Object != "*" {
+				target = d.QuoteIdent(g.Database) + "." + d.QuoteIdent(g.Object)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported grant level: %s", g.Level)
+		}
+
+		if grant {
+			stmt := "GRANT " + strings.Join(privileges, ", ") + " ON " + target + " TO " + account
+			if g.WithGrantOption {
+				stmt += " WITH GRANT OPTION"
+			}
+			return []string{stmt}, nil
+		}
+		if g.WithGrantOption {
+			privileges = append(privileges, "GRANT OPTION")
+		}
+		return []string{"REVOKE " + strings.Join(privileges, ", ") + " ON " + target + " FROM " + account}, nil
+	}
+	return nil, fmt.Errorf("unsupported user action: %s", change.Action)
+}
+
+// pgFlag 返回 PostgreSQL 角色属性，关闭时加 NO 前缀
+func pgFlag(name string, on bool) string {
+	if on {
+		return name
+	}
+	return "NO" + name
+}
+
+// pgRoleOptions 生成 CREATE/ALTER ROLE 的属性。current 不为空时只生成与之不同的属性：
+// 修改 SUPERUSER 需要超级用户权限，PostgreSQL 16 起修改 CREATEDB、CREATEROLE 需要自身具有该属性
+func pgRoleOptions(spec UserSpec, current *UserInfo) []string {
+	d := DialectOf("postgres")
+	var opts []string
+	flag := func(name string, on, was bool) {
+		if current == nil || on != was {
+			opts = append(opts, pgFlag(name, on))
+		}
+	}
+	var cur UserInfo
+	if current != nil {
+		cur = *current
+	}
+	flag("LOGIN", !spec.IsRole, cur.CanLogin)
+	flag("SUPERUSER", spec.Superuser, cur.Superuser)
+	flag("CREATEDB", spec.CreateDB, cur.CreateDB)
+	flag("CREATEROLE", spec.CreateRole, cur.CreateRole)
+	if spec.Password != "" {
+		opts = append(opts, "PASSWORD "+d.QuoteString(spec.Password))
+	}
+	if current == nil {
+		if spec.ConnectionLimit > 0 {
+			opts = append(opts, "CONNECTION LIMIT "+strconv.Itoa(spec.ConnectionLimit))
+		}
+		if spec.ValidUntil != "" {
+			opts = append(opts, "VALID UNTIL "+d.QuoteString(spec.ValidUntil))
+		}
+		return opts
+	}
+	if spec.ConnectionLimit != cur.ConnectionLimit {
+		limit := spec.ConnectionLimit
+		if limit <= 0 {
+			limit = -1
+		}
+		opts = append(opts, "CONNECTION LIMIT "+strconv.Itoa(limit))
+	}
+	if spec.ValidUntil != cur.ValidUntil {
+		until := spec.ValidUntil
+		if until == "" {
+			until = "infinity"
+		}
+		opts = append(opts, "VALID UNTIL "+d.QuoteString(until))
+	}
+	return opts
+}
+
+func postgresUserSQL(change UserChange) ([]string, error) {
+	d := DialectOf("postgres")
+	role := d.QuoteIdent(change.Name)
+	spec := change.User
+
+	switch change.Action {
+	case UserCreate:
+		stmt := "CREATE ROLE " + role + " WITH " + strings.Join(pgRoleOptions(spec, nil), " ")
+		if len(spec.MemberOf) > 0 {
+			roles := make([]string, len(spec.MemberOf))
+			for i, r := range spec.MemberOf {
+				roles[i] = d.QuoteIdent(r)
+			}
+			stmt += " IN ROLE " + strings.Join(roles, ", ")
+		}
+		return []string{stmt}, nil
+
+	case UserAlter:
+		stmts := []string{}
+		if opts := pgRoleOptions(spec, change.Current); len(opts) > 0 {
+			stmts = append(stmts, "ALTER ROLE "+role+" WITH "+strings.Join(opts, " "))
+		}
+		if spec.NewName != "" && spec.NewName != change.Name {
+			stmts = append(stmts, "ALTER ROLE "+role+" RENAME TO "+d.QuoteIdent(spec.NewName))
+		}
+		return stmts, nil
+
+	case UserDrop:
+		return []string{"DROP ROLE " + role}, nil
+
+	case UserGrant, UserRevoke:
+		g := change.Grant
+		grant := change.Action == UserGrant
+		if g.Level == GrantRole {
+			if g.Object == "" {
+				return nil, fmt.Errorf("role name is required")
+			}
+			if grant {
+				stmt := "GRANT " + d.QuoteIdent(g.Object) + " TO " + role
+				if g.WithGrantOption {
+					stmt += " WITH ADMIN OPTION"
+				}
+				return []string{stmt}, nil
+			}
+			return []string{"REVOKE " + d.QuoteIdent(g.Object) + " FROM " + role}, nil
+		}
+
+		privileges, err := normalizePrivileges(g.Privileges)
+		if err != nil {
+			return nil, err
+		}
+		var target string
+		switch g.Level {
+		case GrantDatabase:
+			if g.Database == "" {
+				return nil, fmt.Errorf("database is required")
+			}
+			target = "DATABASE " + d.QuoteIdent(g.Database)
+		case GrantSchema:
+			if g.Schema == "" {
+				return nil, fmt.Errorf("schema is required")
+			}
+			target = "SCHEMA " + d.QuoteIdent(g.Schema)
+		case GrantTable:
+			if g.Schema == "" || g.Object == "" {
+				return nil, fmt.Errorf("schema and table are required")
+			}
+			if g.Object == "*" {
+				target = "ALL TABLES IN SCHEMA " + d.QuoteIdent(g.Schema)
+			} else {
+				target = "TABLE " + d.TableName("", g.Schema, g.Object)
+			}
+		case GrantGlobal:
+			return nil, fmt.Errorf("postgres has no global privileges, use role attributes instead")
+		default:
+			return nil, fmt.Errorf("unsupported grant level: %s", g.Level)
+		}
+
+		if grant {
+			stmt := "GRANT " + strings.Join(privileges, ", ") + " ON " + target + " TO " + role
+			if g.WithGrantOption {
+				stmt += " WITH GRANT OPTION"
+			}
+			return []string{stmt}, nil
+		}
+		return []string{"REVOKE " + strings.Join(privileges, ", ") + " ON " + target + " FROM " + role}, nil
+	}
+	return nil, fmt.Errorf("unsupported user action: %s", change.Action)
+}
+
+// execStatements 依次执行语句，inTx 为 true 时在一个事务中执行
+func execStatements(db *sqlx.DB, stmts []string, inTx bool) error {
+	if !inTx {
+		for _, stmt := range stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("%s: %v", truncateStatement(stmt), err)
+			}
+		}
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %v", truncateStatement(stmt), err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetUsers 从 mysql.user 获取账号，mysql.role_edges（MySQL 8.0）用于识别角色及其成员
+func (a *MySQLAdapter) GetUsers() ([]UserInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Queryx(`
+		SELECT User, Host, Super_priv = 'Y', account_locked = 'Y', max_user_connections, COALESCE(plugin, ''),
+			account_locked = 'Y' AND password_expired = 'Y' AND COALESCE(authentication_string, '') = ''
+		FROM mysql.user
+		ORDER BY User, Host
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []UserInfo{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var u UserInfo
+		if err := rows.Scan(&u.Name, &u.Host, &u.Superuser, &u.Locked, &u.ConnectionLimit, &u.Plugin, &u.IsRole); err != nil {
+			return nil, err
+		}
+		u.MemberOf = []string{}
+		index[u.Name+"@"+u.Host] = len(users)
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// MySQL 5.7 没有 role_edges，忽略错误
+	var edges []struct {
+		FromUser string `db:"FROM_USER"`
+		FromHost string `db:"FROM_HOST"`
+		ToUser   string `db:"TO_USER"`
+		ToHost   string `db:"TO_HOST"`
+	}
+	if err := db.Select(&edges, "SELECT FROM_USER, FROM_HOST, TO_USER, TO_HOST FROM mysql.role_edges ORDER BY FROM_USER"); err == nil {
+		for _, e := range edges {
+			role := e.FromUser + "@" + e.FromHost
+			if i, ok := index[role]; ok {
+				users[i].IsRole = true
+			}
+			if i, ok := index[e.ToUser+"@"+e.ToHost]; ok {
+				users[i].MemberOf = append(users[i].MemberOf, role)
+			}
+		}
+	}
+	for i := range users {
+		users[i].CanLogin = !users[i].IsRole && !users[i].Locked
+	}
+	return users, nil
+}
+
+// mysqlGrantPattern 解析 SHOW GRANTS 返回的对象授权
+var mysqlGrantPattern = regexp.MustCompile(`(?is)^GRANT\s+(.+?)\s+ON\s+(?:(?:TABLE|FUNCTION|PROCEDURE)\s+)?(\S+)\s+TO\s+.+?(\s+WITH GRANT OPTION)?$`)
+
+// mysqlRoleGrantPattern 解析 SHOW GRANTS 返回的角色授权
+var mysqlRoleGrantPattern = regexp.MustCompile(`(?is)^GRANT\s+(.+?)\s+TO\s+\S+?(\s+WITH ADMIN OPTION)?$`)
+
+// splitTopLevel 按逗号拆分，忽略括号和引号内的逗号
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '`' || c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// unquoteMySQLIdent 去掉标识符两侧的反引号
+func unquoteMySQLIdent(s string) string {
+	if len(s) >= 2 && s[0] == '`' && s[len(s)-1] == '`' {
+		return strings.ReplaceAll(s[1:len(s)-1], "``", "`")
+	}
+	return s
+}
+
+// parseMySQLGrants 解析一条 SHOW GRANTS 结果，一次授予多个角色时拆成多条
+func parseMySQLGrants(stmt string) []GrantInfo {
+	g := GrantInfo{SQL: stmt, Privileges: []string{}}
+	if m := mysqlGrantPattern.FindStringSubmatch(stmt); m != nil {
+		g.Privileges = splitTopLevel(m[1])
+		g.Grantable = m[3] != ""
+		target := m[2]
+		// 目标为 db.table，库名和表名可能带反引号
+		dot := -1
+		inQuote := false
+		for i := 0; i < len(target) && dot < 0; i++ {
+			if target[i] == '`' {
+				inQuote = !inQuote
+			} else if target[i] == '.' && !inQuote {
+				dot = i
+			}
+		}
+		if dot < 0 {
+			return []GrantInfo{g}
+		}
+		db, table := unquoteMySQLIdent(target[:dot]), unquoteMySQLIdent(target[dot+1:])
+		switch {
+		case db == "*":
+			g.Level = GrantGlobal
+		case table == "*":
+			g.Level, g.Database = GrantDatabase, db
+		default:
+			g.Level, g.Database, g.Object = GrantTable, db, table
+		}
+		return []GrantInfo{g}
+	}
+	m := mysqlRoleGrantPattern.FindStringSubmatch(stmt)
+	if m == nil {
+		return []GrantInfo{g}
+	}
+	var grants []GrantInfo
+	for _, role := range splitTopLevel(m[1]) {
+		r := g
+		r.Level = GrantRole
+		r.Grantable = m[2] != ""
+		r.Object = strings.NewReplacer("`", "", "'", "").Replace(role)
+		grants = append(grants, r)
+	}
+	return grants
+}
+
+// GetUserGrants 通过 SHOW GRANTS 获取账号的授权
+func (a *MySQLAdapter) GetUserGrants(name, host string) ([]GrantInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Queryx("SHOW GRANTS FOR " + mysqlAccount(name, host))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []GrantInfo{}
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return nil, err
+		}
+		grants = append(grants, parseMySQLGrants(stmt)...)
+	}
+	return grants, rows.Err()
+}
+
+// ApplyUserChange 执行用户变更，MySQL 的账号语句会隐式提交，不使用事务
+func (a *MySQLAdapter) ApplyUserChange(change UserChange) error {
+	stmts, err := BuildUserChangeSQL("mysql", change)
+	if err != nil {
+		return err
+	}
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+	return execStatements(db, stmts, false)
+}
+
+// GetUsers 从 pg_roles 获取角色，排除 pg_ 开头的内置角色
+func (a *PostgresAdapter) GetUsers() ([]UserInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Queryx(`
+		SELECT r.rolname, r.rolcanlogin, r.rolsuper, r.rolcreatedb, r.rolcreaterole, r.rolconnlimit,
+			COALESCE(r.rolvaliduntil::text, ''),
+			ARRAY(SELECT b.rolname FROM pg_auth_members m JOIN pg_roles b ON b.oid = m.roleid WHERE m.member = r.oid ORDER BY b.rolname)
+		FROM pg_roles r
+		WHERE r.rolname !~ '^pg_'
+		ORDER BY r.rolname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []UserInfo{}
+	for rows.Next() {
+		var u UserInfo
+		var memberOf pq.StringArray
+		if err := rows.Scan(&u.Name, &u.CanLogin, &u.Superuser, &u.CreateDB, &u.CreateRole, &u.ConnectionLimit, &u.ValidUntil, &memberOf); err != nil {
+			return nil, err
+		}
+		u.IsRole = !u.CanLogin
+		if u.ConnectionLimit < 0 {
+			u.ConnectionLimit = 0
+		}
+		u.MemberOf = []string(memberOf)
+		if u.MemberOf == nil {
+			u.MemberOf = []string{}
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// GetUserGrants 获取角色的授权：角色成员关系、数据库和 schema 上的有效权限（has_*_privilege），
+// 以及当前库中 information_schema.role_table_grants 记录的表权限
+func (a *PostgresAdapter) GetUserGrants(name, host string) ([]GrantInfo, error) {
+	db, err := a.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	grants := []GrantInfo{}
+	add := func(g GrantInfo) {
+		if len(g.Privileges) == 0 && g.Level != GrantRole {
+			return
+		}
+		if g.Privileges == nil {
+			g.Privileges = []string{}
+		}
+		stmts, err := postgresUserSQL(UserChange{Action: UserGrant, Name: name, Grant: GrantSpec{
+			Level: g.Level, Privileges: g.Privileges, Database: g.Database, Schema: g.Schema, Object: g.Object, WithGrantOption: g.Grantable,
+		}})
+		if err == nil {
+			g.SQL = FormatStatements(stmts)
+		}
+		grants = append(grants, g)
+	}
+
+	var roles []struct {
+		Name  string `db:"rolname"`
+		Admin bool   `db:"admin_option"`
+	}
+	err = db.Select(&roles, `
+		SELECT r.rolname, m.admin_option
+		FROM pg_auth_members m
+		JOIN pg_roles r ON r.oid = m.roleid
+		JOIN pg_roles u ON u.oid = m.member
+		WHERE u.rolname = $1
+		ORDER BY r.rolname
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles {
+		add(GrantInfo{Level: GrantRole, Object: r.Name, Grantable: r.Admin})
+	}
+
+	rows, err := db.Queryx(`
+		SELECT datname, has_database_privilege($1, datname, 'CONNECT'), has_database_privilege($1, datname, 'CREATE'),
+			has_database_privilege($1, datname, 'TEMPORARY')
+		FROM pg_database
+		WHERE datallowconn AND NOT datistemplate
+		ORDER BY datname
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var datname string
+		var conn, create, temp bool
+		if err := rows.Scan(&datname, &conn, &create, &temp); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		add(GrantInfo{Level: GrantDatabase, Database: datname, Privileges: pgPrivileges(map[string]bool{"CONNECT": conn, "CREATE": create, "TEMPORARY": temp})})
+	}
+	rows.Close()
+
+	rows, err = db.Queryx(`
+		SELECT nspname, has_schema_privilege($1, oid, 'USAGE'), has_schema_privilege($1, oid, 'CREATE')
+		FROM pg_namespace
+		WHERE nspname !~ '^pg_' AND nspname <> 'information_schema'
+		ORDER BY nspname
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var schema string
+		var usage, create bool
+		if err := rows.Scan(&schema, &usage, &create); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		add(GrantInfo{Level: GrantSchema, Database: a.config.Database, Schema: schema, Privileges: pgPrivileges(map[string]bool{"USAGE": usage, "CREATE": create})})
+	}
+	rows.Close()
+
+	rows, err = db.Queryx(`
+		SELECT table_schema, table_name, string_agg(privilege_type, ',' ORDER BY privilege_type), bool_and(is_grantable = 'YES')
+		FROM information_schema.role_table_grants
+		WHERE grantee = $1
+		GROUP BY table_schema, table_name
+		ORDER BY table_schema, table_name
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var schema, table, privileges string
+		var grantable bool
+		if err := rows.Scan(&schema, &table, &privileges, &grantable); err != nil {
+			return nil, err
+		}
+		add(GrantInfo{Level: GrantTable, Database: a.config.Database, Schema: schema, Object: table,
+			Privileges: strings.Split(privileges, ","), Grantable: grantable})
+	}
+	return grants, rows.Err()
+}
+
+// pgPrivileges 按固定顺序返回拥有的权限
+func pgPrivileges(has map[string]bool) []string {
+	var result []string
+	for _, p := range []string{"CONNECT", "USAGE", "CREATE", "TEMPORARY"} {
+		if has[p] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// ApplyUserChange 在一个事务中执行用户变更
+func (a *PostgresAdapter) ApplyUserChange(change UserChange) error {
+	stmts, err := BuildUserChangeSQL("postgres", change)
+	if err != nil {
+		return err
+	}
+	db, err := a.DB()
+	if err != nil {
+		return err
+	}
+	return execStatements(db, stmts, true)
+}
+
+// GetUsers SQLite 没有用户
+func (a *SQLiteAdapter) GetUsers() ([]UserInfo, error) {
+	return []UserInfo{}, nil
+}
+
+// GetUserGrants SQLite 没有授权
+func (a *SQLiteAdapter) GetUserGrants(name, host string) ([]GrantInfo, error) {
+	return []GrantInfo{}, nil
+}
+
+// ApplyUserChange SQLite 不支持用户管理
+func (a *SQLiteAdapter) ApplyUserChange(change UserChange) error {
+	return fmt.Errorf("sqlite does not support user management")
+}
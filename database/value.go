@@ -0,0 +1,127 @@
+package database
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 列值分类，用于在导出时保留类型
+const (
+	KindText     = "text"
+	KindInteger  = "integer"
+	KindNumber   = "number"
+	KindBool     = "bool"
+	KindDate     = "date"
+	KindDateTime = "datetime"
+	KindTime     = "time"
+	KindJSON     = "json"
+	KindBinary   = "binary"
+	KindGeometry = "geometry"
+)
+
+// ColumnKind 根据驱动返回的列类型名判断值分类
+func ColumnKind(dbType string) string {
+	t := strings.ToUpper(dbType)
+	t = strings.TrimPrefix(t, "UNSIGNED ")
+	// PostgreSQL 数组按文本处理
+	if strings.HasSuffix(t, "]") {
+		return KindText
+	}
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = t[:i]
+	}
+	t = strings.TrimSuffix(strings.TrimSuffix(t, " ZEROFILL"), " UNSIGNED")
+	switch t {
+	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "YEAR",
+		"INT2", "INT4", "INT8", "SERIAL", "BIGSERIAL":
+		return KindInteger
+	case "DECIMAL", "NUMERIC", "FLOAT", "DOUBLE", "REAL", "FLOAT4", "FLOAT8", "DOUBLE PRECISION":
+		return KindNumber
+	case "BOOL", "BOOLEAN":
+		return KindBool
+	case "DATE":
+		return KindDate
+	case "DATETIME", "TIMESTAMP", "TIMESTAMPTZ":
+		return KindDateTime
+	case "TIME", "TIMETZ":
+		return KindTime
+	case "JSON", "JSONB":
+		return KindJSON
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BYTEA", "BIT":
+		return KindBinary
+	case "GEOMETRY", "GEOGRAPHY", "POINT", "LINESTRING", "POLYGON", "MULTIPOINT", "MULTILINESTRING", "MULTIPOLYGON",
+		"GEOMETRYCOLLECTION", "GEOMCOLLECTION":
+		return KindGeometry
+	}
+	return KindText
+}
+
+// timeLayouts 文本形式的日期时间格式（MySQL 未开启 parseTime 时返回文本）
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999-07",
+	"2006-01-02",
+}
+
+// ParseTimeValue 将驱动返回的日期时间值转换为 time.Time
+func ParseTimeValue(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case []byte:
+		return parseTimeText(string(v))
+	case string:
+		return parseTimeText(v)
+	}
+	return time.Time{}, false
+}
+
+func parseTimeText(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NumberText 返回数值的文本形式，无法识别为数值时返回 false
+func NumberText(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int:
+		return strconv.Itoa(v), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return NumberText(float64(v))
+	case []byte:
+		return numberText(string(v))
+	case string:
+		return numberText(v)
+	}
+	return "", false
+}
+
+// jsonNumber 符合 JSON 规范的数值写法
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+func numberText(s string) (string, bool) {
+	if !jsonNumber.MatchString(s) {
+		return "", false
+	}
+	return s, true
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"dbcat/database"
+)
+
+// StartDataCompare 在后台比较两张表的数据，返回任务ID，完成后通过 GetJobResult 获取 DataDiff
+func (a *App) StartDataCompare(source, target database.DatabaseConfig, options database.DataCompareOptions) (string, error) {
+	if options.SourceTable == "" {
+		return "", fmt.Errorf("未选择需要比较的表")
+	}
+	title := fmt.Sprintf("比较数据 %s → %s", options.SourceTable, target.ProfileKey())
+
+	return a.startJob("data-compare", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		src, err := factory.CreateAdapter(source)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer src.Close()
+		if err := src.Connect(); err != nil {
+			return "", fmt.Errorf("连接源数据库失败: %v", err)
+		}
+
+		dst, err := factory.CreateAdapter(target)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer dst.Close()
+		if err := dst.Connect(); err != nil {
+			return "", fmt.Errorf("连接目标数据库失败: %v", err)
+		}
+
+		diff, err := database.CompareTableData(ctx, src, source.Type, dst, target.Type, options, func(p database.DataCompareProgress) {
+			r.Update(p.Rows, p.TotalRows, fmt.Sprintf("已比较 %d 个区间，发现 %d 处差异", p.Chunks, p.Differences))
+		})
+		if err != nil {
+			return "", err
+		}
+		r.SetResult(diff)
+		r.Message(dataCompareSummary(diff))
+		return "", nil
+	}), nil
+}
+
+// dataCompareSummary 数据比较结果的说明文字
+func dataCompareSummary(diff *database.DataDiff) string {
+	if diff.Added+diff.Removed+diff.Changed == 0 {
+		return fmt.Sprintf("数据一致，共 %d 行", diff.Identical)
+	}
+	return fmt.Sprintf("仅源表 %d 行，仅目标表 %d 行，不同 %d 行，相同 %d 行", diff.Added, diff.Removed, diff.Changed, diff.Identical)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"dbcat/database"
+)
+
+// StartExport 在后台导出表或查询结果，返回任务ID
+func (a *App) StartExport(config database.DatabaseConfig, req database.ExportRequest) (string, error) {
+	if req.Path == "" {
+		return "", fmt.Errorf("未指定导出文件")
+	}
+	query, err := req.SourceQuery(database.DialectOf(config.Type))
+	if err != nil {
+		return "", err
+	}
+	if req.Query != "" && req.NeedsKeyColumns(config.Type) {
+		return "", fmt.Errorf("导出查询结果为 PostgreSQL 的 replace 模式时需要指定冲突列")
+	}
+
+	title := req.Table
+	if title == "" {
+		title = "query"
+	}
+	title = fmt.Sprintf("导出 %s → %s", title, filepath.Base(req.Path))
+
+	return a.startJob("export", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		if err := runExport(ctx, config, req, query, r); err != nil {
+			os.Remove(req.Path)
+			return "", err
+		}
+		return req.Path, nil
+	}), nil
+}
+
+// runExport 执行导出：连接数据库、流式读取并写入文件
+func runExport(ctx context.Context, config database.DatabaseConfig, req database.ExportRequest, query string, r *JobReporter) error {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	// 导出整张表时先取行数作为总量
+	total := int64(0)
+	if req.Query == "" {
+		if count, err := adapter.GetTableRowCount(req.Database, req.Table, ""); err == nil {
+			total = count
+		}
+	}
+	r.Progress(0, total)
+
+	// 导出整张表为 SQL 时按源表的列信息建表，未指定冲突列时使用主键
+	var columns []database.ColumnInfo
+	if strings.EqualFold(req.Format, database.FormatSQL) && req.Query == "" {
+		columns, err = database.SchemaTableColumns(adapter, req.Database, req.Schema, req.Table)
+		if err != nil {
+			return fmt.Errorf("获取表结构失败: %v", err)
+		}
+		if len(req.SQL.KeyColumns) == 0 {
+			for _, col := range columns {
+				if col.IsPrimary {
+					req.SQL.KeyColumns = append(req.SQL.KeyColumns, col.Name)
+				}
+			}
+		}
+		if req.NeedsKeyColumns(config.Type) {
+			return fmt.Errorf("表 %s 没有主键，导出为 PostgreSQL 的 replace 模式时需要指定冲突列", req.Table)
+		}
+	}
+
+	// PostgreSQL 的 PostGIS 列在结果集中没有类型名，导出整张表为 GeoJSON 时按表结构确定空间列
+	if strings.EqualFold(req.Format, database.FormatGeoJSON) && req.Query == "" && req.GeometryColumn == "" {
+		if columns, err := database.SchemaTableColumns(adapter, req.Database, req.Schema, req.Table); err == nil {
+			for _, col := range columns {
+				if database.ColumnKind(col.Type) == database.KindGeometry {
+					req.GeometryColumn = col.Name
+					break
+				}
+			}
+		}
+	}
+
+	file, err := database.CreateExportFile(req.Path)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %v", err)
+	}
+	writer, err := database.NewExportWriter(file, config.Type, req)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	// 同库导出整张表时使用数据库自身的建表语句
+	if sw, ok := writer.(*database.SQLInsertWriter); ok {
+		sw.Columns = columns
+		if req.SQL.CreateTable && req.Query == "" &&
+			req.SQLTargetDialect(config.Type).Name == config.Type && req.SQLTargetTable() == req.Table {
+			if ddl, err := adapter.GetTableDDL(req.Database, req.Table); err == nil {
+				sw.Header = ddl
+			}
+		}
+	}
+
+	sink := database.WithProgress(ctx, writer, func(rows int64) {
+		r.Progress(rows, -1)
+	})
+	if err := adapter.StreamQuery(ctx, req.Database, query, nil, sink); err != nil {
+		writer.Close()
+		file.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"dbcat/database"
+)
+
+// GetSchemaGraph 获取库中表和外键关系组成的关系图
+func (a *App) GetSchemaGraph(config database.DatabaseConfig, dbName, schema string) (*database.SchemaGraph, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return database.GetSchemaGraph(adapter, config.Type, dbName, schema)
+}
+
+// ExportSchemaGraph 将关系图导出为 DOT、Mermaid 或 PlantUML 文本
+func (a *App) ExportSchemaGraph(config database.DatabaseConfig, dbName, schema, format string) (string, error) {
+	graph, err := a.GetSchemaGraph(config, dbName, schema)
+	if err != nil {
+		return "", err
+	}
+	return graph.Export(format)
+}
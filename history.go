@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"dbcat/database"
+	"dbcat/store"
+)
+
+// recordHistory 记录一次查询执行
+func (a *App) recordHistory(config database.DatabaseConfig, dbName, sql string, started time.Time, rows int64, execErr error) {
+	if a.history == nil {
+		return
+	}
+	entry := store.HistoryEntry{
+		Profile:    config.ProfileKey(),
+		Database:   dbName,
+		SQL:        sql,
+		StartedAt:  started.UnixMilli(),
+		DurationMs: time.Since(started).Milliseconds(),
+		Rows:       rows,
+	}
+	if execErr != nil {
+		entry.Error = execErr.Error()
+	}
+	if _, err := a.history.Add(entry); err != nil {
+		log.Printf("记录查询历史失败: %v", err)
+	}
+}
+
+// historyStore 获取历史存储
+func (a *App) historyStore() (*store.HistoryStore, error) {
+	if a.history == nil {
+		return nil, fmt.Errorf("查询历史不可用")
+	}
+	return a.history, nil
+}
+
+// SearchHistory 查询历史记录
+func (a *App) SearchHistory(filter store.HistoryFilter) ([]store.HistoryEntry, error) {
+	history, err := a.historyStore()
+	if err != nil {
+		return nil, err
+	}
+	return history.Search(filter)
+}
+
+// DeleteHistory 删除历史记录
+func (a *App) DeleteHistory(ids []int64) error {
+	history, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	return history.Delete(ids...)
+}
+
+// ClearHistory 清空历史记录，profile 为空时清空全部
+func (a *App) ClearHistory(profile string) error {
+	history, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	return history.Clear(profile)
+}
+
+// RerunHistory 重新执行一条历史记录
+func (a *App) RerunHistory(config database.DatabaseConfig, id int64) ([]map[string]string, error) {
+	history, err := a.historyStore()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := history.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.ExecuteQuery(config, entry.Database, entry.SQL)
+}
+
+// GetHistoryRetention 获取历史记录保留条数
+func (a *App) GetHistoryRetention() (int, error) {
+	history, err := a.historyStore()
+	if err != nil {
+		return 0, err
+	}
+	return history.Retention(), nil
+}
+
+// SetHistoryRetention 设置历史记录保留条数，0 表示不限制
+func (a *App) SetHistoryRetention(n int) error {
+	history, err := a.historyStore()
+	if err != nil {
+		return err
+	}
+	return history.SetRetention(n)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"dbcat/database"
+)
+
+// importPreviewRows 预览时返回的行数
+const importPreviewRows = 50
+
+// PreviewImport 读取导入文件的前若干行，推断字段名和类型
+func (a *App) PreviewImport(opts database.ImportSourceOptions) (database.ImportPreview, error) {
+	return database.PreviewImport(opts, 0, importPreviewRows)
+}
+
+// StartImport 在后台将 CSV/NDJSON 文件导入到表中，返回任务ID
+func (a *App) StartImport(config database.DatabaseConfig, opts database.ImportOptions) (string, error) {
+	if opts.Source.Path == "" {
+		return "", fmt.Errorf("未指定导入文件")
+	}
+	if opts.Table == "" {
+		return "", fmt.Errorf("未指定目标表")
+	}
+	title := fmt.Sprintf("导入 %s → %s", filepath.Base(opts.Source.Path), opts.Table)
+
+	return a.startJob("import", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		adapter, err := factory.CreateAdapter(config)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer adapter.Close()
+
+		if err := adapter.Connect(); err != nil {
+			return "", fmt.Errorf("连接数据库失败: %v", err)
+		}
+
+		// 进度按已读取的字节数计算，行数写在说明文字中
+		result, err := database.RunImport(ctx, adapter, config.Type, opts, func(res database.ImportResult, bytesRead, size int64) {
+			r.Update(bytesRead, size, importSummary(res))
+		})
+		if opts.CreateTable && a.metadata != nil {
+			a.metadata.Invalidate(config)
+		}
+		r.Message(importSummary(result))
+		if err != nil {
+			return result.RejectPath, err
+		}
+		return result.RejectPath, nil
+	}), nil
+}
+
+// importSummary 导入结果的说明文字
+func importSummary(res database.ImportResult) string {
+	if res.Failed > 0 {
+		return fmt.Sprintf("已导入 %d 行，失败 %d 行", res.Inserted, res.Failed)
+	}
+	return fmt.Sprintf("已导入 %d 行", res.Inserted)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// 后台任务事件
+const (
+	JobProgressEvent = "job:progress"
+	JobDoneEvent     = "job:done"
+)
+
+// 后台任务状态
+const (
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// progressInterval 进度事件的最小间隔
+const progressInterval = 200 * time.Millisecond
+
+// JobInfo 后台任务状态
+type JobInfo struct {
+	ID         string `json:"ID"`
+	Kind       string `json:"Kind"`
+	Title      string `json:"Title"`
+	Status     string `json:"Status"`
+	Processed  int64  `json:"Processed"`
+	Total      int64  `json:"Total"`
+	Message    string `json:"Message"`
+	Error      string `json:"Error"`
+	Output     string `json:"Output"`
+	StartedAt  int64  `json:"StartedAt"`
+	FinishedAt int64  `json:"FinishedAt"`
+}
+
+// JobReporter 任务执行过程中用于上报进度
+type JobReporter struct {
+	app      *App
+	job      *job
+	lastEmit time.Time
+}
+
+// Progress 更新已处理数量和总量，total 小于 0 时保持不变
+func (r *JobReporter) Progress(processed, total int64) {
+	r.app.jobs.mu.Lock()
+	r.job.info.Processed = processed
+	if total >= 0 {
+		r.job.info.Total = total
+	}
+	info := r.job.info
+	r.app.jobs.mu.Unlock()
+	r.throttledEmit(info)
+}
+
+// Update 同时更新进度和说明文字，与 Progress 一样按间隔发送事件
+func (r *JobReporter) Update(processed, total int64, message string) {
+	r.app.jobs.mu.Lock()
+	r.job.info.Processed = processed
+	if total >= 0 {
+		r.job.info.Total = total
+	}
+	r.job.info.Message = message
+	info := r.job.info
+	r.app.jobs.mu.Unlock()
+	r.throttledEmit(info)
+}
+
+// throttledEmit 距上次发送超过 progressInterval 时发送进度事件
+func (r *JobReporter) throttledEmit(info JobInfo) {
+	if time.Since(r.lastEmit) >= progressInterval {
+		r.lastEmit = time.Now()
+		r.app.emit(JobProgressEvent, info)
+	}
+}
+
+// Message 更新任务当前的说明文字
+func (r *JobReporter) Message(message string) {
+	r.app.jobs.mu.Lock()
+	r.job.info.Message = message
+	info := r.job.info
+	r.app.jobs.mu.Unlock()
+	r.app.emit(JobProgressEvent, info)
+}
+
+// SetResult 保存任务的结构化结果，任务结束后通过 GetJobResult 读取
+func (r *JobReporter) SetResult(result interface{}) {
+	r.app.jobs.mu.Lock()
+	r.job.result = result
+	r.app.jobs.mu.Unlock()
+}
+
+// job 后台任务
+type job struct {
+	info   JobInfo
+	cancel context.CancelFunc
+	result interface{}
+}
+
+// jobManager 后台任务列表
+type jobManager struct {
+	mu   sync.Mutex
+	seq  int64
+	jobs map[string]*job
+}
+
+func newJobManager() *jobManager {
+	return &jobManager{jobs: make(map[string]*job)}
+}
+
+// startJob 在后台执行任务，run 返回的字符串作为任务输出（如导出文件路径）
+func (a *App) startJob(kind, title string, run func(ctx context.Context, r *JobReporter) (string, error)) string {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a.jobs.mu.Lock()
+	a.jobs.seq++
+	j := &job{
+		info: JobInfo{
+			ID:        fmt.Sprintf("%s-%d", kind, a.jobs.seq),
+			Kind:      kind,
+			Title:     title,
+			Status:    JobRunning,
+			StartedAt: time.Now().UnixMilli(),
+		},
+		cancel: cancel,
+	}
+	a.jobs.jobs[j.info.ID] = j
+	info := j.info
+	a.jobs.mu.Unlock()
+	a.emit(JobProgressEvent, info)
+
+	go func() {
+		defer cancel()
+		output, err := run(ctx, &JobReporter{app: a, job: j})
+
+		a.jobs.mu.Lock()
+		j.info.Output = output
+		j.info.FinishedAt = time.Now().UnixMilli()
+		switch {
+		case errors.Is(err, context.Canceled) || ctx.Err() != nil:
+			j.info.Status = JobCancelled
+		case err != nil:
+			j.info.Status = JobFailed
+			j.info.Error = err.Error()
+		default:
+			j.info.Status = JobCompleted
+		}
+		info := j.info
+		a.jobs.mu.Unlock()
+		a.emit(JobDoneEvent, info)
+	}()
+	return info.ID
+}
+
+// emit 向前端发送事件
+func (a *App) emit(event string, data interface{}) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, event, data)
+	}
+}
+
+// GetJobs 获取全部后台任务，按开始时间倒序
+func (a *App) GetJobs() []JobInfo {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	list := make([]JobInfo, 0, len(a.jobs.jobs))
+	for _, j := range a.jobs.jobs {
+		list = append(list, j.info)
+	}
+	sort.Slice(list, func(i, k int) bool {
+		return list[i].StartedAt > list[k].StartedAt
+	})
+	return list
+}
+
+// CancelJob 取消后台任务
+func (a *App) CancelJob(id string) error {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	j, ok := a.jobs.jobs[id]
+	if !ok {
+		return fmt.Errorf("任务不存在: %s", id)
+	}
+	j.cancel()
+	return nil
+}
+
+// GetJobResult 获取任务的结构化结果，没有结果时返回 nil
+func (a *App) GetJobResult(id string) (interface{}, error) {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	j, ok := a.jobs.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("任务不存在: %s", id)
+	}
+	return j.result, nil
+}
+
+// ClearFinishedJobs 清除已结束的任务记录
+func (a *App) ClearFinishedJobs() {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+
+	for id, j := range a.jobs.jobs {
+		if j.info.Status != JobRunning {
+			delete(a.jobs.jobs, id)
+		}
+	}
+}
+
+// SelectSaveFile 打开保存文件对话框，pattern 形如 "*.csv"
+func (a *App) SelectSaveFile(title, defaultFilename, pattern string) (string, error) {
+	options := runtime.SaveDialogOptions{
+		Title:           title,
+		DefaultFilename: defaultFilename,
+	}
+	if pattern != "" {
+		options.Filters = []runtime.FileFilter{{DisplayName: pattern, Pattern: pattern}}
+	}
+	return runtime.SaveFileDialog(a.ctx, options)
+}
+
+// SelectOpenFile 打开选择文件对话框，pattern 形如 "*.csv;*.ndjson"
+func (a *App) SelectOpenFile(title, pattern string) (string, error) {
+	options := runtime.OpenDialogOptions{Title: title}
+	if pattern != "" {
+		options.Filters = []runtime.FileFilter{{DisplayName: pattern, Pattern: pattern}}
+	}
+	return runtime.OpenFileDialog(a.ctx, options)
+}
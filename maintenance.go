@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"dbcat/database"
+)
+
+// GetMaintenanceOperations 获取数据库支持的表维护操作
+func (a *App) GetMaintenanceOperations(config database.DatabaseConfig) []string {
+	return database.MaintenanceOperations(config.Type)
+}
+
+// StartMaintenance 在后台对表执行维护操作，tables 为空时作用于整个数据库。
+// 返回任务ID，结束后通过 GetJobResult 获取 []MaintenanceResult
+func (a *App) StartMaintenance(config database.DatabaseConfig, dbName, schema string, tables []string, operation string) (string, error) {
+	supported := false
+	for _, op := range database.MaintenanceOperations(config.Type) {
+		supported = supported || op == operation
+	}
+	if !supported {
+		return "", fmt.Errorf("不支持的维护操作: %s", operation)
+	}
+	title := fmt.Sprintf("%s %s", operation, dbName)
+	if len(tables) == 1 {
+		title = fmt.Sprintf("%s %s", operation, tables[0])
+	}
+
+	return a.startJob("maintenance", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		adapter, err := factory.CreateAdapter(config)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer adapter.Close()
+		if err := adapter.Connect(); err != nil {
+			return "", fmt.Errorf("连接数据库失败: %v", err)
+		}
+
+		results, err := database.RunMaintenance(ctx, adapter, config.Type, dbName, schema, tables, operation, func(p database.MaintenanceProgress) {
+			message := fmt.Sprintf("已完成 %d/%d", p.Done, p.Total)
+			if p.Current != "" {
+				message = fmt.Sprintf("正在处理 %s（%d/%d）", p.Current, p.Done+1, p.Total)
+			}
+			r.Update(int64(p.Done), int64(p.Total), message)
+		})
+		r.SetResult(results)
+		if err != nil {
+			return "", err
+		}
+		failed := 0
+		for _, res := range results {
+			if res.Status == database.MaintStatusError {
+				failed++
+			}
+		}
+		if failed > 0 {
+			r.Message(fmt.Sprintf("完成，%d 条错误", failed))
+		} else {
+			r.Message("完成")
+		}
+		return "", nil
+	}), nil
+}
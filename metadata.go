@@ -0,0 +1,60 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	"dbcat/database"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// MetadataUpdatedEvent 元数据后台加载完成事件
+const MetadataUpdatedEvent = "metadata:updated"
+
+// MetadataUpdate 元数据更新事件内容
+type MetadataUpdate struct {
+	Profile  string `json:"Profile"`
+	Database string `json:"Database"`
+}
+
+// newMetadataCache 创建元数据缓存，加载完成后通知前端
+func (a *App) newMetadataCache() *database.MetadataCache {
+	cache := database.NewMetadataCache()
+	cache.OnUpdate = func(profile, dbName string) {
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, MetadataUpdatedEvent, MetadataUpdate{Profile: profile, Database: dbName})
+		}
+	}
+	return cache
+}
+
+// GetMetadata 获取缓存的元数据，首次调用时在后台开始加载
+func (a *App) GetMetadata(config database.DatabaseConfig, dbName string) database.MetadataSnapshot {
+	return a.metadata.Snapshot(config, dbName)
+}
+
+// RefreshMetadata 在后台重新加载元数据
+func (a *App) RefreshMetadata(config database.DatabaseConfig, dbName string) {
+	a.metadata.Refresh(config, dbName)
+}
+
+// Complete 返回自动补全建议，cursorOffset 为编辑器中的 UTF-16 偏移
+func (a *App) Complete(config database.DatabaseConfig, dbName, sqlText string, cursorOffset int) []database.CompletionItem {
+	return a.metadata.Complete(config, dbName, sqlText, utf16ToByteOffset(sqlText, cursorOffset))
+}
+
+// utf16ToByteOffset 将 JavaScript 字符串偏移转换为 Go 字节偏移
+func utf16ToByteOffset(s string, offset int) int {
+	units := 0
+	for i, r := range s {
+		if units >= offset {
+			return i
+		}
+		if r >= 0x10000 && utf8.ValidRune(r) {
+			units += 2
+		} else {
+			units++
+		}
+	}
+	return len(s)
+}
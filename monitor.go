@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"dbcat/database"
+)
+
+// 定时刷新事件
+const (
+	ProcessesEvent = "monitor:processes"
+	StatusEvent    = "monitor:status"
+)
+
+// minWatchInterval 定时刷新的最小间隔
+const minWatchInterval = time.Second
+
+// WatchEvent 定时刷新时推送的数据，ID 为 Watch 系列方法返回的ID
+type WatchEvent struct {
+	ID    string      `json:"ID"`
+	Data  interface{} `json:"Data"`
+	Error string      `json:"Error"`
+	Time  int64       `json:"Time"`
+}
+
+// watchManager 正在运行的定时刷新
+type watchManager struct {
+	mu      sync.Mutex
+	seq     int64
+	watches map[string]context.CancelFunc
+}
+
+func newWatchManager() *watchManager {
+	return &watchManager{watches: make(map[string]context.CancelFunc)}
+}
+
+// startWatch 连接数据库后按间隔执行 tick 并将结果作为事件推送，直到调用 StopWatch。
+// 整个刷新期间复用同一个连接
+func (a *App) startWatch(kind, event string, config database.DatabaseConfig, interval time.Duration, tick func(ctx context.Context, adapter database.DBAdapter) (interface{}, error)) (string, error) {
+	if interval < minWatchInterval {
+		interval = minWatchInterval
+	}
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	if err := adapter.Connect(); err != nil {
+		adapter.Close()
+		return "", fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.watches.mu.Lock()
+	a.watches.seq++
+	id := fmt.Sprintf("%s-%d", kind, a.watches.seq)
+	a.watches.watches[id] = cancel
+	a.watches.mu.Unlock()
+
+	go func() {
+		defer adapter.Close()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			data, err := tick(ctx, adapter)
+			if ctx.Err() != nil {
+				return
+			}
+			ev := WatchEvent{ID: id, Data: data, Time: time.Now().UnixMilli()}
+			if err != nil {
+				ev.Error = err.Error()
+			}
+			a.emit(event, ev)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return id, nil
+}
+
+// StopWatch 停止定时刷新
+func (a *App) StopWatch(id string) error {
+	a.watches.mu.Lock()
+	defer a.watches.mu.Unlock()
+
+	cancel, ok := a.watches.watches[id]
+	if !ok {
+		return fmt.Errorf("刷新任务不存在: %s", id)
+	}
+	cancel()
+	delete(a.watches.watches, id)
+	return nil
+}
+
+// stopAllWatches 停止全部定时刷新，应用退出时调用
+func (a *App) stopAllWatches() {
+	a.watches.mu.Lock()
+	defer a.watches.mu.Unlock()
+
+	for id, cancel := range a.watches.watches {
+		cancel()
+		delete(a.watches.watches, id)
+	}
+}
+
+// GetServerProcesses 获取服务器上的连接列表
+func (a *App) GetServerProcesses(config database.DatabaseConfig) ([]database.ProcessInfo, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.GetServerProcesses()
+}
+
+// KillProcess 结束服务器上的连接，queryOnly 为 true 时只中止正在执行的语句
+func (a *App) KillProcess(config database.DatabaseConfig, id int64, queryOnly bool) error {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.KillProcess(id, queryOnly)
+}
+
+// WatchServerProcesses 按间隔（毫秒）刷新连接列表并通过 monitor:processes 事件推送，返回刷新任务ID
+func (a *App) WatchServerProcesses(config database.DatabaseConfig, intervalMs int) (string, error) {
+	return a.startWatch("processes", ProcessesEvent, config, time.Duration(intervalMs)*time.Millisecond,
+		func(ctx context.Context, adapter database.DBAdapter) (interface{}, error) {
+			return adapter.GetServerProcesses()
+		})
+}
+
+// GetBlockingTree 获取锁等待关系组成的阻塞树
+func (a *App) GetBlockingTree(config database.DatabaseConfig) ([]database.BlockingNode, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	waits, err := adapter.GetLockWaits()
+	if err != nil {
+		return nil, err
+	}
+	processes, err := adapter.GetServerProcesses()
+	if err != nil {
+		return nil, err
+	}
+	return database.BuildBlockingTree(waits, processes), nil
+}
+
+// GetServerVariables 获取服务器配置项
+func (a *App) GetServerVariables(config database.DatabaseConfig) ([]database.ServerVariable, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.GetServerVariables()
+}
+
+// GetServerStatus 获取服务器运行状态
+func (a *App) GetServerStatus(config database.DatabaseConfig) ([]database.ServerVariable, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.GetServerStatus()
+}
+
+// WatchServerStatus 按间隔（毫秒）采样运行状态，将计数换算为 QPS、缓冲命中率等指标，
+// 通过 monitor:status 事件推送，返回刷新任务ID
+func (a *App) WatchServerStatus(config database.DatabaseConfig, intervalMs int) (string, error) {
+	var prev []database.ServerVariable
+	var prevTime time.Time
+	return a.startWatch("status", StatusEvent, config, time.Duration(intervalMs)*time.Millisecond,
+		func(ctx context.Context, adapter database.DBAdapter) (interface{}, error) {
+			cur, err := adapter.GetServerStatus()
+			if err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			metrics := database.ComputeServerMetrics(config.Type, prev, cur, now.Sub(prevTime).Seconds())
+			prev, prevTime = cur, now
+			return metrics, nil
+		})
+}
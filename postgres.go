@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"dbcat/database"
+)
+
+// connectPostgres 创建并连接 PostgreSQL 适配器，用于 PostgreSQL 专有的功能
+func connectPostgres(config database.DatabaseConfig) (*database.PostgresAdapter, error) {
+	if config.Type != "postgres" {
+		return nil, fmt.Errorf("该功能只支持 PostgreSQL")
+	}
+	adapter := database.NewPostgresAdapter(config)
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	return adapter, nil
+}
+
+// GetPostgresExtensions 获取可用及已安装的扩展
+func (a *App) GetPostgresExtensions(config database.DatabaseConfig) ([]database.PGExtension, error) {
+	adapter, err := connectPostgres(config)
+	if err != nil {
+		return nil, err
+	}
+	defer adapter.Close()
+
+	return adapter.GetExtensions()
+}
+
+// GetPostgresTypes 获取 schema 中的枚举、域和复合类型及其 DDL
+func (a *App) GetPostgresTypes(config database.DatabaseConfig, schema string) ([]database.PGType, error) {
+	adapter, err := connectPostgres(config)
+	if err != nil {
+		return nil, err
+	}
+	defer adapter.Close()
+
+	return adapter.GetTypes(schema)
+}
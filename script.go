@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"dbcat/database"
+)
+
+// ExecuteScriptFile 在后台逐条执行磁盘上的 SQL 脚本文件，返回任务ID。
+// 出错停止时任务输出为出错语句的行号，可将其作为 StartLine 从该处继续执行
+func (a *App) ExecuteScriptFile(config database.DatabaseConfig, path string, options database.ScriptOptions) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("未指定脚本文件")
+	}
+	return a.startScriptJob("script", fmt.Sprintf("执行脚本 %s", filepath.Base(path)), config, path, options), nil
+}
+
+// startScriptJob 启动执行脚本文件的后台任务
+func (a *App) startScriptJob(kind, title string, config database.DatabaseConfig, path string, options database.ScriptOptions) string {
+	return a.startJob(kind, title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		adapter, err := factory.CreateAdapter(config)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer adapter.Close()
+
+		if err := adapter.Connect(); err != nil {
+			return "", fmt.Errorf("连接数据库失败: %v", err)
+		}
+
+		// 进度为已执行到的字节偏移，语句数写在说明文字中
+		result, err := database.RunScriptFile(ctx, adapter, config.Type, path, options, func(res database.ScriptResult) {
+			r.Update(res.Offset, res.Size, scriptSummary(res))
+		})
+		a.metadata.Invalidate(config)
+		r.Progress(result.Offset, result.Size)
+		r.Message(scriptSummary(result))
+		if err != nil && result.StopLine > 0 {
+			return fmt.Sprint(result.StopLine), fmt.Errorf("第 %d 行的语句执行失败: %v", result.StopLine, result.Errors[len(result.Errors)-1].Error)
+		}
+		return "", err
+	})
+}
+
+// scriptSummary 脚本执行进度的说明文字
+func scriptSummary(res database.ScriptResult) string {
+	text := fmt.Sprintf("已执行 %d 条语句", res.Executed)
+	if res.Skipped > 0 {
+		text += fmt.Sprintf("，跳过 %d 条", res.Skipped)
+	}
+	if res.Failed > 0 {
+		text += fmt.Sprintf("，失败 %d 条", res.Failed)
+	}
+	if res.StopLine > 0 {
+		text += fmt.Sprintf("，在第 %d 行停止", res.StopLine)
+	}
+	return text
+}
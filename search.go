@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"dbcat/database"
+)
+
+// SearchMatchEvent 全库搜索找到结果时推送的事件
+const SearchMatchEvent = "search:match"
+
+// SearchMatchInfo 搜索结果事件，JobID 为 SearchDatabase 返回的任务ID
+type SearchMatchInfo struct {
+	JobID string               `json:"JobID"`
+	Match database.SearchMatch `json:"Match"`
+}
+
+// SearchDatabase 在后台搜索对象名和数据，结果通过 search:match 事件逐条推送，
+// 返回任务ID，可通过 CancelJob 取消，结束后通过 GetJobResult 获取 SearchSummary
+func (a *App) SearchDatabase(config database.DatabaseConfig, dbName, term string, options database.SearchOptions) (string, error) {
+	if term == "" {
+		return "", fmt.Errorf("搜索内容不能为空")
+	}
+	title := fmt.Sprintf("搜索 %q", term)
+
+	return a.startJob("search", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		adapter, err := factory.CreateAdapter(config)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer adapter.Close()
+		if err := adapter.Connect(); err != nil {
+			return "", fmt.Errorf("连接数据库失败: %v", err)
+		}
+
+		id := r.job.info.ID
+		summary, err := database.SearchDatabase(ctx, adapter, config.Type, dbName, term, options,
+			func(m database.SearchMatch) {
+				a.emit(SearchMatchEvent, SearchMatchInfo{JobID: id, Match: m})
+			},
+			func(p database.SearchProgress) {
+				r.Update(int64(p.Tables), int64(p.TotalTables), fmt.Sprintf("已搜索 %d/%d 张表，找到 %d 条结果", p.Tables, p.TotalTables, p.Matches))
+			})
+		if err != nil {
+			return "", err
+		}
+		r.SetResult(summary)
+		r.Message(fmt.Sprintf("找到 %d 条结果", summary.Matches))
+		return "", nil
+	}), nil
+}
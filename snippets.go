@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"dbcat/database"
+	"dbcat/store"
+)
+
+// snippetStore 获取片段库
+func (a *App) snippetStore() (*store.SnippetStore, error) {
+	if a.snippets == nil {
+		return nil, fmt.Errorf("片段库不可用")
+	}
+	return a.snippets, nil
+}
+
+// GetSnippets 获取全部SQL片段
+func (a *App) GetSnippets() ([]store.Snippet, error) {
+	snippets, err := a.snippetStore()
+	if err != nil {
+		return nil, err
+	}
+	return snippets.List(), nil
+}
+
+// SaveSnippet 保存SQL片段
+func (a *App) SaveSnippet(snippet store.Snippet) (store.Snippet, error) {
+	snippets, err := a.snippetStore()
+	if err != nil {
+		return snippet, err
+	}
+	return snippets.Save(snippet)
+}
+
+// DeleteSnippet 删除SQL片段
+func (a *App) DeleteSnippet(id string) error {
+	snippets, err := a.snippetStore()
+	if err != nil {
+		return err
+	}
+	return snippets.Delete(id)
+}
+
+// GetSnippetParams 获取SQL模板中的参数名，注释和字符串的写法按连接的数据库类型判断
+func (a *App) GetSnippetParams(config database.DatabaseConfig, sql string) []string {
+	return database.ParamNames(database.DialectOf(config.Type), sql)
+}
+
+// RunSnippet 绑定参数并执行SQL片段
+func (a *App) RunSnippet(config database.DatabaseConfig, dbName, id string, values map[string]interface{}) ([]map[string]string, error) {
+	snippets, err := a.snippetStore()
+	if err != nil {
+		return nil, err
+	}
+	snippet, err := snippets.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return a.ExecuteTemplate(config, dbName, snippet.SQL, values)
+}
+
+// ExecuteTemplate 将命名参数绑定为驱动参数后执行SQL
+func (a *App) ExecuteTemplate(config database.DatabaseConfig, dbName, sql string, values map[string]interface{}) ([]map[string]string, error) {
+	started := time.Now()
+	result, rows, err := a.executeTemplate(config, dbName, sql, values)
+	a.recordHistory(config, dbName, sql, started, rows, err)
+	return result, err
+}
+
+// executeTemplate 绑定参数并执行，返回结果集及返回或影响的行数
+func (a *App) executeTemplate(config database.DatabaseConfig, dbName, sql string, values map[string]interface{}) ([]map[string]string, int64, error) {
+	query, args, err := database.BindParams(database.DialectOf(config.Type), sql, values)
+	if err != nil {
+		return nil, 0, fmt.Errorf("绑定参数失败: %v", err)
+	}
+
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, 0, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, 0, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	if database.IsDML(database.DialectOf(config.Type), query) {
+		affected, err := execAffected(adapter, dbName, query, args)
+		if err != nil {
+			return nil, 0, err
+		}
+		return []map[string]string{}, affected, nil
+	}
+	result, err := adapter.ExecuteQueryArgs(dbName, query, args)
+	return result, int64(len(result)), err
+}
+
+// ExportSnippets 将片段导出为目录下的 .sql 文件，folder 为空时导出全部
+func (a *App) ExportSnippets(dir, folder string) (int, error) {
+	snippets, err := a.snippetStore()
+	if err != nil {
+		return 0, err
+	}
+	return snippets.Export(dir, folder)
+}
+
+// ImportSnippets 从目录导入 .sql 文件
+func (a *App) ImportSnippets(dir string) (int, error) {
+	snippets, err := a.snippetStore()
+	if err != nil {
+		return 0, err
+	}
+	return snippets.Import(dir)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"dbcat/database"
+)
+
+// connectSQLite 创建并连接 SQLite 适配器，用于 SQLite 专有的功能
+func connectSQLite(config database.DatabaseConfig) (*database.SQLiteAdapter, error) {
+	if config.Type != "sqlite" {
+		return nil, fmt.Errorf("该功能只支持 SQLite")
+	}
+	adapter := database.NewSQLiteAdapter(config)
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	return adapter, nil
+}
+
+// GetSQLiteDatabases 获取 main 及附加的数据库文件
+func (a *App) GetSQLiteDatabases(config database.DatabaseConfig) ([]database.AttachedDatabase, error) {
+	adapter, err := connectSQLite(config)
+	if err != nil {
+		return nil, err
+	}
+	defer adapter.Close()
+
+	return adapter.GetDatabaseFiles()
+}
+
+// AttachSQLiteDatabase 附加数据库文件，返回包含该文件的连接配置，需要由前端保存
+func (a *App) AttachSQLiteDatabase(config database.DatabaseConfig, name, path string, readOnly bool) (database.DatabaseConfig, error) {
+	adapter, err := connectSQLite(config)
+	if err != nil {
+		return config, err
+	}
+	defer adapter.Close()
+
+	if err := adapter.Attach(name, path, readOnly); err != nil {
+		return config, fmt.Errorf("附加数据库失败: %v", err)
+	}
+	return adapter.Config(), nil
+}
+
+// DetachSQLiteDatabase 取消附加数据库文件，返回新的连接配置
+func (a *App) DetachSQLiteDatabase(config database.DatabaseConfig, name string) (database.DatabaseConfig, error) {
+	adapter, err := connectSQLite(config)
+	if err != nil {
+		return config, err
+	}
+	defer adapter.Close()
+
+	if err := adapter.Detach(name); err != nil {
+		return config, fmt.Errorf("取消附加数据库失败: %v", err)
+	}
+	return adapter.Config(), nil
+}
+
+// SetSQLitePragma 修改 PRAGMA，返回新的连接配置（连接级 PRAGMA 保存在配置中）
+func (a *App) SetSQLitePragma(config database.DatabaseConfig, name, value string) (database.DatabaseConfig, error) {
+	adapter, err := connectSQLite(config)
+	if err != nil {
+		return config, err
+	}
+	defer adapter.Close()
+
+	if _, err := adapter.SetPragma(name, value); err != nil {
+		return config, fmt.Errorf("修改 PRAGMA 失败: %v", err)
+	}
+	return adapter.Config(), nil
+}
+
+// StartSQLiteBackup 在后台复制 SQLite 数据库到 dest，schema 为空时复制 main。
+// compact 为 true 时使用 VACUUM INTO 生成压缩后的文件，否则使用在线备份 API，返回任务ID
+func (a *App) StartSQLiteBackup(config database.DatabaseConfig, schema, dest string, compact bool) (string, error) {
+	if config.Type != "sqlite" {
+		return "", fmt.Errorf("该功能只支持 SQLite")
+	}
+	if dest == "" {
+		return "", fmt.Errorf("未选择备份文件")
+	}
+
+	return a.startJob("sqlite-backup", fmt.Sprintf("备份 %s", config.ProfileKey()), func(ctx context.Context, r *JobReporter) (string, error) {
+		adapter, err := connectSQLite(config)
+		if err != nil {
+			return "", err
+		}
+		defer adapter.Close()
+
+		if compact {
+			r.Message("正在执行 VACUUM INTO")
+			if err := adapter.VacuumInto(ctx, schema, dest); err != nil {
+				return "", err
+			}
+			return dest, nil
+		}
+		err = adapter.Backup(ctx, schema, dest, func(done, total int) {
+			r.Update(int64(done), int64(total), fmt.Sprintf("已复制 %d/%d 页", done, total))
+		})
+		if err != nil {
+			return "", err
+		}
+		return dest, nil
+	}), nil
+}
@@ -0,0 +1,262 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DefaultHistoryRetention 默认保留的历史记录条数
+const DefaultHistoryRetention = 5000
+
+// HistoryEntry 查询历史记录
+type HistoryEntry struct {
+	ID         int64  `json:"ID" db:"id"`
+	Profile    string `json:"Profile" db:"profile"`
+	Database   string `json:"Database" db:"database"`
+	SQL        string `json:"SQL" db:"sql"`
+	StartedAt  int64  `json:"StartedAt" db:"started_at"`
+	DurationMs int64  `json:"DurationMs" db:"duration_ms"`
+	Rows       int64  `json:"Rows" db:"rows"`
+	Error      string `json:"Error" db:"error"`
+}
+
+// HistoryFilter 历史记录查询条件，时间均为毫秒时间戳，0 表示不限制
+type HistoryFilter struct {
+	Keyword string `json:"Keyword"`
+	Profile string `json:"Profile"`
+	Since   int64  `json:"Since"`
+	Until   int64  `json:"Until"`
+	Offset  int    `json:"Offset"`
+	Limit   int    `json:"Limit"`
+}
+
+// HistoryStore 基于本地 SQLite 文件的查询历史存储
+type HistoryStore struct {
+	mu        sync.Mutex
+	db        *sqlx.DB
+	retention int
+}
+
+// DefaultDir 返回 dbcat 本地数据目录
+func DefaultDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "dbcat")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// OpenHistoryStore 打开（必要时创建）历史记录文件
+func OpenHistoryStore(path string) (*HistoryStore, error) {
+	db, err := sqlx.Connect("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			profile TEXT NOT NULL,
+			database TEXT NOT NULL DEFAULT '',
+			sql TEXT NOT NULL,
+			started_at INTEGER NOT NULL,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			rows INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_history_profile ON history(profile, started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_history_started ON history(started_at)`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS history_fts USING fts4(content="history", sql)`,
+		`CREATE TRIGGER IF NOT EXISTS history_ai AFTER INSERT ON history BEGIN
+			INSERT INTO history_fts(docid, sql) VALUES (new.id, new.sql);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS history_bd BEFORE DELETE ON history BEGIN
+			DELETE FROM history_fts WHERE docid = old.id;
+		END`,
+		`CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to init history store: %v", err)
+		}
+	}
+
+	s := &HistoryStore{db: db, retention: DefaultHistoryRetention}
+	var value string
+	err = db.Get(&value, "SELECT value FROM settings WHERE key = 'retention'")
+	if err == nil {
+		if n, convErr := strconv.Atoi(value); convErr == nil {
+			s.retention = n
+		}
+	} else if err != sql.ErrNoRows {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close 关闭历史记录文件
+func (s *HistoryStore) Close() error {
+	return s.db.Close()
+}
+
+// Add 写入一条历史记录并按保留上限清理旧记录
+func (s *HistoryStore) Add(entry HistoryEntry) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(
+		`INSERT INTO history (profile, database, sql, started_at, duration_ms, rows, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.Profile, entry.Database, entry.SQL, entry.StartedAt, entry.DurationMs, entry.Rows, entry.Error,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, s.prune()
+}
+
+// prune 删除超出保留上限的最旧记录
+func (s *HistoryStore) prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+	_, err := s.db.Exec(`
+		DELETE FROM history WHERE id IN (
+			SELECT id FROM history ORDER BY id DESC LIMIT -1 OFFSET ?
+		)`, s.retention)
+	return err
+}
+
+// Get 按ID获取历史记录
+func (s *HistoryStore) Get(id int64) (HistoryEntry, error) {
+	var entry HistoryEntry
+	err := s.db.Get(&entry, "SELECT * FROM history WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return entry, fmt.Errorf("history entry %d not found", id)
+	}
+	return entry, err
+}
+
+// Search 按关键字、连接和时间范围查询历史记录，结果按时间倒序
+func (s *HistoryStore) Search(filter HistoryFilter) ([]HistoryEntry, error) {
+	var where []string
+	var args []interface{}
+
+	if match := ftsQuery(filter.Keyword); match != "" {
+		where = append(where, "h.id IN (SELECT docid FROM history_fts WHERE history_fts MATCH ?)")
+		args = append(args, match)
+	}
+	if filter.Profile != "" {
+		where = append(where, "h.profile = ?")
+		args = append(args, filter.Profile)
+	}
+	if filter.Since > 0 {
+		where = append(where, "h.started_at >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Until > 0 {
+		where = append(where, "h.started_at < ?")
+		args = append(args, filter.Until)
+	}
+
+	query := "SELECT h.* FROM history h"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += " ORDER BY h.started_at DESC, h.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, filter.Offset)
+
+	entries := []HistoryEntry{}
+	if err := s.db.Select(&entries, query, args...); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Delete 删除指定的历史记录
+func (s *HistoryStore) Delete(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In("DELETE FROM history WHERE id IN (?)", ids)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(query, args...)
+	return err
+}
+
+// Clear 清空历史记录，profile 为空时清空全部
+func (s *HistoryStore) Clear(profile string) error {
+	if profile == "" {
+		_, err := s.db.Exec("DELETE FROM history")
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM history WHERE profile = ?", profile)
+	return err
+}
+
+// Retention 获取保留上限
+func (s *HistoryStore) Retention() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retention
+}
+
+// SetRetention 设置保留上限，0 表示不限制
+func (s *HistoryStore) SetRetention(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid retention: %d", n)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(
+		"INSERT INTO settings (key, value) VALUES ('retention', ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value",
+		strconv.Itoa(n),
+	)
+	if err != nil {
+		return err
+	}
+	s.retention = n
+	return s.prune()
+}
+
+// ftsQuery 将用户输入转换为 FTS MATCH 表达式，每个词按前缀匹配
+func ftsQuery(keyword string) string {
+	var terms []string
+	for _, word := range strings.Fields(keyword) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`*"`)
+	}
+	return strings.Join(terms, " ")
+}
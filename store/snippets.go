@@ -0,0 +1,279 @@
+package store
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snippet SQL片段，SQL 中可以使用 :name 或 ${name} 形式的参数
+type Snippet struct {
+	ID          string `json:"ID"`
+	Name        string `json:"Name"`
+	Folder      string `json:"Folder"`
+	Description string `json:"Description"`
+	SQL         string `json:"SQL"`
+	UpdatedAt   int64  `json:"UpdatedAt"`
+}
+
+// SnippetStore 基于本地 JSON 文件的片段库
+type SnippetStore struct {
+	mu       sync.Mutex
+	path     string
+	snippets []Snippet
+}
+
+// OpenSnippetStore 打开片段库文件，文件不存在时创建空库
+func OpenSnippetStore(path string) (*SnippetStore, error) {
+	s := &SnippetStore{path: path, snippets: []Snippet{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.snippets); err != nil {
+		return nil, fmt.Errorf("failed to parse snippets: %v", err)
+	}
+	return s, nil
+}
+
+// save 写回片段库文件，先写临时文件再替换
+func (s *SnippetStore) save() error {
+	data, err := json.MarshalIndent(s.snippets, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// List 按目录和名称排序返回全部片段
+func (s *SnippetStore) List() []Snippet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Snippet, len(s.snippets))
+	copy(list, s.snippets)
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Folder != list[j].Folder {
+			return list[i].Folder < list[j].Folder
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Get 按ID获取片段
+func (s *SnippetStore) Get(id string) (Snippet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, snippet := range s.snippets {
+		if snippet.ID == id {
+			return snippet, nil
+		}
+	}
+	return Snippet{}, fmt.Errorf("snippet %s not found", id)
+}
+
+// Save 新增或更新片段，ID 为空时视为新增
+func (s *SnippetStore) Save(snippet Snippet) (Snippet, error) {
+	if strings.TrimSpace(snippet.Name) == "" {
+		return snippet, fmt.Errorf("snippet name is required")
+	}
+	snippet.Folder = cleanFolder(snippet.Folder)
+	snippet.UpdatedAt = time.Now().UnixMilli()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.upsert(&snippet)
+	return snippet, s.save()
+}
+
+// upsert 按ID更新片段，不存在时追加
+func (s *SnippetStore) upsert(snippet *Snippet) {
+	if snippet.ID == "" {
+		snippet.ID = newID()
+	}
+	for i := range s.snippets {
+		if s.snippets[i].ID == snippet.ID {
+			s.snippets[i] = *snippet
+			return
+		}
+	}
+	s.snippets = append(s.snippets, *snippet)
+}
+
+// Delete 删除片段
+func (s *SnippetStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, snippet := range s.snippets {
+		if snippet.ID == id {
+			s.snippets = append(s.snippets[:i], s.snippets[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("snippet %s not found", id)
+}
+
+// Export 将片段导出为目录下的 .sql 文件，目录层级对应片段目录，folder 为空时导出全部
+func (s *SnippetStore) Export(dir, folder string) (int, error) {
+	folder = cleanFolder(folder)
+	count := 0
+	for _, snippet := range s.List() {
+		if folder != "" && snippet.Folder != folder && !strings.HasPrefix(snippet.Folder, folder+"/") {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(snippet.Folder))
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return count, err
+		}
+
+		var sb strings.Builder
+		sb.WriteString("-- name: " + snippet.Name + "\n")
+		if snippet.Description != "" {
+			for _, line := range strings.Split(snippet.Description, "\n") {
+				sb.WriteString("-- description: " + line + "\n")
+			}
+		}
+		sb.WriteString(strings.TrimRight(snippet.SQL, "\n") + "\n")
+
+		file := filepath.Join(target, safeFileName(snippet.Name)+".sql")
+		if err := os.WriteFile(file, []byte(sb.String()), 0o644); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Import 从目录导入 .sql 文件，同目录同名的片段会被覆盖
+func (s *SnippetStore) Import(dir string) (int, error) {
+	var imported []Snippet
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".sql") {
+			return nil
+		}
+		snippet, err := readSnippetFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		snippet.Folder = cleanFolder(filepath.ToSlash(rel))
+		imported = append(imported, snippet)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	for _, snippet := range imported {
+		for _, existing := range s.snippets {
+			if existing.Folder == snippet.Folder && existing.Name == snippet.Name {
+				snippet.ID = existing.ID
+				break
+			}
+		}
+		snippet.UpdatedAt = now
+		s.upsert(&snippet)
+	}
+	return len(imported), s.save()
+}
+
+// readSnippetFile 读取片段文件，开头的 -- name: / -- description: 注释作为元数据
+func readSnippetFile(path string) (Snippet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Snippet{}, err
+	}
+	defer f.Close()
+
+	snippet := Snippet{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	var description, body []string
+	header := true
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header {
+			trimmed := strings.TrimSpace(line)
+			if v, ok := strings.CutPrefix(trimmed, "-- name:"); ok {
+				snippet.Name = strings.TrimSpace(v)
+				continue
+			}
+			if v, ok := strings.CutPrefix(trimmed, "-- description:"); ok {
+				description = append(description, strings.TrimSpace(v))
+				continue
+			}
+			header = false
+		}
+		body = append(body, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Snippet{}, err
+	}
+	snippet.Description = strings.Join(description, "\n")
+	snippet.SQL = strings.TrimRight(strings.Join(body, "\n"), "\n")
+	return snippet, nil
+}
+
+// cleanFolder 规范化目录路径，统一使用 / 分隔
+func cleanFolder(folder string) string {
+	folder = strings.Trim(strings.ReplaceAll(folder, "\\", "/"), "/ ")
+	if folder == "" || folder == "." {
+		return ""
+	}
+	parts := strings.Split(folder, "/")
+	cleaned := parts[:0]
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" && part != "." && part != ".." {
+			cleaned = append(cleaned, part)
+		}
+	}
+	return strings.Join(cleaned, "/")
+}
+
+// safeFileName 替换文件名中不允许的字符
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+}
+
+// newID 生成随机ID
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dbcat/database"
+)
+
+// StartTransfer 在后台将源连接中的表复制到目标连接，返回任务ID
+func (a *App) StartTransfer(source, target database.DatabaseConfig, options database.TransferOptions) (string, error) {
+	if len(options.Tables) == 0 {
+		return "", fmt.Errorf("未选择需要传输的表")
+	}
+	title := fmt.Sprintf("传输 %d 张表 → %s", len(options.Tables), target.ProfileKey())
+	if len(options.Tables) == 1 {
+		title = fmt.Sprintf("传输 %s → %s", options.Tables[0].Name, target.ProfileKey())
+	}
+
+	return a.startJob("transfer", title, func(ctx context.Context, r *JobReporter) (string, error) {
+		factory := database.NewDBFactory()
+		src, err := factory.CreateAdapter(source)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer src.Close()
+		if err := src.Connect(); err != nil {
+			return "", fmt.Errorf("连接源数据库失败: %v", err)
+		}
+
+		dst, err := factory.CreateAdapter(target)
+		if err != nil {
+			return "", fmt.Errorf("创建数据库适配器失败: %v", err)
+		}
+		defer dst.Close()
+		if err := dst.Connect(); err != nil {
+			return "", fmt.Errorf("连接目标数据库失败: %v", err)
+		}
+
+		err = database.RunTransfer(ctx, src, source.Type, dst, target.Type, options, func(p database.TransferProgress) {
+			r.Update(p.Rows, p.TotalRows, transferSummary(p))
+		})
+		if options.CreateTable {
+			a.metadata.Invalidate(target)
+		}
+		return "", err
+	}), nil
+}
+
+// transferSummary 传输进度的说明文字，包含速度和预计剩余时间
+func transferSummary(p database.TransferProgress) string {
+	text := fmt.Sprintf("%d/%d 张表", p.TablesDone, p.TablesTotal)
+	if p.Table != "" {
+		text = fmt.Sprintf("正在传输 %s，%s", p.Table, text)
+	}
+	if p.RowsPerSecond > 0 {
+		text += fmt.Sprintf("，%.0f 行/秒", p.RowsPerSecond)
+	}
+	if p.ETASeconds >= 0 && p.Table != "" {
+		text += "，剩余约 " + (time.Duration(p.ETASeconds) * time.Second).String()
+	}
+	return text
+}
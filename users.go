@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+
+	"dbcat/database"
+)
+
+// GetUsers 获取数据库用户和角色
+func (a *App) GetUsers(config database.DatabaseConfig) ([]database.UserInfo, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.GetUsers()
+}
+
+// GetUserGrants 获取用户或角色的授权，host 只用于 MySQL
+func (a *App) GetUserGrants(config database.DatabaseConfig, name, host string) ([]database.GrantInfo, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %v", err)
+	}
+
+	return adapter.GetUserGrants(name, host)
+}
+
+// PreviewUserChanges 预览创建、修改、删除用户及授权将执行的 SQL
+func (a *App) PreviewUserChanges(config database.DatabaseConfig, changes []database.UserChange) (string, error) {
+	if err := a.fillCurrentUsers(config, changes); err != nil {
+		return "", err
+	}
+	var stmts []string
+	for _, change := range changes {
+		sqls, err := database.BuildUserChangeSQL(config.Type, change)
+		if err != nil {
+			return "", fmt.Errorf("生成 %s 语句失败: %v", change.Name, err)
+		}
+		stmts = append(stmts, sqls...)
+	}
+	return database.FormatStatements(stmts), nil
+}
+
+// ApplyUserChanges 依次执行用户变更，遇到错误时停止，返回已执行的变更数
+func (a *App) ApplyUserChanges(config database.DatabaseConfig, changes []database.UserChange) (int, error) {
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return 0, fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return 0, fmt.Errorf("连接数据库失败: %v", err)
+	}
+	if err := database.FillCurrentUsers(adapter, changes); err != nil {
+		return 0, fmt.Errorf("读取用户属性失败: %v", err)
+	}
+
+	for i, change := range changes {
+		if err := adapter.ApplyUserChange(change); err != nil {
+			return i, fmt.Errorf("执行 %s 的变更失败: %v", change.Name, err)
+		}
+	}
+	return len(changes), nil
+}
+
+// fillCurrentUsers 修改用户时需要修改前的属性，前端未提供时从数据库读取
+func (a *App) fillCurrentUsers(config database.DatabaseConfig, changes []database.UserChange) error {
+	needed := false
+	for _, change := range changes {
+		if change.Action == database.UserAlter && change.Current == nil {
+			needed = true
+		}
+	}
+	if !needed {
+		return nil
+	}
+
+	factory := database.NewDBFactory()
+	adapter, err := factory.CreateAdapter(config)
+	if err != nil {
+		return fmt.Errorf("创建数据库适配器失败: %v", err)
+	}
+	defer adapter.Close()
+
+	if err := adapter.Connect(); err != nil {
+		return fmt.Errorf("连接数据库失败: %v", err)
+	}
+	if err := database.FillCurrentUsers(adapter, changes); err != nil {
+		return fmt.Errorf("读取用户属性失败: %v", err)
+	}
+	return nil
+}